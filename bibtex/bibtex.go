@@ -0,0 +1,251 @@
+// Package bibtex parses BibTeX databases and converts the entries to references, so they can be used
+// to resolve citations.
+package bibtex
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Entry is a single BibTeX entry, like @article{key, ...}.
+type Entry struct {
+	Type   string            // lowercased entry type, i.e. "article"
+	Key    string            // citation key
+	Fields map[string]string // lowercased field names to their (cleaned up) values
+	Line   int               // line on which the entry starts
+}
+
+// ParseError is returned when a BibTeX database can't be parsed.
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (e *ParseError) Error() string { return fmt.Sprintf("bibtex: line %d: %s", e.Line, e.Message) }
+
+// months are the predefined month macros.
+var months = map[string]string{
+	"jan": "January", "feb": "February", "mar": "March", "apr": "April", "may": "May", "jun": "June",
+	"jul": "July", "aug": "August", "sep": "September", "oct": "October", "nov": "November", "dec": "December",
+}
+
+// Parse parses data and returns all the entries in it. @string macros are expanded, @comment and
+// @preamble are skipped.
+func Parse(data []byte) ([]*Entry, error) {
+	p := &parser{data: data, line: 1, macros: map[string]string{}}
+	for k, v := range months {
+		p.macros[k] = v
+	}
+	entries := []*Entry{}
+	for {
+		// Everything outside of an entry is a comment.
+		for p.i < len(p.data) && p.data[p.i] != '@' {
+			p.next()
+		}
+		if p.i >= len(p.data) {
+			return entries, nil
+		}
+		p.next()
+		e, err := p.entry()
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			entries = append(entries, e)
+		}
+	}
+}
+
+type parser struct {
+	data   []byte
+	i      int
+	line   int
+	macros map[string]string
+}
+
+func (p *parser) next() {
+	if p.data[p.i] == '\n' {
+		p.line++
+	}
+	p.i++
+}
+
+func (p *parser) errorf(format string, a ...interface{}) error {
+	return &ParseError{Line: p.line, Message: fmt.Sprintf(format, a...)}
+}
+
+func (p *parser) skipSpace() {
+	for p.i < len(p.data) && unicode.IsSpace(rune(p.data[p.i])) {
+		p.next()
+	}
+}
+
+// ident reads an entry type, field name, or macro name.
+func (p *parser) ident() string {
+	beg := p.i
+	for p.i < len(p.data) && !unicode.IsSpace(rune(p.data[p.i])) && !bytes.ContainsAny(p.data[p.i:p.i+1], `{}()=,#"`) {
+		p.next()
+	}
+	return string(p.data[beg:p.i])
+}
+
+// expect skips whitespace and checks the next character is c.
+func (p *parser) expect(c byte) error {
+	p.skipSpace()
+	if p.i >= len(p.data) {
+		return p.errorf("unexpected end of file, expected %q", c)
+	}
+	if p.data[p.i] != c {
+		return p.errorf("expected %q, got %q", c, p.data[p.i])
+	}
+	p.next()
+	return nil
+}
+
+// entry parses an entry, p.i is just after the '@'. A nil entry is returned for @string, @comment and @preamble.
+func (p *parser) entry() (*Entry, error) {
+	line := p.line
+	typ := strings.ToLower(p.ident())
+	if typ == "" {
+		return nil, p.errorf("expected entry type after @")
+	}
+	p.skipSpace()
+	if p.i >= len(p.data) || (p.data[p.i] != '{' && p.data[p.i] != '(') {
+		return nil, p.errorf("expected '{' or '(' after @%s", typ)
+	}
+	closing := byte('}')
+	if p.data[p.i] == '(' {
+		closing = ')'
+	}
+
+	switch typ {
+	case "comment", "preamble":
+		_, err := p.braced(p.data[p.i], closing)
+		return nil, err
+	case "string":
+		p.next()
+		for {
+			p.skipSpace()
+			if p.i < len(p.data) && p.data[p.i] == closing {
+				p.next()
+				return nil, nil
+			}
+			name, value, err := p.field()
+			if err != nil {
+				return nil, err
+			}
+			p.macros[name] = value
+		}
+	}
+
+	p.next()
+	p.skipSpace()
+	beg := p.i
+	for p.i < len(p.data) && p.data[p.i] != ',' && p.data[p.i] != closing {
+		p.next()
+	}
+	if p.i >= len(p.data) {
+		return nil, p.errorf("unexpected end of file in @%s", typ)
+	}
+	e := &Entry{Type: typ, Key: strings.TrimSpace(string(p.data[beg:p.i])), Fields: map[string]string{}, Line: line}
+	if e.Key == "" {
+		return nil, p.errorf("@%s without a key", typ)
+	}
+
+	for {
+		p.skipSpace()
+		if p.i >= len(p.data) {
+			return nil, p.errorf("unexpected end of file in @%s{%s", typ, e.Key)
+		}
+		switch p.data[p.i] {
+		case closing:
+			p.next()
+			return e, nil
+		case ',':
+			p.next()
+			continue
+		}
+		name, value, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		e.Fields[name] = clean(value)
+	}
+}
+
+// field parses name = value.
+func (p *parser) field() (string, string, error) {
+	name := strings.ToLower(p.ident())
+	if name == "" {
+		return "", "", p.errorf("expected field name")
+	}
+	if err := p.expect('='); err != nil {
+		return "", "", err
+	}
+	value := ""
+	for {
+		p.skipSpace()
+		if p.i >= len(p.data) {
+			return "", "", p.errorf("unexpected end of file in field %q", name)
+		}
+		switch c := p.data[p.i]; {
+		case c == '{':
+			v, err := p.braced('{', '}')
+			if err != nil {
+				return "", "", err
+			}
+			value += v
+		case c == '"':
+			v, err := p.braced('"', '"')
+			if err != nil {
+				return "", "", err
+			}
+			value += v
+		default:
+			id := p.ident()
+			if id == "" {
+				return "", "", p.errorf("expected value for field %q", name)
+			}
+			if v, ok := p.macros[strings.ToLower(id)]; ok {
+				value += v
+			} else {
+				value += id // numbers, and undefined macros
+			}
+		}
+		p.skipSpace()
+		if p.i < len(p.data) && p.data[p.i] == '#' {
+			p.next()
+			continue
+		}
+		return name, value, nil
+	}
+}
+
+// braced returns the text between open and closing, p.i is at open. Braces inside must be balanced.
+func (p *parser) braced(open, closing byte) (string, error) {
+	line := p.line
+	p.next()
+	beg := p.i
+	depth := 0
+	for p.i < len(p.data) {
+		c := p.data[p.i]
+		switch {
+		case c == closing && depth == 0:
+			v := string(p.data[beg:p.i])
+			p.next()
+			return v, nil
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '\\':
+			p.next() // skip escaped character
+		}
+		if p.i < len(p.data) {
+			p.next()
+		}
+	}
+	return "", &ParseError{Line: line, Message: fmt.Sprintf("unterminated %q", open)}
+}
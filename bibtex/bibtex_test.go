@@ -0,0 +1,100 @@
+package bibtex
+
+import (
+	"testing"
+)
+
+const db = `This is a comment.
+@string{acm = "ACM Press"}
+@comment{ ignore {this} }
+@article{Knuth84,
+  author = {Donald E. Knuth and Lamport, Leslie},
+  title = {Literate {P}rogramming},
+  journal = "The Computer Journal",
+  year = 1984, month = may,
+  volume = 27, number = {2}, pages = {97--111},
+  doi = {10.1093/comjnl/27.2.97},
+  publisher = acm # " Inc."
+}
+@inproceedings(erdos,
+  author = {Erd{\H o}s, Paul and G{\"o}del, Kurt and Johannes van der Waals and others},
+  title = {On {\em something}},
+  booktitle = {Proc. Stuff},
+  year = {1950}
+)
+`
+
+func TestParse(t *testing.T) {
+	entries, err := Parse([]byte(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected %d entries, got %d", 2, len(entries))
+	}
+	e := entries[0]
+	if e.Type != "article" || e.Key != "Knuth84" || e.Line != 4 {
+		t.Errorf("expected %s %s on line %d, got %s %s on line %d", "article", "Knuth84", 4, e.Type, e.Key, e.Line)
+	}
+	for field, want := range map[string]string{
+		"title":     "Literate Programming",
+		"month":     "May",
+		"pages":     "97–111",
+		"publisher": "ACM Press Inc.",
+		"volume":    "27",
+	} {
+		if got := e.Fields[field]; got != want {
+			t.Errorf("expected %s to be %q, got %q", field, want, got)
+		}
+	}
+	if got := entries[1].Fields["title"]; got != "On something" {
+		t.Errorf("expected title to be %q, got %q", "On something", got)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := Parse([]byte("@article{x,\n  title = {unbalanced {,\n}\n"))
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected a *ParseError, got %v", err)
+	}
+	if perr.Line != 2 {
+		t.Errorf("expected error on line %d, got %d", 2, perr.Line)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	refs, err := References([]byte(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := refs["knuth84"]
+	if !ok {
+		t.Fatalf("expected reference %q", "knuth84")
+	}
+	if r.Target != "https://doi.org/10.1093/comjnl/27.2.97" {
+		t.Errorf("expected target from DOI, got %q", r.Target)
+	}
+	if len(r.Series) != 1 || r.Series[0].Name != "DOI" {
+		t.Errorf("expected DOI seriesInfo, got %v", r.Series)
+	}
+	if want := "The Computer Journal, vol. 27, no. 2, pp. 97–111, ACM Press Inc."; len(r.RefContent) != 1 || r.RefContent[0] != want {
+		t.Errorf("expected refcontent %q, got %v", want, r.RefContent)
+	}
+
+	authors := refs["erdos"].Front.Authors
+	want := []struct{ full, surname, initials string }{
+		{"Paul Erdős", "Erdős", "P."},
+		{"Kurt Gödel", "Gödel", "K."},
+		{"Johannes van der Waals", "van der Waals", "J."},
+	}
+	if len(authors) != len(want) {
+		t.Fatalf("expected %d authors, got %d", len(want), len(authors))
+	}
+	for i, w := range want {
+		a := authors[i]
+		if a.Fullname != w.full || a.Surname != w.surname || a.Initials != w.initials {
+			t.Errorf("expected author %q %q %q, got %q %q %q", w.full, w.surname, w.initials, a.Fullname, a.Surname, a.Initials)
+		}
+	}
+}
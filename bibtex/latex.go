@@ -0,0 +1,92 @@
+package bibtex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// accents maps a LaTeX accent command and the letter it is applied to, to the accented letter.
+var accents = map[string]map[rune]rune{
+	`"`: {'a': 'ä', 'e': 'ë', 'i': 'ï', 'o': 'ö', 'u': 'ü', 'y': 'ÿ', 'A': 'Ä', 'E': 'Ë', 'I': 'Ï', 'O': 'Ö', 'U': 'Ü'},
+	`'`: {'a': 'á', 'e': 'é', 'i': 'í', 'o': 'ó', 'u': 'ú', 'y': 'ý', 'c': 'ć', 'n': 'ń', 's': 'ś', 'z': 'ź', 'A': 'Á', 'E': 'É', 'I': 'Í', 'O': 'Ó', 'U': 'Ú', 'Y': 'Ý'},
+	"`": {'a': 'à', 'e': 'è', 'i': 'ì', 'o': 'ò', 'u': 'ù', 'A': 'À', 'E': 'È', 'I': 'Ì', 'O': 'Ò', 'U': 'Ù'},
+	`^`: {'a': 'â', 'e': 'ê', 'i': 'î', 'o': 'ô', 'u': 'û', 'A': 'Â', 'E': 'Ê', 'I': 'Î', 'O': 'Ô', 'U': 'Û'},
+	`~`: {'a': 'ã', 'n': 'ñ', 'o': 'õ', 'A': 'Ã', 'N': 'Ñ', 'O': 'Õ'},
+	`c`: {'c': 'ç', 's': 'ş', 'C': 'Ç', 'S': 'Ş'},
+	`v`: {'c': 'č', 'e': 'ě', 'n': 'ň', 'r': 'ř', 's': 'š', 'z': 'ž', 'C': 'Č', 'E': 'Ě', 'N': 'Ň', 'R': 'Ř', 'S': 'Š', 'Z': 'Ž'},
+	`H`: {'o': 'ő', 'u': 'ű', 'O': 'Ő', 'U': 'Ű'},
+	`r`: {'a': 'å', 'u': 'ů', 'A': 'Å', 'U': 'Ů'},
+}
+
+// symbols are LaTeX commands that produce a single character.
+var symbols = map[string]string{
+	"ss": "ß", "o": "ø", "O": "Ø", "aa": "å", "AA": "Å", "ae": "æ", "AE": "Æ", "oe": "œ", "OE": "Œ",
+	"l": "ł", "L": "Ł", "i": "ı", "&": "&", "%": "%", "$": "$", "#": "#", "_": "_", "{": "{", "}": "}",
+	"textendash": "–", "textemdash": "—", "LaTeX": "LaTeX", "TeX": "TeX",
+}
+
+// clean converts the LaTeX in a field value to plain text: accents and escapes are converted, braces are
+// removed and all whitespace is collapsed to a single space.
+func clean(s string) string {
+	var b strings.Builder
+	r := []rune(s)
+	for i := 0; i < len(r); i++ {
+		switch r[i] {
+		case '{', '}':
+			continue
+		case '~':
+			b.WriteRune(' ')
+			continue
+		case '-':
+			// -- is an en dash, --- an em dash.
+			if i+2 < len(r) && r[i+1] == '-' && r[i+2] == '-' {
+				b.WriteRune('—')
+				i += 2
+				continue
+			}
+			if i+1 < len(r) && r[i+1] == '-' {
+				b.WriteRune('–')
+				i++
+				continue
+			}
+		case '\\':
+			if i+1 >= len(r) {
+				continue
+			}
+			j := i + 1
+			if unicode.IsLetter(r[j]) {
+				for j < len(r) && unicode.IsLetter(r[j]) {
+					j++
+				}
+			} else {
+				j++
+			}
+			cmd := string(r[i+1 : j])
+			if acc, ok := accents[cmd]; ok {
+				// \"o, \"{o}, {\"o} and \c c are all used.
+				k := j
+				for k < len(r) && (r[k] == '{' || r[k] == ' ') {
+					k++
+				}
+				if k < len(r) {
+					if a, ok := acc[r[k]]; ok {
+						b.WriteRune(a)
+						i = k
+						continue
+					}
+				}
+			}
+			if sym, ok := symbols[cmd]; ok {
+				b.WriteString(sym)
+			}
+			// Unknown commands (\emph, \textit, ...) are dropped, their argument is kept.
+			i = j - 1
+			if j < len(r) && r[j] == ' ' && unicode.IsLetter(r[j-1]) {
+				i = j // the space terminating a command is not part of the text
+			}
+			continue
+		}
+		b.WriteRune(r[i])
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
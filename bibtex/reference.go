@@ -0,0 +1,193 @@
+package bibtex
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+)
+
+// References parses data and returns the references keyed by the lowercased citation key.
+func References(data []byte) (map[string]*reference.Reference, error) {
+	entries, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]*reference.Reference, len(entries))
+	for _, e := range entries {
+		refs[strings.ToLower(e.Key)] = e.Reference()
+	}
+	return refs, nil
+}
+
+// Reference converts e to a reference. The authors (or, if there are none, the editors) become the
+// reference's authors, a DOI becomes a seriesInfo and the target when there is no URL. Where the entry was
+// published is summarized in a single refcontent.
+func (e *Entry) Reference() *reference.Reference {
+	f := e.Fields
+	r := &reference.Reference{
+		Anchor: e.Key,
+		Target: f["url"],
+		Front:  reference.Front{Title: f["title"]},
+	}
+
+	r.Front.Authors = authors(f["author"], "")
+	if len(r.Front.Authors) == 0 {
+		r.Front.Authors = authors(f["editor"], "editor")
+	}
+	if len(r.Front.Authors) == 0 {
+		org := f["organization"]
+		if org == "" {
+			org = f["institution"]
+		}
+		if org != "" {
+			r.Front.Authors = []reference.Author{{Organization: &reference.Organization{Value: org}}}
+		}
+	}
+
+	if f["year"] != "" {
+		r.Front.Date = &reference.Date{Year: f["year"], Month: month(f["month"]), Day: f["day"]}
+	}
+
+	if doi := f["doi"]; doi != "" {
+		r.Series = append(r.Series, reference.SeriesInfo{Name: "DOI", Value: doi})
+		if r.Target == "" {
+			r.Target = "https://doi.org/" + doi
+		}
+	}
+	if isbn := f["isbn"]; isbn != "" {
+		r.Series = append(r.Series, reference.SeriesInfo{Name: "ISBN", Value: isbn})
+	}
+
+	if c := e.refcontent(); c != "" {
+		r.RefContent = []string{c}
+	}
+	if note := f["note"]; note != "" {
+		r.Annotation = []string{note}
+	}
+	return r
+}
+
+// refcontent returns the "where was this published" part of the entry.
+func (e *Entry) refcontent() string {
+	f := e.Fields
+	parts := []string{}
+	add := func(prefix, v string) {
+		if v != "" {
+			parts = append(parts, prefix+v)
+		}
+	}
+
+	switch e.Type {
+	case "article":
+		add("", f["journal"])
+	case "inproceedings", "incollection", "conference":
+		add("In ", f["booktitle"])
+	case "techreport":
+		add("", f["institution"])
+		add("", f["type"])
+	case "phdthesis":
+		add("Ph.D. thesis, ", f["school"])
+	case "mastersthesis":
+		add("Master's thesis, ", f["school"])
+	case "misc", "online":
+		add("", f["howpublished"])
+	}
+	add("vol. ", f["volume"])
+	add("no. ", f["number"])
+	if p := f["pages"]; p != "" {
+		if strings.ContainsAny(p, "-–,") {
+			add("pp. ", p)
+		} else {
+			add("p. ", p)
+		}
+	}
+	add("", f["publisher"])
+	return strings.Join(parts, ", ")
+}
+
+// authors splits a BibTeX name list into authors, names are separated by "and" and are written as
+// "First Last", "Last, First" or "Last, Jr, First".
+func authors(names, role string) []reference.Author {
+	if names == "" {
+		return nil
+	}
+	as := []reference.Author{}
+	for _, name := range splitNames(names) {
+		if name == "others" {
+			continue
+		}
+		first, last := "", name
+		switch parts := strings.Split(name, ","); len(parts) {
+		case 1:
+			// "First von Last", the last word is the surname, and lowercased words before it are part of it too.
+			words := strings.Fields(name)
+			j := len(words) - 1
+			for j > 0 && isLower(words[j-1]) {
+				j--
+			}
+			first, last = strings.Join(words[:j], " "), strings.Join(words[j:], " ")
+		case 2:
+			last, first = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		default:
+			last, first = strings.TrimSpace(parts[0])+", "+strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2])
+		}
+		a := reference.Author{Surname: last, Initials: initials(first), Role: role}
+		a.Fullname = strings.TrimSpace(first + " " + last)
+		as = append(as, a)
+	}
+	return as
+}
+
+// splitNames splits on " and ", ignoring the case of "and".
+func splitNames(names string) []string {
+	words := strings.Fields(names)
+	out := []string{}
+	cur := []string{}
+	for _, w := range words {
+		if strings.EqualFold(w, "and") {
+			out = append(out, strings.Join(cur, " "))
+			cur = cur[:0]
+			continue
+		}
+		cur = append(cur, w)
+	}
+	return append(out, strings.Join(cur, " "))
+}
+
+func isLower(w string) bool {
+	for _, r := range w {
+		return r >= 'a' && r <= 'z'
+	}
+	return false
+}
+
+// initials returns the initials of the first names, i.e. "John Ronald" becomes "J. R.".
+func initials(first string) string {
+	is := []string{}
+	for _, w := range strings.Fields(first) {
+		for _, p := range strings.Split(w, "-") {
+			r := []rune(p)
+			if len(r) == 0 {
+				continue
+			}
+			is = append(is, string(r[0])+".")
+		}
+	}
+	return strings.Join(is, " ")
+}
+
+// month returns the full English month name of m, which may be a number or a name.
+func month(m string) string {
+	if m == "" {
+		return ""
+	}
+	if i, err := strconv.Atoi(m); err == nil && i >= 1 && i <= 12 {
+		return time.Month(i).String()
+	}
+	if full, ok := months[strings.ToLower(m)]; ok {
+		return full
+	}
+	return m
+}
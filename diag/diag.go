@@ -0,0 +1,120 @@
+// Package diag collects the diagnostics (errors, warnings and informational messages) that are
+// emitted while parsing and rendering a document.
+package diag
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+)
+
+// Severity is the severity of a diagnostic.
+type Severity int
+
+// Diagnostic severities.
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return "severity" + strconv.Itoa(int(s))
+}
+
+// Diagnostic is a single problem found in a document.
+type Diagnostic struct {
+	Severity Severity
+	Code     string // short identifier for the problem, i.e. "include-read"
+	Message  string
+
+	File   string // source file, may be empty
+	Line   int    // 1-based line number, 0 if not known
+	Column int    // 1-based column number, 0 if not known
+}
+
+// String returns the diagnostic as "file:line:column: severity: message [code]", elements that are
+// not known are omitted.
+func (d Diagnostic) String() string {
+	s := ""
+	if d.File != "" {
+		s = d.File
+		if d.Line > 0 {
+			s += ":" + strconv.Itoa(d.Line)
+			if d.Column > 0 {
+				s += ":" + strconv.Itoa(d.Column)
+			}
+		}
+		s += ": "
+	}
+	s += d.Severity.String() + ": " + d.Message
+	if d.Code != "" {
+		s += " [" + d.Code + "]"
+	}
+	return s
+}
+
+// Diagnostics collects diagnostics. It is safe for concurrent use. All methods work on a nil
+// *Diagnostics, diagnostics added to it are logged with log.Print and then discarded.
+type Diagnostics struct {
+	mu   sync.Mutex
+	list []Diagnostic
+}
+
+// New returns a new and empty Diagnostics.
+func New() *Diagnostics { return &Diagnostics{} }
+
+// Add adds d.
+func (ds *Diagnostics) Add(d Diagnostic) {
+	if ds == nil {
+		log.Print(d.String())
+		return
+	}
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.list = append(ds.list, d)
+}
+
+// Errorf adds an error diagnostic for file with code.
+func (ds *Diagnostics) Errorf(code, file string, format string, a ...interface{}) {
+	ds.Add(Diagnostic{Severity: Error, Code: code, File: file, Message: fmt.Sprintf(format, a...)})
+}
+
+// Warningf adds a warning diagnostic for file with code.
+func (ds *Diagnostics) Warningf(code, file string, format string, a ...interface{}) {
+	ds.Add(Diagnostic{Severity: Warning, Code: code, File: file, Message: fmt.Sprintf(format, a...)})
+}
+
+// Infof adds an informational diagnostic for file with code.
+func (ds *Diagnostics) Infof(code, file string, format string, a ...interface{}) {
+	ds.Add(Diagnostic{Severity: Info, Code: code, File: file, Message: fmt.Sprintf(format, a...)})
+}
+
+// List returns a copy of all collected diagnostics in the order they were added.
+func (ds *Diagnostics) List() []Diagnostic {
+	if ds == nil {
+		return nil
+	}
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return append([]Diagnostic(nil), ds.list...)
+}
+
+// Has returns true if a diagnostic with a severity of at least sev was added.
+func (ds *Diagnostics) Has(sev Severity) bool {
+	for _, d := range ds.List() {
+		if d.Severity >= sev {
+			return true
+		}
+	}
+	return false
+}
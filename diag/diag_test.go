@@ -0,0 +1,42 @@
+package diag
+
+import "testing"
+
+func TestDiagnosticString(t *testing.T) {
+	tests := []struct {
+		d      Diagnostic
+		expect string
+	}{
+		{Diagnostic{Severity: Error, Code: "include-read", Message: "oops", File: "draft.md", Line: 12, Column: 3}, "draft.md:12:3: error: oops [include-read]"},
+		{Diagnostic{Severity: Warning, Message: "oops", File: "draft.md", Line: 12}, "draft.md:12: warning: oops"},
+		{Diagnostic{Severity: Info, Message: "oops", Column: 3}, "info: oops"},
+	}
+	for _, tc := range tests {
+		if got := tc.d.String(); got != tc.expect {
+			t.Errorf("expected %q, got %q", tc.expect, got)
+		}
+	}
+}
+
+func TestDiagnosticsHas(t *testing.T) {
+	d := New()
+	d.Infof("info", "", "just so you know")
+	if d.Has(Warning) {
+		t.Errorf("expected no warnings")
+	}
+	d.Warningf("warn", "", "be careful")
+	if !d.Has(Warning) {
+		t.Errorf("expected warnings")
+	}
+	if d.Has(Error) {
+		t.Errorf("expected no errors")
+	}
+	if l := len(d.List()); l != 2 {
+		t.Errorf("expected %d diagnostics, got %d", 2, l)
+	}
+
+	var nilDiag *Diagnostics
+	if nilDiag.Has(Info) {
+		t.Errorf("expected nil Diagnostics to be empty")
+	}
+}
@@ -11,75 +11,111 @@ func New(language string) Lang {
 	// The keys must be in all lower case for normalized lookup.
 	l.m = map[string]Term{
 		"en": {
+			Addresses:    "Authors' Addresses",
 			And:          "and",
+			EtAl:         "et al.",
 			Of:           "of",
 			Authors:      "Authors",
 			Bibliography: "Bibliography",
+			Contents:     "Table of Contents",
 			Footnotes:    "Footnotes",
 			Index:        "Index",
 			WrittenBy:    "Written by",
 			See:          "see",
+			Appendix:     "appendix",
 			Section:      "section",
+			Figure:       "figure",
+			Table:        "table",
 			UseCounter:   "use counter",
 			UseTitle:     "use title",
 		},
 		"nl": {
+			Addresses:    "Adressen van de auteurs",
 			And:          "en",
+			EtAl:         "et al.",
 			Of:           "of",
 			Bibliography: "Bibliografie",
+			Contents:     "Inhoudsopgave",
 			Footnotes:    "Voetnoten",
 			Index:        "Index",
 			WrittenBy:    "Geschreven door",
 			See:          "zie",
+			Appendix:     "appendix",
 			Section:      "sectie",
+			Figure:       "figuur",
+			Table:        "tabel",
 			UseCounter:   "gebruik nummer",
 			UseTitle:     "gebruik titel",
 		},
 		"de": {
+			Addresses:    "Adressen der Autoren",
 			And:          "und",
+			EtAl:         "et al.",
 			Of:           "von",
 			Bibliography: "Literaturverzeichnis",
+			Contents:     "Inhaltsverzeichnis",
 			Footnotes:    "Fußnoten",
 			Index:        "Index",
 			WrittenBy:    "Geschrieben von",
 			See:          "siehe",
+			Appendix:     "Anhang",
 			Section:      "Abschnitt",
+			Figure:       "Abbildung",
+			Table:        "Tabelle",
 			UseCounter:   "Zähler benutzen",
 			UseTitle:     "Titel benutzen",
 		},
 		"ja": {
+			Addresses:    "(no translation!)",
 			And:          "(no translation!)",
+			EtAl:         "et al.",
 			Of:           "(no translation!)",
 			Bibliography: "参考文献",
+			Contents:     "目次",
 			Footnotes:    "脚注",
 			Index:        "索引",
 			WrittenBy:    "(no translation!)",
 			See:          "(no translation!)",
+			Appendix:     "付録",
 			Section:      "(no translation!)",
+			Figure:       "図",
+			Table:        "表",
 			UseCounter:   "(no translation!)",
 			UseTitle:     "(no translation!)",
 		},
 		"zh-cn": {
+			Addresses:    "(no translation!)",
 			And:          "(no translation!)",
+			EtAl:         "et al.",
 			Of:           "(no translation!)",
 			Bibliography: "参考文献",
+			Contents:     "目录",
 			Footnotes:    "注释",
 			Index:        "索引",
 			WrittenBy:    "(no translation!)",
 			See:          "(no translation!)",
+			Appendix:     "附录",
 			Section:      "(no translation!)",
+			Figure:       "图",
+			Table:        "表",
 			UseCounter:   "(no translation!)",
 			UseTitle:     "(no translation!)",
 		},
 		"zh-tw": {
+			Addresses:    "(no translation!)",
 			And:          "(no translation!)",
+			EtAl:         "et al.",
 			Of:           "(no translation!)",
 			Bibliography: "參考文獻",
+			Contents:     "目錄",
 			Footnotes:    "註釋",
 			Index:        "索引",
 			WrittenBy:    "(no translation!)",
 			See:          "(no translation!)",
+			Appendix:     "附錄",
 			Section:      "(no translation!)",
+			Figure:       "圖",
+			Table:        "表",
 			UseCounter:   "(no translation!)",
 			UseTitle:     "(no translation!)",
 		},
@@ -98,16 +134,22 @@ type Lang struct {
 // Term contains the specific terms for translation.
 type Term struct {
 	And          string
+	EtAl         string
 	Of           string
 	Authors      string
+	Addresses    string // heading of the authors' addresses
 	Bibliography string
+	Contents     string // heading of the table of contents
 	Footnotes    string
 	Index        string
 	WrittenBy    string
 
 	// for cross references
 	See        string
+	Appendix   string
 	Section    string
+	Figure     string
+	Table      string
 	UseCounter string
 	UseTitle   string
 }
@@ -120,12 +162,18 @@ func (l Lang) Field(f string) string {
 	switch strings.ToLower(f) {
 	case "and":
 		return m.And
+	case "etal":
+		return m.EtAl
 	case "of":
 		return m.Of
-	case "Authors":
+	case "authors":
 		return m.Authors
+	case "addresses":
+		return m.Addresses
 	case "bibliography":
 		return m.Bibliography
+	case "contents":
+		return m.Contents
 	case "footnotes":
 		return m.Footnotes
 	case "index":
@@ -134,8 +182,14 @@ func (l Lang) Field(f string) string {
 		return m.WrittenBy
 	case "see":
 		return m.See
+	case "appendix":
+		return m.Appendix
 	case "section":
 		return m.Section
+	case "figure":
+		return m.Figure
+	case "table":
+		return m.Table
 	case "usecounter":
 		return m.UseCounter
 	case "usetitle":
@@ -146,12 +200,18 @@ func (l Lang) Field(f string) string {
 
 func (l Lang) Footnotes() string    { return l.Field("footnotes") }
 func (l Lang) Bibliography() string { return l.Field("bibliography") }
+func (l Lang) Contents() string     { return l.Field("contents") }
 func (l Lang) Index() string        { return l.Field("index") }
 func (l Lang) Authors() string      { return l.Field("authors") }
+func (l Lang) Addresses() string    { return l.Field("addresses") }
 func (l Lang) And() string          { return l.Field("and") }
+func (l Lang) EtAl() string         { return l.Field("etal") }
 func (l Lang) Of() string           { return l.Field("of") }
 func (l Lang) WrittenBy() string    { return l.Field("writtenby") }
 func (l Lang) See() string          { return l.Field("see") }
+func (l Lang) Appendix() string     { return l.Field("appendix") }
 func (l Lang) Section() string      { return l.Field("section") }
+func (l Lang) Figure() string       { return l.Field("figure") }
+func (l Lang) Table() string        { return l.Field("table") }
 func (l Lang) UseCounter() string   { return l.Field("usecounter") }
 func (l Lang) UseTitle() string     { return l.Field("usetitle") }
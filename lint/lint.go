@@ -0,0 +1,296 @@
+// Package lint checks a parsed mmark document for problems that would otherwise only be found when
+// running xml2rfc on the resulting XML.
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+	"github.com/mmarkdown/mmark/v2/mparser"
+	"github.com/mmarkdown/mmark/v2/render/xml"
+)
+
+// Rule is a single lint check.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    diag.Severity
+
+	check func(l *linter)
+}
+
+// Rules holds all the lint rules, in the order they are run.
+var Rules = []Rule{
+	{"citation-unknown", "citation without a matching <reference> block, BibTeX entry or known series prefix", diag.Error, citationUnknown},
+	{"xref-unknown", "cross reference to an anchor that does not exist", diag.Error, xrefUnknown},
+	{"anchor-duplicate", "anchor that is defined more than once", diag.Error, anchorDuplicate},
+	{"no-backmatter", "citations without a {backmatter} to place the bibliography in", diag.Error, noBackmatter},
+	{"bcp14-boilerplate", "BCP 14 keywords used without the BCP 14 boilerplate", diag.Warning, bcp14Boilerplate},
+	{"reference-unused", "<reference> block that is never cited", diag.Warning, referenceUnused},
+}
+
+// Options control the linter.
+type Options struct {
+	// Disabled holds the IDs of the rules that should not be run.
+	Disabled map[string]bool
+
+	// File is used as the file name for problems for which no source span is known.
+	File string
+
+	// BibTeX holds the references from BibTeX files keyed by lowercased citation key, may be nil.
+	BibTeX map[string]*reference.Reference
+
+	// Sources holds the source spans of the nodes in the document, may be nil.
+	Sources *mast.Sources
+
+	// Diagnostics receives the problems found, the rule ID is used as the diagnostic's code.
+	Diagnostics *diag.Diagnostics
+}
+
+// Lint runs all enabled rules on doc. Doc should be parsed without adding the bibliography or index.
+func Lint(doc ast.Node, opts Options) {
+	l := &linter{doc: doc, opts: opts}
+	l.gather()
+	for _, r := range Rules {
+		if opts.Disabled[r.ID] {
+			continue
+		}
+		l.rule = r
+		r.check(l)
+	}
+}
+
+// Known returns true if id is the ID of a lint rule.
+func Known(id string) bool {
+	for _, r := range Rules {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+type linter struct {
+	doc  ast.Node
+	opts Options
+	rule Rule
+
+	title      *mast.Title
+	citations  []*ast.Citation
+	xrefs      []*ast.CrossReference
+	references map[string]*mast.ReferenceBlock // lowercased anchor -> reference block
+	backmatter bool
+}
+
+// gather walks the document once and records everything the rules need.
+func (l *linter) gather() {
+	l.references = map[string]*mast.ReferenceBlock{}
+	ast.WalkFunc(l.doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch n := node.(type) {
+		case *mast.Title:
+			if l.title == nil {
+				l.title = n
+			}
+		case *ast.Citation:
+			l.citations = append(l.citations, n)
+		case *ast.CrossReference:
+			l.xrefs = append(l.xrefs, n)
+		case *mast.ReferenceBlock:
+			if anchor := mparser.ReferenceAnchor(n.Literal); anchor != nil {
+				l.references[strings.ToLower(string(anchor))] = n
+			}
+		case *ast.DocumentMatter:
+			if n.Matter == ast.DocumentMatterBack {
+				l.backmatter = true
+			}
+		}
+		return ast.GoToNext
+	})
+}
+
+// report reports a problem found by the current rule at node.
+func (l *linter) report(node ast.Node, format string, a ...interface{}) {
+	d := diag.Diagnostic{Severity: l.rule.Severity, Code: l.rule.ID, File: l.opts.File, Message: fmt.Sprintf(format, a...)}
+	if span, ok := l.opts.Sources.Span(node); ok {
+		d.File, d.Line, d.Column = span.File, span.Line, span.Column
+	}
+	l.opts.Diagnostics.Add(d)
+}
+
+// destinations returns the citation destinations of c that end up in the bibliography, RFC2119@BCP14 is
+// returned as two destinations.
+func (l *linter) destinations(c *ast.Citation) []string {
+	dests := []string{}
+	for _, d := range c.Destination {
+		if xml.AuthorFromTitle(d, l.title) != nil || xml.ContactFromTitle(d, l.title) != nil {
+			continue
+		}
+		if n := bytes.Index(d, []byte("@")); n > 0 && len(d[n+1:]) > 2 {
+			dests = append(dests, string(d[n+1:]))
+			d = d[:n]
+		}
+		dests = append(dests, string(d))
+	}
+	return dests
+}
+
+func citationUnknown(l *linter) {
+	for _, c := range l.citations {
+		for _, d := range l.destinations(c) {
+			if _, ok := l.references[strings.ToLower(d)]; ok {
+				continue
+			}
+			if _, ok := l.opts.BibTeX[strings.ToLower(d)]; ok {
+				continue
+			}
+			if !mparser.KnownSeries([]byte(d)) {
+				l.report(c, "Citation %q has no <reference> and is not a known series", d)
+			}
+		}
+	}
+}
+
+// anchors returns all the anchors defined in the document, and the nodes they are defined on.
+func (l *linter) anchors() (ids []string, nodes []ast.Node) {
+	ast.WalkFunc(l.doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		id := anchor(node)
+		if id == "" {
+			return ast.GoToNext
+		}
+		// A caption figure's anchor is also set on its child.
+		if f, ok := node.GetParent().(*ast.CaptionFigure); ok && anchor(f) == id {
+			return ast.GoToNext
+		}
+		ids = append(ids, id)
+		nodes = append(nodes, node)
+		return ast.GoToNext
+	})
+	return ids, nodes
+}
+
+// anchor returns the anchor defined on node, or the empty string if there is none.
+func anchor(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.Heading:
+		if n.HeadingID != "" {
+			return n.HeadingID
+		}
+	case *ast.CaptionFigure:
+		if n.HeadingID != "" {
+			return n.HeadingID
+		}
+	case *mast.ReferenceBlock:
+		return string(mparser.ReferenceAnchor(n.Literal))
+	}
+	if a := mast.AttributeFromNode(node); a != nil {
+		return string(a.ID)
+	}
+	return ""
+}
+
+func xrefUnknown(l *linter) {
+	ids, _ := l.anchors()
+	known := map[string]bool{}
+	for _, id := range ids {
+		known[id] = true
+	}
+	for _, x := range l.xrefs {
+		if !known[string(x.Destination)] {
+			l.report(x, "Cross reference to unknown anchor %q", x.Destination)
+		}
+	}
+}
+
+func anchorDuplicate(l *linter) {
+	ids, nodes := l.anchors()
+	seen := map[string]bool{}
+	for i, id := range ids {
+		if seen[id] {
+			l.report(nodes[i], "Anchor %q is defined more than once", id)
+		}
+		seen[id] = true
+	}
+}
+
+func noBackmatter(l *linter) {
+	if l.backmatter {
+		return
+	}
+	for _, c := range l.citations {
+		if len(l.destinations(c)) > 0 {
+			l.report(c, "Citations found, but no {backmatter} to place the bibliography in")
+			return
+		}
+	}
+}
+
+func bcp14Boilerplate(l *linter) {
+	var (
+		keyword     ast.Node
+		boilerplate bool
+	)
+	ast.WalkFunc(l.doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch n := node.(type) {
+		case *ast.Strong:
+			if t, ok := ast.GetFirstChild(n).(*ast.Text); ok && keyword == nil && xml.Is2119(t.Literal) {
+				keyword = n
+			}
+		case *ast.Paragraph:
+			// The boilerplate may be wrapped, or have "BCP 14" in a link.
+			if strings.Contains(mast.PlainText(n), "BCP 14") {
+				boilerplate = true
+			}
+		}
+		return ast.GoToNext
+	})
+	if keyword == nil {
+		return
+	}
+
+	cited := map[string]bool{}
+	for _, c := range l.citations {
+		for _, d := range l.destinations(c) {
+			cited[d] = true
+		}
+	}
+	if boilerplate && cited["RFC2119"] && cited["RFC8174"] {
+		return
+	}
+	l.report(keyword, "BCP 14 keywords are used, but the BCP 14 boilerplate citing RFC 2119 and RFC 8174 is missing")
+}
+
+func referenceUnused(l *linter) {
+	cited := map[string]bool{}
+	for _, c := range l.citations {
+		for _, d := range l.destinations(c) {
+			cited[strings.ToLower(d)] = true
+		}
+	}
+	// xrefs can also point to references.
+	for _, x := range l.xrefs {
+		cited[strings.ToLower(string(x.Destination))] = true
+	}
+	ast.WalkFunc(l.doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if n, ok := node.(*mast.ReferenceBlock); ok && entering {
+			anchor := mparser.ReferenceAnchor(n.Literal)
+			if anchor != nil && !cited[strings.ToLower(string(anchor))] {
+				l.report(n, "Reference %q is never cited", anchor)
+			}
+		}
+		return ast.GoToNext
+	})
+}
@@ -0,0 +1,85 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mparser"
+)
+
+var draft = []byte(`%%%
+title = "x"
+%%%
+
+# Intro {#intro}
+
+Text [@foo] [@RFC1234] and (#nothere) (#intro). You **MUST** do it.
+
+# Again {#intro}
+
+<reference anchor='bar' target=''>
+<front><title>x</title></front>
+</reference>
+`)
+
+func lint(t *testing.T, draft []byte, disabled map[string]bool) map[string]diag.Diagnostic {
+	t.Helper()
+	init := mparser.NewInitial("")
+	init.Track(draft)
+	p := parser.NewWithExtensions(mparser.Extensions)
+	p.Opts = parser.Options{ParserHook: init.Hook, ReadIncludeFn: init.ReadInclude}
+	doc := markdown.Parse(draft, p)
+	init.Spans(doc)
+
+	d := diag.New()
+	Lint(doc, Options{Disabled: disabled, File: "draft.md", Sources: init.Sources, Diagnostics: d})
+	found := map[string]diag.Diagnostic{}
+	for _, x := range d.List() {
+		found[x.Code] = x
+	}
+	return found
+}
+
+func TestLint(t *testing.T) {
+	found := lint(t, draft, nil)
+	for _, r := range Rules {
+		if _, ok := found[r.ID]; !ok {
+			t.Errorf("expected rule %q to trigger", r.ID)
+		}
+	}
+	if x := found["xref-unknown"]; x.Line != 7 || x.Column != 30 {
+		t.Errorf("expected xref-unknown at 7:30, got %d:%d", x.Line, x.Column)
+	}
+}
+
+func TestLintDisabled(t *testing.T) {
+	found := lint(t, draft, map[string]bool{"bcp14-boilerplate": true})
+	if _, ok := found["bcp14-boilerplate"]; ok {
+		t.Errorf("expected rule %q to be disabled", "bcp14-boilerplate")
+	}
+	if len(found) != len(Rules)-1 {
+		t.Errorf("expected %d rules to trigger, got %d", len(Rules)-1, len(found))
+	}
+}
+
+func TestLintBCP14Wrapped(t *testing.T) {
+	const doc = `%%%
+title = "x"
+%%%
+
+# Intro
+
+The key words "**MUST**", "**MUST NOT**", etc. in this document are to be interpreted as described in BCP
+14 [@!RFC2119] [@!RFC8174] when, and only when, they appear in all capitals, as shown here.
+
+You **MUST** do it, see [BCP
+14](https://www.rfc-editor.org/info/bcp14).
+
+{backmatter}
+`
+	if x, ok := lint(t, []byte(doc), nil)["bcp14-boilerplate"]; ok {
+		t.Errorf("expected no %q warning, got %v", "bcp14-boilerplate", x)
+	}
+}
@@ -24,6 +24,7 @@ type BibliographyItem struct {
 	Anchor []byte
 	Type   ast.CitationTypes
 
-	Reference      *reference.Reference // parsed reference XML
-	ReferenceGroup []byte               // raw, unparsed reference group  XML
+	Reference      *reference.Reference      // parsed reference XML
+	ReferenceGroup *reference.ReferenceGroup // parsed reference group XML
+	Raw            []byte                    // raw reference (group) XML that failed to parse, only used for XML output
 }
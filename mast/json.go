@@ -0,0 +1,463 @@
+package mast
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// JSONVersion is the version of the JSON schema written by ToJSON. It is increased when the schema changes
+// in a way that isn't backwards compatible.
+const JSONVersion = 1
+
+// The JSON schema of an AST is:
+//
+//	{"version": 1, "document": NODE}
+//
+// where NODE is an object with the following members, which are left out when empty:
+//
+//	"type"      the node type: "Paragraph", "Heading", "Title", "BibliographyItem", etc.
+//	"literal"   the literal text of the node, i.e. the text of a Text node or the code of a CodeBlock
+//	"content"   the content of the node, only set for some block level nodes
+//	"attribute" the node's attribute: {"id": ID, "classes": [CLASS, ...], "attrs": {KEY: VALUE, ...}}
+//	"data"      the node type specific fields, keyed by their Go name, i.e. {"Level": 1, "HeadingID": "intro"}
+//	"children"  the child nodes
+//
+// Byte slices are written as strings, structs (like TitleData or a Reference) as objects keyed by their
+// field names and time.Time as RFC 3339 text. A footnote's link to its text is written as "Footnote": true,
+// it's linked up again when the JSON is read.
+
+// jsonTypes are the node types that can be written to and read from JSON, keyed by their name.
+var jsonTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, n := range []ast.Node{
+		&ast.Document{}, &ast.DocumentMatter{}, &ast.BlockQuote{}, &ast.Aside{}, &ast.List{}, &ast.ListItem{},
+		&ast.Paragraph{}, &ast.Math{}, &ast.MathBlock{}, &ast.Heading{}, &ast.HorizontalRule{}, &ast.Emph{},
+		&ast.Strong{}, &ast.Del{}, &ast.Link{}, &ast.CrossReference{}, &ast.Citation{}, &ast.Image{}, &ast.Text{},
+		&ast.HTMLBlock{}, &ast.CodeBlock{}, &ast.Softbreak{}, &ast.Hardbreak{}, &ast.NonBlockingSpace{},
+		&ast.Code{}, &ast.HTMLSpan{}, &ast.Table{}, &ast.TableCell{}, &ast.TableHeader{}, &ast.TableBody{},
+		&ast.TableRow{}, &ast.TableFooter{}, &ast.Caption{}, &ast.CaptionFigure{}, &ast.Callout{}, &ast.Index{},
+		&ast.Subscript{}, &ast.Superscript{}, &ast.Footnotes{},
+
+		&Title{}, &Authors{}, &Bibliography{}, &BibliographyItem{}, &BibliographyWrapper{}, &ReferenceBlock{},
+		&DocumentIndex{}, &IndexLetter{}, &IndexItem{}, &IndexSubItem{}, &IndexLink{}, &TableOfContents{},
+		&TableOfContentsEntry{},
+	} {
+		t := reflect.TypeOf(n).Elem()
+		jsonTypes[t.Name()] = t
+	}
+}
+
+// jsonNode is a node as written to JSON.
+type jsonNode struct {
+	Type      string                 `json:"type"`
+	Literal   string                 `json:"literal,omitempty"`
+	Content   string                 `json:"content,omitempty"`
+	Attribute *jsonAttribute         `json:"attribute,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Children  []*jsonNode            `json:"children,omitempty"`
+}
+
+type jsonAttribute struct {
+	ID      string            `json:"id,omitempty"`
+	Classes []string          `json:"classes,omitempty"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+type jsonDocument struct {
+	Version  int       `json:"version"`
+	Document *jsonNode `json:"document"`
+}
+
+// ToJSON returns the AST rooted at doc as indented JSON.
+func ToJSON(doc ast.Node) ([]byte, error) {
+	n, err := toJSONNode(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(jsonDocument{Version: JSONVersion, Document: n}, "", "  ")
+}
+
+// FromJSON returns the AST from data, which must have been written by ToJSON, or follow the same schema.
+func FromJSON(data []byte) (ast.Node, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	doc := jsonDocument{}
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != JSONVersion {
+		return nil, fmt.Errorf("unsupported JSON AST version: %d", doc.Version)
+	}
+	if doc.Document == nil {
+		return nil, fmt.Errorf("no document in JSON AST")
+	}
+	node, err := fromJSONNode(doc.Document)
+	if err != nil {
+		return nil, err
+	}
+	linkFootnotes(node)
+	return node, nil
+}
+
+func toJSONNode(node ast.Node) (*jsonNode, error) {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Ptr || jsonTypes[v.Elem().Type().Name()] != v.Elem().Type() {
+		return nil, fmt.Errorf("node type %T can't be written to JSON", node)
+	}
+	n := &jsonNode{Type: v.Elem().Type().Name()}
+
+	var attr *ast.Attribute
+	if c := node.AsContainer(); c != nil {
+		n.Literal, n.Content, attr = string(c.Literal), string(c.Content), c.Attribute
+	} else if l := node.AsLeaf(); l != nil {
+		n.Literal, n.Content, attr = string(l.Literal), string(l.Content), l.Attribute
+	}
+	if attr != nil && (len(attr.ID) > 0 || len(attr.Classes) > 0 || len(attr.Attrs) > 0) {
+		n.Attribute = &jsonAttribute{ID: string(attr.ID)}
+		for _, c := range attr.Classes {
+			n.Attribute.Classes = append(n.Attribute.Classes, string(c))
+		}
+		if len(attr.Attrs) > 0 {
+			n.Attribute.Attrs = map[string]string{}
+			for k, a := range attr.Attrs {
+				n.Attribute.Attrs[k] = string(a)
+			}
+		}
+	}
+
+	data, err := toJSONFields(v.Elem())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", n.Type, err)
+	}
+	if len(data) > 0 {
+		n.Data = data
+	}
+
+	for _, c := range node.GetChildren() {
+		child, err := toJSONNode(c)
+		if err != nil {
+			return nil, err
+		}
+		n.Children = append(n.Children, child)
+	}
+	return n, nil
+}
+
+// toJSONFields returns the fields of the node struct v that aren't empty. The embedded Container or Leaf
+// is skipped, it's part of the node itself, and a node embedded by pointer (IndexItem's *ast.Index) is
+// written as an object.
+func toJSONFields(v reflect.Value) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	for i := 0; i < v.NumField(); i++ {
+		f, fv := v.Type().Field(i), v.Field(i)
+		if !f.IsExported() || f.Type == containerType || f.Type == leafType || fv.IsZero() {
+			continue
+		}
+		if f.Type == nodeType {
+			// Link.Footnote, the footnote is in the tree already.
+			data[f.Name] = true
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Ptr && f.Type.Implements(nodeType) {
+			fields, err := toJSONFields(fv.Elem())
+			if err != nil {
+				return nil, err
+			}
+			data[f.Name] = fields
+			continue
+		}
+		x, err := toJSONValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", f.Name, err)
+		}
+		data[f.Name] = x
+	}
+	return data, nil
+}
+
+var (
+	containerType       = reflect.TypeOf(ast.Container{})
+	leafType            = reflect.TypeOf(ast.Leaf{})
+	nodeType            = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	byteSliceType       = reflect.TypeOf([]byte(nil))
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// toJSONValue converts v to a value that encoding/json writes in the JSON schema: byte slices become strings
+// and structs objects keyed by field name.
+func toJSONValue(v reflect.Value) (interface{}, error) {
+	if v.Type() == byteSliceType {
+		return string(v.Bytes()), nil
+	}
+	if v.Type().Implements(textMarshalerType) && v.Kind() != reflect.Ptr {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText() // time.Time is written as RFC 3339
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.Bool, reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return v.Interface(), nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return toJSONValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			x, err := toJSONValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			s[i] = x
+		}
+		return s, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map with %s keys", v.Type().Key())
+		}
+		m := map[string]interface{}{}
+		for _, k := range v.MapKeys() {
+			x, err := toJSONValue(v.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			m[k.String()] = x
+		}
+		return m, nil
+	case reflect.Struct:
+		m := map[string]interface{}{}
+		for i := 0; i < v.NumField(); i++ {
+			f, fv := v.Type().Field(i), v.Field(i)
+			if !f.IsExported() || fv.IsZero() {
+				continue
+			}
+			x, err := toJSONValue(fv)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", f.Name, err)
+			}
+			m[f.Name] = x
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func fromJSONNode(n *jsonNode) (ast.Node, error) {
+	t, ok := jsonTypes[n.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown node type %q", n.Type)
+	}
+	v := reflect.New(t)
+	// Allocate the node embedded by pointer, the Container (or Leaf) of an IndexLink is its *ast.Link's.
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Anonymous && f.Type.Kind() == reflect.Ptr && f.Type.Implements(nodeType) {
+			v.Elem().Field(i).Set(reflect.New(f.Type.Elem()))
+		}
+	}
+	node := v.Interface().(ast.Node)
+
+	for name, x := range n.Data {
+		f, ok := t.FieldByName(name)
+		if !ok || !f.IsExported() || len(f.Index) > 1 {
+			return nil, fmt.Errorf("%s: unknown field %q", n.Type, name)
+		}
+		fv := v.Elem().FieldByIndex(f.Index)
+		if f.Type == nodeType {
+			continue // Link.Footnote, set by linkFootnotes.
+		}
+		if err := fromJSONValue(x, fv); err != nil {
+			return nil, fmt.Errorf("%s: %s: %s", n.Type, name, err)
+		}
+	}
+
+	var attr *ast.Attribute
+	if n.Attribute != nil {
+		attr = &ast.Attribute{ID: bytesOrNil(n.Attribute.ID), Attrs: map[string][]byte{}}
+		for _, c := range n.Attribute.Classes {
+			attr.Classes = append(attr.Classes, []byte(c))
+		}
+		for k, a := range n.Attribute.Attrs {
+			attr.Attrs[k] = []byte(a)
+		}
+	}
+	if c := node.AsContainer(); c != nil {
+		c.Literal, c.Content, c.Attribute = bytesOrNil(n.Literal), bytesOrNil(n.Content), attr
+	} else if l := node.AsLeaf(); l != nil {
+		l.Literal, l.Content, l.Attribute = bytesOrNil(n.Literal), bytesOrNil(n.Content), attr
+		if len(n.Children) > 0 {
+			return nil, fmt.Errorf("%s: leaf node can't have children", n.Type)
+		}
+	}
+
+	for _, c := range n.Children {
+		child, err := fromJSONNode(c)
+		if err != nil {
+			return nil, err
+		}
+		ast.AppendChild(node, child)
+	}
+	return node, nil
+}
+
+func bytesOrNil(s string) []byte {
+	if s == "" {
+		return nil
+	}
+	return []byte(s)
+}
+
+// fromJSONValue sets v to x, as decoded by encoding/json with UseNumber.
+func fromJSONValue(x interface{}, v reflect.Value) error {
+	if x == nil {
+		return nil
+	}
+	if v.Type() == byteSliceType {
+		s, ok := x.(string)
+		if !ok {
+			return fmt.Errorf("expected string, got %T", x)
+		}
+		v.SetBytes([]byte(s))
+		return nil
+	}
+	if v.Type().Implements(textMarshalerType) && v.Kind() != reflect.Ptr && v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		s, ok := x.(string)
+		if !ok {
+			return fmt.Errorf("expected string, got %T", x)
+		}
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, ok := x.(bool)
+		if !ok {
+			return fmt.Errorf("expected boolean, got %T", x)
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.String:
+		s, ok := x.(string)
+		if !ok {
+			return fmt.Errorf("expected string, got %T", x)
+		}
+		v.SetString(s)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := x.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected number, got %T", x)
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := x.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected number, got %T", x)
+		}
+		i, err := n.Int64()
+		if err != nil || i < 0 {
+			return fmt.Errorf("invalid unsigned number %s", n)
+		}
+		v.SetUint(uint64(i))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, ok := x.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected number, got %T", x)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+		return nil
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := fromJSONValue(x, p.Elem()); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	case reflect.Slice:
+		a, ok := x.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected array, got %T", x)
+		}
+		s := reflect.MakeSlice(v.Type(), len(a), len(a))
+		for i := range a {
+			if err := fromJSONValue(a[i], s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Map:
+		o, ok := x.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected object, got %T", x)
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(o))
+		for k, e := range o {
+			ev := reflect.New(v.Type().Elem()).Elem()
+			if err := fromJSONValue(e, ev); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), ev)
+		}
+		v.Set(m)
+		return nil
+	case reflect.Struct:
+		o, ok := x.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected object, got %T", x)
+		}
+		// Sorted, so errors are reported in a stable order.
+		names := make([]string, 0, len(o))
+		for k := range o {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			f, ok := v.Type().FieldByName(name)
+			if !ok || !f.IsExported() {
+				return fmt.Errorf("unknown field %q", name)
+			}
+			if err := fromJSONValue(o[name], v.FieldByIndex(f.Index)); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// linkFootnotes sets the Footnote of the links to footnotes to the footnote's list item, these have the same
+// reference (the footnote's ID).
+func linkFootnotes(doc ast.Node) {
+	items := map[string]ast.Node{}
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if item, ok := node.(*ast.ListItem); ok && entering && len(item.RefLink) > 0 {
+			items[string(item.RefLink)] = item
+		}
+		return ast.GoToNext
+	})
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if link, ok := node.(*ast.Link); ok && entering && link.NoteID > 0 {
+			link.Footnote = items[string(link.Destination)]
+		}
+		return ast.GoToNext
+	})
+}
@@ -0,0 +1,71 @@
+package mast_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/pipeline"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../testdata/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []pipeline.Format{pipeline.FormatXML, pipeline.FormatHTML, pipeline.FormatText} {
+		for _, f := range files {
+			input, err := os.ReadFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := pipeline.Options{Format: format, Flags: pipeline.CommonFlags, FileName: f, Diagnostics: diag.New()}
+			doc := pipeline.Parse(input, opts)
+			// Rendering may change the AST, so convert it first.
+			data, err := mast.ToJSON(doc)
+			if err != nil {
+				t.Fatalf("%s: %s", f, err)
+			}
+			want, err := pipeline.Render(doc, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			doc, err = mast.FromJSON(data)
+			if err != nil {
+				t.Fatalf("%s: %s", f, err)
+			}
+			got, err := pipeline.Render(doc, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("format %d: %s: rendering differs after JSON round trip, got\n%s\nwant\n%s", format, f, got, want)
+			}
+		}
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`{"version": 2, "document": {"type": "Document"}}`, "unsupported JSON AST version"},
+		{`{"version": 1}`, "no document"},
+		{`{"version": 1, "document": {"type": "Unknown"}}`, `unknown node type "Unknown"`},
+		{`{"version": 1, "document": {"type": "Heading", "data": {"Level": "one"}}}`, "Heading: Level: expected number"},
+		{`{"version": 1, "document": {"type": "Heading", "data": {"Color": 1}}}`, `unknown field "Color"`},
+		{`{"version": 1, "document": {"type": "Text", "children": [{"type": "Text"}]}}`, "leaf node can't have children"},
+	}
+	for _, tc := range tests {
+		_, err := mast.FromJSON([]byte(tc.input))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("expected error %q for %s, got %v", tc.err, tc.input, err)
+		}
+	}
+}
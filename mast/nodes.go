@@ -3,6 +3,7 @@ package mast
 import (
 	"bytes"
 	"sort"
+	"strings"
 
 	"github.com/gomarkdown/markdown/ast"
 )
@@ -20,6 +21,53 @@ func MoveChildren(a, b ast.Node) {
 	}
 }
 
+// ChildIndex returns the index of node in its parent's children.
+func ChildIndex(node ast.Node) int {
+	for i, c := range node.GetParent().GetChildren() {
+		if c == node {
+			return i
+		}
+	}
+	return 0
+}
+
+// FigureID returns the ID of the figure: the one from the caption, or the one set with an attribute on the
+// figure or its content.
+func FigureID(node *ast.CaptionFigure) string {
+	if node.HeadingID != "" {
+		return node.HeadingID
+	}
+	if id := Attribute(node, "id"); len(id) > 0 {
+		return string(id)
+	}
+	if c := ast.GetFirstChild(node); c != nil {
+		return string(Attribute(c, "id"))
+	}
+	return ""
+}
+
+// PlainText returns the text in node, without any markup. Index items are skipped and whitespace is collapsed.
+func PlainText(node ast.Node) string {
+	buf := &strings.Builder{}
+	ast.WalkFunc(node, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch node := node.(type) {
+		case *ast.Text:
+			buf.Write(node.Literal)
+		case *ast.Code:
+			buf.Write(node.Literal)
+		case *ast.Softbreak, *ast.Hardbreak, *ast.NonBlockingSpace:
+			buf.WriteString(" ")
+		case *ast.Index:
+			return ast.SkipChildren
+		}
+		return ast.GoToNext
+	})
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
 // Some attribute helper functions.
 
 // AttributeFromNode returns the attribute from the node, if it was there was one.
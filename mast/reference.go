@@ -2,7 +2,8 @@ package mast
 
 import "github.com/gomarkdown/markdown/ast"
 
-// ReferenceBlock represents markdown reference node.
+// ReferenceBlock represents markdown reference node. The Literal holds the reference as XML, the Content
+// holds the source as it was found in the document (XML or TOML).
 type ReferenceBlock struct {
 	ast.Leaf
 }
@@ -0,0 +1,39 @@
+package reference
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BibXML returns the directory and file name of the reference for anchor, in the layout used by
+// https://bib.ietf.org/public/rfc/ and xml2rfc's reference library, i.e. "RFC2119" returns "bibxml" and
+// "reference.RFC.2119.xml". For anchors that aren't an RFC, I-D, W3C, BCP or STD document, empty strings are
+// returned. An I-D anchor may include a version after a '#': "I-D.ietf-foo#02".
+func BibXML(anchor string) (dir, file string) {
+	switch {
+	case strings.HasPrefix(anchor, "RFC"):
+		return "bibxml", fmt.Sprintf("reference.RFC.%s.xml", anchor[3:])
+
+	case strings.HasPrefix(anchor, "W3C."):
+		return "bibxml4", fmt.Sprintf("reference.W3C.%s.xml", anchor[4:])
+
+	case strings.HasPrefix(anchor, "BCP"):
+		return "bibxml9", fmt.Sprintf("reference.BCP.%s.xml", anchor[3:])
+
+	case strings.HasPrefix(anchor, "STD"):
+		return "bibxml9", fmt.Sprintf("reference.STD.%s.xml", anchor[3:])
+
+	case strings.HasPrefix(anchor, "I-D."):
+		// no version: https://bib.ietf.org/public/rfc/bibxml3/reference.I-D.brzozowski-dhc-dhcvp6-leasequery.xml
+		//
+		// with version: https://bib.ietf.org/public/rfc/bibxml3/reference.I-D.draft-brzozowski-dhc-dhcvp6-leasequery-00.xml
+		//
+		// rewrite # to - and we have our link, and also include "draft-" before it.
+		draft := anchor[4:]
+		if hash := strings.Index(draft, "#"); hash > 0 {
+			draft = "draft-" + draft[:hash] + "-" + draft[hash+1:]
+		}
+		return "bibxml3", fmt.Sprintf("reference.I-D.%s.xml", draft)
+	}
+	return "", ""
+}
@@ -82,3 +82,11 @@ type Reference struct {
 	RefContent []string     `xml:"refcontent,omitempty"`
 	Annotation []string     `xml:"annotation,omitempty"`
 }
+
+// ReferenceGroup is the entire <referencegroup> structure, used for BCPs and STDs, see RFC 7991, Section 2.40.
+type ReferenceGroup struct {
+	XMLName    xml.Name    `xml:"referencegroup"`
+	Anchor     string      `xml:"anchor,attr"`
+	Target     string      `xml:"target,attr,omitempty"`
+	References []Reference `xml:"reference"`
+}
@@ -60,3 +60,23 @@ func TestReferenceDate(t *testing.T) {
 		t.Errorf("expected\n%s\ngot\n%s", expect, str)
 	}
 }
+
+func TestBibXML(t *testing.T) {
+	tests := []struct {
+		anchor, dir, file string
+	}{
+		{"RFC2119", "bibxml", "reference.RFC.2119.xml"},
+		{"BCP14", "bibxml9", "reference.BCP.14.xml"},
+		{"STD69", "bibxml9", "reference.STD.69.xml"},
+		{"W3C.REC-xml", "bibxml4", "reference.W3C.REC-xml.xml"},
+		{"I-D.ietf-foo", "bibxml3", "reference.I-D.ietf-foo.xml"},
+		{"I-D.ietf-foo#02", "bibxml3", "reference.I-D.draft-ietf-foo-02.xml"},
+		{"pandoc", "", ""},
+	}
+	for _, tc := range tests {
+		dir, file := BibXML(tc.anchor)
+		if dir != tc.dir || file != tc.file {
+			t.Errorf("BibXML(%q): want %s/%s, got %s/%s", tc.anchor, tc.dir, tc.file, dir, file)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package mast
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/diag"
+)
+
+// Span is the location in a source file a node originates from. When includes are used, File is
+// the included file, not the initial document.
+type Span struct {
+	File   string
+	Line   int // 1-based line number
+	Column int // 1-based column number
+}
+
+// IsZero returns true if s is not set.
+func (s Span) IsZero() bool { return s.Line == 0 }
+
+// String returns the span as "file:line:column", or "file:line" when the column is not known.
+func (s Span) String() string {
+	if s.IsZero() {
+		return s.File
+	}
+	x := s.File + ":" + strconv.Itoa(s.Line)
+	if s.Column > 0 {
+		x += ":" + strconv.Itoa(s.Column)
+	}
+	return x
+}
+
+// Sources records the span for nodes in a document. It is safe for concurrent use.
+type Sources struct {
+	mu sync.RWMutex
+	m  map[ast.Node]Span
+}
+
+// NewSources returns a new and empty Sources.
+func NewSources() *Sources { return &Sources{m: make(map[ast.Node]Span)} }
+
+// Set sets the span for node.
+func (s *Sources) Set(node ast.Node, span Span) {
+	if s == nil || node == nil || span.IsZero() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[node] = span
+}
+
+// Span returns the span of node. If node has no span itself, the span of the nearest parent that has
+// one is returned. Index nodes return the span of the *ast.Index they are created from.
+func (s *Sources) Span(node ast.Node) (Span, bool) {
+	if s == nil {
+		return Span{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for node != nil {
+		if span, ok := s.m[node]; ok {
+			return span, true
+		}
+		switch i := node.(type) {
+		case *IndexItem:
+			if span, ok := s.m[i.Index]; ok {
+				return span, true
+			}
+		case *IndexSubItem:
+			if span, ok := s.m[i.Index]; ok {
+				return span, true
+			}
+		}
+		node = node.GetParent()
+	}
+	return Span{}, false
+}
+
+// Diagnostic returns a diagnostic with code for node, it is located at node's span. When that isn't known file is
+// used.
+func (s *Sources) Diagnostic(node ast.Node, sev diag.Severity, code, file string, format string, a ...interface{}) diag.Diagnostic {
+	d := diag.Diagnostic{Severity: sev, Code: code, File: file, Message: fmt.Sprintf(format, a...)}
+	if span, ok := s.Span(node); ok {
+		d.File, d.Line, d.Column = span.File, span.Line, span.Column
+	}
+	return d
+}
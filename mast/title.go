@@ -51,6 +51,37 @@ type TitleData struct {
 	Contact   []Contact
 
 	Language string
+
+	Bibliography  []string // BibTeX files used to resolve citations.
+	CitationStyle string   // Citation style for HTML and manual page bibliographies: "ietf", "ieee" or "author-year".
+}
+
+// statusToName translates the status to the name used in the document header.
+var statusToName = map[string]string{
+	"full-standard": "Standards Track",
+	"standard":      "Standards Track",
+	"informational": "Informational",
+	"experimental":  "Experimental",
+	"bcp":           "Best Current Practice",
+	"historic":      "Historic",
+}
+
+// Draft returns true if the document is an Internet-Draft, i.e. not an RFC.
+func (t *TitleData) Draft() bool { return t.SeriesInfo.Name != "RFC" }
+
+// StatusName returns the name of the document's status as used in the document header, i.e. "Standards Track".
+func (t *TitleData) StatusName() string { return statusToName[t.SeriesInfo.Status] }
+
+// DateString returns the document's date as shown in the document header, "October 2026", with the day if long
+// is true and the document is a draft. It returns the empty string if there is no date.
+func (t *TitleData) DateString(long bool) string {
+	if t.Date.IsZero() {
+		return ""
+	}
+	if long && t.Draft() {
+		return t.Date.Format("2 January 2006")
+	}
+	return t.Date.Format("January 2006")
 }
 
 type Link struct {
@@ -0,0 +1,19 @@
+package mast
+
+import "github.com/gomarkdown/markdown/ast"
+
+// TableOfContents represents the table of contents, its children are the TableOfContentsEntry nodes of the
+// top level sections.
+type TableOfContents struct {
+	ast.Container
+}
+
+// TableOfContentsEntry is a section in the table of contents, its Literal holds the section's title as plain
+// text. The children are the entries of the subsections.
+type TableOfContentsEntry struct {
+	ast.Container
+
+	Destination []byte // ID of the section's heading
+	Level       int    // level of the section's heading
+	Unnumbered  bool   // the section isn't numbered
+}
@@ -1,39 +1,106 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"html/template"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/ast"
-	"github.com/gomarkdown/markdown/html"
-	"github.com/gomarkdown/markdown/parser"
-	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/lint"
 	"github.com/mmarkdown/mmark/v2/mast"
 	"github.com/mmarkdown/mmark/v2/mparser"
-	"github.com/mmarkdown/mmark/v2/render/man"
+	"github.com/mmarkdown/mmark/v2/pipeline"
 	"github.com/mmarkdown/mmark/v2/render/mhtml"
-	"github.com/mmarkdown/mmark/v2/render/xml"
 )
 
 var (
 	flagCSS       = flag.String("css", "", "link to a CSS stylesheet (only used with -html)")
 	flagHead      = flag.String("head", "", "link to HTML to be included in head (only used with -html)")
-	flagAst       = flag.Bool("ast", false, "print abstract syntax tree and exit")
+	flagTemplate  = flag.String("template", "", "html/template file used to create HTML pages, \"default\" is the built-in theme (only used with -html)")
+	flagAst       = astFlag("")
+	flagDeps      = depsFlag("")
+	flagMF        = flag.String("MF", "", "write the dependencies of the document as a Makefile rule to this file")
+	flagMT        = flag.String("MT", "", "target of the Makefile rule written by -deps and -MF, defaults to the output file")
+	flagFromJSON  = flag.Bool("from-json", false, "read the input as a JSON abstract syntax tree, as printed by -ast=json")
 	flagBib       = flag.Bool("bibliography", true, "generate a bibliography section after the back matter")
 	flagFragment  = flag.Bool("fragment", false, "don't create a full document")
 	flagHTML      = flag.Bool("html", false, "create HTML output")
+	flagSplit     = flag.String("split", "", "create HTML output split in pages, one per section, in this directory")
+	flagSplitLvl  = flag.Int("split-level", 1, "sections up to this level start a page (only used with -split)")
 	flagIndex     = flag.Bool("index", true, "generate an index at the end of the document")
 	flagMan       = flag.Bool("man", false, "generate manual pages (nroff)")
+	flagLatex     = flag.Bool("latex", false, "create LaTeX output")
+	flagText      = flag.Bool("text", false, "create RFC 7994 style plain text output")
+	flagUnpaged   = flag.Bool("unpaginated", false, "don't break text output in pages (only used with -text)")
+	flagFmt       = flag.Bool("fmt", false, "format the markdown and rewrite the file in place (standard input is written to standard output)")
+	flagImport    = flag.Bool("import", false, "convert RFC 7991 XML to mmark markdown")
 	flagUnsafe    = flag.Bool("unsafe", false, "allow unsafe includes")
+	flagNoInclude = flag.Bool("no-include", false, "disable includes")
+	flagIncRoots  = flag.String("include-roots", "", "comma separated list of directories includes may be read from")
+	flagIncSize   = flag.Int64("include-size", mparser.DefaultMaxIncludeSize, "maximum size of an included file in bytes")
+	flagIncDepth  = flag.Int("include-depth", mparser.DefaultMaxIncludeDepth, "maximum nesting depth of includes")
 	flagIntraEmph = flag.Bool("intra-emphasis", false, "interpret camel_case_value as emphasizing \"case\" (legacy behavior)")
 	flagVersion   = flag.Bool("version", false, "show mmark version")
 	flagUnicode   = flag.Bool("unicode", true, "from xml2rfc 3.16 onwards unicode is allowed in <t>")
+	flagWerror    = flag.Bool("Werror", false, "exit with a non-zero exit code when warnings or errors were emitted")
+	flagLint      = flag.Bool("lint", false, "check the document for problems and exit")
+	flagLintOff   = flag.String("lint-disable", "", "comma separated list of lint rules to disable")
+	flagBibTeX    = flag.String("bibtex", "", "comma separated list of BibTeX files used to resolve citations")
+	flagLibrary   = flag.String("library", "", "directory with a local reference library (bibxml layout)")
 )
 
+func init() {
+	flag.Var(&flagAst, "ast", "print abstract syntax tree and exit, with -ast=json as JSON")
+	flag.Var(&flagDeps, "deps", "print the files the document depends on as a Makefile rule and exit, with -deps=json as JSON")
+}
+
+// astFlag is the value of -ast: "" when not given, "text" or "json". It can be used as a boolean flag.
+type astFlag string
+
+func (a *astFlag) String() string   { return string(*a) }
+func (a *astFlag) IsBoolFlag() bool { return true }
+
+func (a *astFlag) Set(s string) error {
+	switch s {
+	case "true", "text":
+		*a = "text"
+	case "false":
+		*a = ""
+	case "json":
+		*a = "json"
+	default:
+		return fmt.Errorf("unknown format %q, expected text or json", s)
+	}
+	return nil
+}
+
+// depsFlag is the value of -deps: "" when not given, "make" or "json". It can be used as a boolean flag.
+type depsFlag string
+
+func (d *depsFlag) String() string   { return string(*d) }
+func (d *depsFlag) IsBoolFlag() bool { return true }
+
+func (d *depsFlag) Set(s string) error {
+	switch s {
+	case "true", "make":
+		*d = "make"
+	case "false":
+		*d = ""
+	case "json":
+		*d = "json"
+	default:
+		return fmt.Errorf("unknown format %q, expected make or json", s)
+	}
+	return nil
+}
+
 func main() {
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "SYNOPSIS: %s [OPTIONS] %s\n", os.Args[0], "[FILE...]")
@@ -51,21 +118,145 @@ func main() {
 		os.Exit(0)
 	}
 
+	opts := pipeline.Options{Format: pipeline.FormatXML, CSS: *flagCSS, Library: *flagLibrary}
+	switch {
+	case *flagHTML, *flagSplit != "":
+		opts.Format = pipeline.FormatHTML
+	case *flagMan:
+		opts.Format = pipeline.FormatMan
+	case *flagLatex:
+		opts.Format = pipeline.FormatLaTeX
+	case *flagText:
+		opts.Format = pipeline.FormatText
+	case *flagFmt:
+		opts.Format = pipeline.FormatMarkdown
+	}
+	if *flagBib {
+		opts.Flags |= pipeline.Bibliography
+	}
+	if *flagIndex {
+		opts.Flags |= pipeline.Index
+	}
+	if *flagFragment {
+		opts.Flags |= pipeline.Fragment
+	}
+	if *flagUnsafe {
+		opts.Flags |= pipeline.UnsafeInclude
+	}
+	if *flagNoInclude {
+		opts.Flags |= pipeline.NoInclude
+	}
+	for _, r := range strings.Split(*flagIncRoots, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			opts.IncludeRoots = append(opts.IncludeRoots, r)
+		}
+	}
+	opts.MaxIncludeSize, opts.MaxIncludeDepth = *flagIncSize, *flagIncDepth
+	if *flagIntraEmph {
+		opts.Flags |= pipeline.IntraEmphasis
+	}
+	if *flagUnicode {
+		opts.Flags |= pipeline.AllowUnicode
+	}
+	if *flagUnpaged {
+		opts.Flags |= pipeline.Unpaginated
+	}
+
+	for _, f := range strings.Split(*flagBibTeX, ",") {
+		if f != "" {
+			opts.BibTeX = append(opts.BibTeX, f)
+		}
+	}
+
+	if *flagFmt {
+		// the bibliography and index are generated, they are not part of the source.
+		opts.Flags &^= pipeline.Bibliography | pipeline.Index
+	}
+
+	disabled := map[string]bool{}
+	if *flagLint {
+		// the bibliography and index are generated, and not useful to lint.
+		opts.Flags &^= pipeline.Bibliography | pipeline.Index
+		for _, id := range strings.Split(*flagLintOff, ",") {
+			if id == "" {
+				continue
+			}
+			if !lint.Known(id) {
+				log.Printf("Unknown lint rule %q", id)
+				os.Exit(1)
+			}
+			disabled[id] = true
+		}
+	}
+
+	if *flagFmt && opts.Format != pipeline.FormatMarkdown {
+		log.Printf("The -fmt flag can't be combined with -html, -split, -man, -latex or -text")
+		os.Exit(1)
+	}
+
+	if *flagSplit != "" && len(args) > 1 {
+		log.Printf("Only a single file can be split in pages")
+		os.Exit(1)
+	}
+
+	if opts.Format == pipeline.FormatHTML && *flagHead != "" {
+		head, err := ioutil.ReadFile(*flagHead)
+		if err != nil {
+			log.Printf("Couldn't open %q, error: %q", *flagHead, err)
+			return
+		}
+		opts.Head = head
+	}
+
+	if opts.Format == pipeline.FormatHTML && *flagTemplate != "" {
+		opts.Template = mhtml.DefaultTemplate
+		if *flagTemplate != "default" {
+			tmpl, err := template.ParseFiles(*flagTemplate)
+			if err != nil {
+				log.Printf("Couldn't parse template %q: %q", *flagTemplate, err)
+				os.Exit(1)
+			}
+			opts.Template = tmpl
+		}
+	}
+
+	failed := false
+	defer func() {
+		if failed {
+			os.Exit(1)
+		}
+	}()
+
+	depfile := &bytes.Buffer{} // the rules for -MF
+	if *flagMF != "" {
+		defer func() {
+			if err := ioutil.WriteFile(*flagMF, depfile.Bytes(), 0644); err != nil {
+				log.Printf("Couldn't write %q: %q", *flagMF, err)
+				failed = true
+			}
+		}()
+	}
+
 	for _, fileName := range args {
 		var (
-			d    []byte
-			err  error
-			init mparser.Initial
+			d   []byte
+			err error
 		)
+		opts.Diagnostics = diag.New()
+		opts.Sources = mast.NewSources()
+		opts.Dependencies = nil
+		if flagDeps != "" || *flagMF != "" {
+			opts.Dependencies = mparser.NewDependencies()
+		}
 		if fileName == "os.Stdin" {
-			init = mparser.NewInitial("")
+			opts.FileName = ""
 			d, err = ioutil.ReadAll(os.Stdin)
 			if err != nil {
 				log.Printf("Couldn't read %q: %q", fileName, err)
 				continue
 			}
 		} else {
-			init = mparser.NewInitial(fileName)
+			opts.FileName = fileName
 			d, err = ioutil.ReadFile(fileName)
 			if err != nil {
 				log.Printf("Couldn't open %q: %q", fileName, err)
@@ -73,129 +264,186 @@ func main() {
 			}
 		}
 
-		d = markdown.NormalizeNewlines(d)
-
-		if *flagUnsafe {
-			init.Flags |= mparser.UnsafeInclude
+		if *flagImport {
+			x, err := pipeline.Import(d, opts)
+			failed = report(opts.Diagnostics) || failed
+			if err != nil {
+				log.Printf("Couldn't import %q: %q", fileName, err)
+				failed = true
+				continue
+			}
+			os.Stdout.Write(x)
+			continue
 		}
 
-		if !*flagIntraEmph {
-			mparser.Extensions |= parser.NoIntraEmphasis
+		var doc ast.Node
+		if *flagFromJSON {
+			doc, err = mast.FromJSON(d)
+			if err != nil {
+				log.Printf("Couldn't read the AST from %q: %q", fileName, err)
+				failed = true
+				continue
+			}
+			pipeline.Complete(doc, opts)
+		} else {
+			doc = pipeline.Parse(d, opts)
 		}
 
-		p := parser.NewWithExtensions(mparser.Extensions)
-		parserFlags := parser.FlagsNone
-		documentTitle := ""      // hack to get document title from toml title block and then set it here.
-		documentLanguage := "en" // get document language from title block if it is set.
-		if !*flagHTML && !*flagMan {
-			parserFlags |= parser.SkipFootnoteList // both xml formats don't deal with footnotes well.
-		}
-		p.Opts = parser.Options{
-			ParserHook: func(data []byte) (ast.Node, []byte, int) {
-				node, data, consumed := mparser.Hook(data)
-				if t, ok := node.(*mast.Title); ok {
-					documentTitle = t.TitleData.Title
-					documentLanguage = t.TitleData.Language
-				}
-				return node, data, consumed
-			},
-			ReadIncludeFn: init.ReadInclude,
-			Flags:         parserFlags,
+		if opts.Dependencies != nil {
+			target := *flagMT
+			if target == "" {
+				target = output(fileName, opts.Format)
+			}
+			switch flagDeps {
+			case "make":
+				os.Stdout.Write(opts.Dependencies.Rule(target))
+				failed = report(opts.Diagnostics) || failed
+				continue
+			case "json":
+				x, _ := opts.Dependencies.JSON()
+				fmt.Println(string(x))
+				failed = report(opts.Diagnostics) || failed
+				continue
+			}
+			depfile.Write(opts.Dependencies.Rule(target))
 		}
 
-		doc := markdown.Parse(d, p)
-		if *flagMan {
-			title := false
-			// If there isn't a title block the resulting manual page does not start
-			// with .TH, this messes up the entire rendering. Walk to AST to check for
-			// a title block, and if none is found inject an empty one.
-			ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
-				if _, ok := node.(*mast.Title); ok {
-					title = true
-					return ast.Terminate
-				}
-				return ast.GoToNext
-			})
-			if !title {
-				t := &mast.Title{TitleData: &mast.TitleData{Title: "User Commands 1"}}
-				c := doc.GetChildren()
-				newc := append([]ast.Node{t}, c...)
-				doc.SetChildren(newc) // t must be the first element.
-			} else {
-				ast.AppendChild(doc, &mast.Authors{})
+		if *flagLint {
+			lintOpts := lint.Options{Disabled: disabled, File: opts.FileName, Sources: opts.Sources, Diagnostics: opts.Diagnostics}
+			lintOpts.BibTeX = pipeline.BibTeX(doc, opts)
+			lint.Lint(doc, lintOpts)
+			if opts.Diagnostics.Has(diag.Warning) {
+				failed = true
 			}
-
-		}
-		if *flagBib {
-			mparser.AddBibliography(doc)
-		}
-		if *flagIndex {
-			mparser.AddIndex(doc)
+			report(opts.Diagnostics)
+			continue
 		}
 
-		if *flagAst {
+		switch flagAst {
+		case "text":
 			ast.Print(os.Stdout, doc)
 			fmt.Print("\n")
+			failed = report(opts.Diagnostics) || failed
+			return
+		case "json":
+			x, err := mast.ToJSON(doc)
+			failed = report(opts.Diagnostics) || failed
+			if err != nil {
+				log.Printf("Couldn't convert the AST of %q to JSON: %q", fileName, err)
+				failed = true
+				return
+			}
+			fmt.Println(string(x))
 			return
 		}
 
-		var renderer markdown.Renderer
-
-		switch {
-		case *flagHTML:
-			mhtmlOpts := mhtml.RendererOptions{
-				Language: lang.New(documentLanguage),
-			}
-			opts := html.RendererOptions{
-				Comments:       [][]byte{[]byte("//"), []byte("#")}, // TODO(miek): make this an option.
-				RenderNodeHook: mhtmlOpts.RenderHook,
-				Flags:          html.CommonFlags | html.FootnoteNoHRTag | html.FootnoteReturnLinks,
-				Generator:      `  <meta name="GENERATOR" content="github.com/mmarkdown/mmark Mmark Markdown Processor - mmark.miek.nl`,
-			}
-			if !*flagFragment {
-				opts.Flags |= html.CompletePage
-			}
-			opts.CSS = *flagCSS
-			if *flagHead != "" {
-				head, err := ioutil.ReadFile(*flagHead)
-				if err != nil {
-					log.Printf("Couldn't open %q, error: %q", *flagHead, err)
-					continue
-				}
-				opts.Head = head
+		if *flagSplit != "" {
+			pages, err := pipeline.Split(doc, opts, *flagSplitLvl)
+			failed = report(opts.Diagnostics) || failed
+			if err != nil {
+				log.Printf("Couldn't render %q: %q", fileName, err)
+				failed = true
+				continue
 			}
-			if documentTitle != "" {
-				opts.Title = documentTitle
+			if err := writePages(*flagSplit, pages); err != nil {
+				log.Printf("Couldn't write the pages of %q: %q", fileName, err)
+				failed = true
 			}
+			continue
+		}
 
-			renderer = html.NewRenderer(opts)
-		case *flagMan:
-			opts := man.RendererOptions{
-				Comments: [][]byte{[]byte("//"), []byte("#")},
-				Language: lang.New(documentLanguage),
-			}
-			if *flagFragment {
-				opts.Flags |= man.ManFragment
-			}
-			renderer = man.NewRenderer(opts)
-		default:
-			opts := xml.RendererOptions{
-				Flags:    xml.CommonFlags,
-				Comments: [][]byte{[]byte("//"), []byte("#")},
-				Language: lang.New(documentLanguage),
-			}
-			if *flagFragment {
-				opts.Flags |= xml.XMLFragment
+		x, err := pipeline.Render(doc, opts)
+		failed = report(opts.Diagnostics) || failed
+		if err != nil {
+			log.Printf("Couldn't render %q: %q", fileName, err)
+			continue
+		}
+
+		if *flagFmt {
+			// A JSON AST is formatted to standard output, it can't be rewritten in place.
+			if opts.FileName == "" || *flagFromJSON {
+				os.Stdout.Write(x)
+				continue
 			}
-			if *flagUnicode {
-				opts.Flags |= xml.AllowUnicode
+			if err := rewrite(opts.FileName, d, x); err != nil {
+				log.Printf("Couldn't write %q: %q", fileName, err)
+				failed = true
 			}
-
-			renderer = xml.NewRenderer(opts)
+			continue
 		}
 
-		x := markdown.Render(doc, renderer)
-
 		fmt.Println(string(x))
 	}
 }
+
+// rewrite writes formatted to fileName if it differs from the original. It's written to a temporary file in the
+// same directory first, which is then renamed, so fileName is never left half written.
+func rewrite(fileName string, original, formatted []byte) error {
+	if bytes.Equal(original, formatted) {
+		return nil
+	}
+	fi, err := os.Stat(fileName)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails after the rename.
+
+	if _, err := f.Write(formatted); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(fi.Mode().Perm()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fileName)
+}
+
+// output returns the name of the file that would be created from fileName for format, it's the target of the
+// Makefile rule of the dependencies.
+func output(fileName string, format pipeline.Format) string {
+	if *flagSplit != "" {
+		return filepath.Join(*flagSplit, "index.html")
+	}
+	if fileName == "os.Stdin" {
+		return "-"
+	}
+	ext := map[pipeline.Format]string{
+		pipeline.FormatXML:      ".xml",
+		pipeline.FormatHTML:     ".html",
+		pipeline.FormatMan:      ".1",
+		pipeline.FormatLaTeX:    ".tex",
+		pipeline.FormatMarkdown: ".md",
+		pipeline.FormatText:     ".txt",
+	}[format]
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ext
+}
+
+// writePages writes the pages to dir, which is created if it doesn't exist.
+func writePages(dir string, pages []pipeline.Page) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, p := range pages {
+		if err := ioutil.WriteFile(filepath.Join(dir, p.Name), p.Data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// report prints the diagnostics to standard error, it returns true if -Werror is given and any warnings
+// or errors were seen.
+func report(d *diag.Diagnostics) bool {
+	for _, x := range d.List() {
+		fmt.Fprintln(os.Stderr, x)
+	}
+	return *flagWerror && d.Has(diag.Warning)
+}
@@ -3,11 +3,12 @@ package mparser
 import (
 	"bytes"
 	"encoding/xml"
-	"log"
+	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/diag"
 	"github.com/mmarkdown/mmark/v2/mast"
 	"github.com/mmarkdown/mmark/v2/mast/reference"
 )
@@ -15,9 +16,18 @@ import (
 // CitationToBibliography walks the AST and gets all the citations from HTML blocks and groups them into
 // normative and informative references.
 func CitationToBibliography(doc ast.Node) (normative ast.Node, informative ast.Node) {
+	return Initial{}.CitationToBibliography(doc)
+}
+
+// CitationToBibliography is like CitationToBibliography, but problems are reported to in.Diagnostics. Citations
+// without a reference block are resolved against the BibTeX files, see BibTeXReferences. When BibTeX files are used,
+// citations that can't be resolved are reported. RFCs, I-Ds, etc. are looked up in the reference library when
+// in.Library is set.
+func (in Initial) CitationToBibliography(doc ast.Node) (normative ast.Node, informative ast.Node) {
 	seen := map[string]*mast.BibliographyItem{}
-	raw := map[string][]byte{}
+	raw := map[string]*mast.ReferenceBlock{}
 	names := []string{} // names of the authors and contacts
+	bib := in.BibTeXReferences(doc)
 
 	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
 		if t, ok := node.(*mast.Title); ok {
@@ -50,6 +60,9 @@ func CitationToBibliography(doc ast.Node) (normative ast.Node, informative ast.N
 					ref2 := &mast.BibliographyItem{}
 					ref2.Anchor = second
 					ref2.Type = c.Type[i]
+					if span, ok := in.Sources.Span(c); ok {
+						in.Sources.Set(ref2, span)
+					}
 					seen[string(second)] = ref2
 
 					d = d[:n]
@@ -58,13 +71,16 @@ func CitationToBibliography(doc ast.Node) (normative ast.Node, informative ast.N
 				ref := &mast.BibliographyItem{}
 				ref.Anchor = d
 				ref.Type = c.Type[i]
+				if span, ok := in.Sources.Span(c); ok {
+					in.Sources.Set(ref, span)
+				}
 
 				seen[string(d)] = ref
 			}
 		case *mast.ReferenceBlock:
-			anchor := anchorFromReference(c.Literal)
+			anchor := ReferenceAnchor(c.Literal)
 			if anchor != nil {
-				raw[string(bytes.ToLower(anchor))] = c.Literal
+				raw[string(bytes.ToLower(anchor))] = c
 			}
 		}
 		return ast.GoToNext
@@ -82,14 +98,32 @@ func CitationToBibliography(doc ast.Node) (normative ast.Node, informative ast.N
 	for _, k := range keys {
 		r := seen[k]
 		// If we have a reference anchor and the raw XML add that here.
-		if rw, ok := raw[string(bytes.ToLower(r.Anchor))]; ok {
-			var x reference.Reference
-			if e := xml.Unmarshal(rw, &x); e != nil {
-				log.Printf("Failed to unmarshal reference: %q: %s, assuming <referencegroup>", r.Anchor, e)
-				r.ReferenceGroup = rw
-			} else {
-				r.Reference = &x
+		if block, ok := raw[string(bytes.ToLower(r.Anchor))]; ok {
+			x, group, e := unmarshalReference(block.Literal)
+			if e != nil {
+				d := diag.Diagnostic{Severity: diag.Warning, Code: "reference-unmarshal", File: in.file}
+				d.Message = fmt.Sprintf("Failed to unmarshal reference: %q: %s, using it as is", r.Anchor, e)
+				if span, ok := in.Sources.Span(block); ok {
+					d.File, d.Line, d.Column = span.File, span.Line, span.Column
+				}
+				in.Diagnostics.Add(d)
+				r.Raw = block.Literal
 			}
+			r.Reference, r.ReferenceGroup = x, group
+		} else if x, ok := bib[string(bytes.ToLower(r.Anchor))]; ok {
+			// BibTeX keys are case insensitive, use the anchor as cited, so it matches the xref's target.
+			ref := *x
+			ref.Anchor = string(r.Anchor)
+			r.Reference = &ref
+		} else if in.Library != "" && KnownSeries(r.Anchor) {
+			r.Reference, r.ReferenceGroup = in.libraryReference(r)
+		} else if bib != nil && !KnownSeries(r.Anchor) {
+			d := diag.Diagnostic{Severity: diag.Warning, Code: "citation-unknown", File: in.file}
+			d.Message = fmt.Sprintf("Citation %q has no <reference> and is not found in the BibTeX files", r.Anchor)
+			if span, ok := in.Sources.Span(r); ok {
+				d.File, d.Line, d.Column = span.File, span.Line, span.Column
+			}
+			in.Diagnostics.Add(d)
 		}
 
 		switch r.Type {
@@ -127,8 +161,9 @@ func NodeBackMatter(doc ast.Node) ast.Node {
 	return matter
 }
 
-// Parse '<reference anchor='CBR03' target=">' and return the string after anchor= is the ID for the reference.
-func anchorFromReference(data []byte) []byte {
+// ReferenceAnchor parses '<reference anchor='CBR03' target=">' and returns the string after anchor=, this is the ID
+// for the reference.
+func ReferenceAnchor(data []byte) []byte {
 	if !bytes.HasPrefix(data, []byte("<reference ")) && !bytes.HasPrefix(data, []byte("<referencegroup ")) {
 		return nil
 	}
@@ -157,15 +192,19 @@ func anchorFromReference(data []byte) []byte {
 	return data[beg+1 : i]
 }
 
-// ReferenceHook is the hook used to parse reference nodes.
-func ReferenceHook(data []byte) (ast.Node, []byte, int) {
+// ReferenceHook is the hook used to parse reference nodes, both XML and TOML references are recognized.
+func ReferenceHook(data []byte) (ast.Node, []byte, int) { return Initial{}.ReferenceHook(data) }
+
+// ReferenceHook is like ReferenceHook, but problems are reported to in.Diagnostics.
+func (in Initial) ReferenceHook(data []byte) (ast.Node, []byte, int) {
 	ref, ok := IsReference(data)
 	if !ok {
-		return nil, nil, 0
+		return in.referenceTOMLHook(data)
 	}
 
 	node := &mast.ReferenceBlock{}
 	node.Literal = fmtReference(ref)
+	node.Content = ref
 	return node, nil, len(ref)
 }
 
@@ -184,7 +223,7 @@ func IsReference(data []byte) ([]byte, bool) {
 
 	// scan for an end-of-reference marker, across lines if necessary
 	end := bytes.Index(data[len(typ):], []byte(typ))
-	if end > len(data) || end == 0 {
+	if end <= 0 {
 		return nil, false
 	}
 
@@ -192,27 +231,64 @@ func IsReference(data []byte) ([]byte, bool) {
 }
 
 func fmtReference(data []byte) []byte {
-	var x reference.Reference
-	if e := xml.Unmarshal(data, &x); e != nil {
+	x, group, e := unmarshalReference(data)
+	if e != nil {
 		return data
 	}
 
-	out, e := xml.MarshalIndent(x, "", "   ")
+	var out []byte
+	if group != nil {
+		out, e = xml.MarshalIndent(group, "", "   ")
+	} else {
+		out, e = xml.MarshalIndent(x, "", "   ")
+	}
 	if e != nil {
 		return data
 	}
 	return out
 }
 
+// unmarshalReference parses data, which holds either a <reference> or a <referencegroup>. A reference group
+// must have an anchor and at least one reference, and each reference must have an anchor.
+func unmarshalReference(data []byte) (*reference.Reference, *reference.ReferenceGroup, error) {
+	if !bytes.HasPrefix(data, []byte("<referencegroup")) {
+		var x reference.Reference
+		if e := xml.Unmarshal(data, &x); e != nil {
+			return nil, nil, e
+		}
+		return &x, nil, nil
+	}
+
+	var group reference.ReferenceGroup
+	if e := xml.Unmarshal(data, &group); e != nil {
+		return nil, nil, e
+	}
+	if group.Anchor == "" {
+		return nil, nil, fmt.Errorf("<referencegroup> without an anchor")
+	}
+	if len(group.References) == 0 {
+		return nil, nil, fmt.Errorf("<referencegroup> %q without references", group.Anchor)
+	}
+	for i, x := range group.References {
+		if x.Anchor == "" {
+			return nil, nil, fmt.Errorf("reference %d in <referencegroup> %q without an anchor", i+1, group.Anchor)
+		}
+	}
+	return nil, &group, nil
+}
+
 // AddBibliography adds the bibliography to the document. It will be
 // added just after the backmatter node. If that node can't be found this
 // function returns false and does nothing.
-func AddBibliography(doc ast.Node) bool {
-	norm, inform := CitationToBibliography(doc)
+func AddBibliography(doc ast.Node) bool { return Initial{}.AddBibliography(doc) }
+
+// AddBibliography is like AddBibliography, but problems are reported to in.Diagnostics.
+func (in Initial) AddBibliography(doc ast.Node) bool {
+	norm, inform := in.CitationToBibliography(doc)
 	where := NodeBackMatter(doc)
 	if where == nil {
 		if norm != nil || inform != nil {
-			log.Print("No {backmatter} found, can't insert bibliography")
+			in.Diagnostics.Warningf("no-backmatter", in.file, "No {backmatter} found, can't insert bibliography")
 		}
 		return false
 	}
@@ -1,7 +1,13 @@
 package mparser
 
 import (
+	"encoding/xml"
 	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
 )
 
 func TestAnchorFromReference(t *testing.T) {
@@ -13,7 +19,7 @@ func TestAnchorFromReference(t *testing.T) {
  </front>
 </reference>`)
 
-	got := string(anchorFromReference(ref))
+	got := string(ReferenceAnchor(ref))
 	want := "ts"
 
 	if got != want {
@@ -35,3 +41,107 @@ func TestReferenceHook(t *testing.T) {
 		t.Errorf("want %d, got %d, for input %s...", len(ref), read, ref[:20])
 	}
 }
+
+func TestReferenceHookTOML(t *testing.T) {
+	ref := []byte(`%%% reference
+anchor = "ts"
+title = "Old Possum's Book of Practical Cats"
+date = "1939-10"
+
+[[author]]
+initials = "TS"
+surname = "Stearns"
+fullname = "TS. Stearns"
+organization = "Faber and Faber"
+
+[[seriesInfo]]
+name = "ISBN"
+value = "0-571-05866-8"
+%%%
+`)
+
+	node, _, read := ReferenceHook(append(ref, []byte("\nparagraph\n")...))
+	if read != len(ref) {
+		t.Fatalf("want %d, got %d, for input %s...", len(ref), read, ref[:20])
+	}
+	block := node.(*mast.ReferenceBlock)
+	if got := string(ReferenceAnchor(block.Literal)); got != "ts" {
+		t.Errorf("want anchor %s, got %s", "ts", got)
+	}
+
+	var x reference.Reference
+	if err := xml.Unmarshal(block.Literal, &x); err != nil {
+		t.Fatal(err)
+	}
+	if x.Front.Date.Year != "1939" || x.Front.Date.Month != "October" {
+		t.Errorf("want date %s %s, got %s %s", "1939", "October", x.Front.Date.Year, x.Front.Date.Month)
+	}
+	if len(x.Front.Authors) != 1 || x.Front.Authors[0].Organization.Value != "Faber and Faber" {
+		t.Errorf("want one author from %s, got %v", "Faber and Faber", x.Front.Authors)
+	}
+	if len(x.Series) != 1 || x.Series[0].Value != "0-571-05866-8" {
+		t.Errorf("want seriesInfo %s, got %v", "0-571-05866-8", x.Series)
+	}
+}
+
+func TestReferenceHookTOMLError(t *testing.T) {
+	ref := []byte(`%%% reference
+title = "no anchor"
+%%%
+`)
+	d := diag.New()
+	node, _, read := Initial{Diagnostics: d}.ReferenceHook(ref)
+	if read != len(ref) {
+		t.Fatalf("want %d, got %d, for input %s...", len(ref), read, ref[:20])
+	}
+	if node.AsLeaf().Literal != nil {
+		t.Errorf("want no reference, got %s", node.AsLeaf().Literal)
+	}
+	if !d.Has(diag.Error) {
+		t.Errorf("want an error diagnostic")
+	}
+}
+
+func TestReferenceGroup(t *testing.T) {
+	tests := []struct {
+		in    string
+		valid bool
+	}{
+		{`<referencegroup anchor="STD1" target="https://example.org"><reference anchor="RFC1"><front><title>One</title></front></reference></referencegroup>`, true},
+		{`<referencegroup target="https://example.org"><reference anchor="RFC1"><front><title>One</title></front></reference></referencegroup>`, false},
+		{`<referencegroup anchor="STD1"></referencegroup>`, false},
+		{`<referencegroup anchor="STD1"><reference><front><title>One</title></front></reference></referencegroup>`, false},
+	}
+	for i, tc := range tests {
+		x, group, err := unmarshalReference([]byte(tc.in))
+		if x != nil {
+			t.Errorf("test %d, expected no reference, got %v", i, x)
+		}
+		if valid := err == nil; valid != tc.valid {
+			t.Errorf("test %d, expected valid to be %t, got %t (%v)", i, tc.valid, valid, err)
+		}
+		if tc.valid && (group.Anchor != "STD1" || len(group.References) != 1 || group.References[0].Anchor != "RFC1") {
+			t.Errorf("test %d, expected group %s with reference %s, got %v", i, "STD1", "RFC1", group)
+		}
+	}
+}
+
+func TestReferenceGroupInvalid(t *testing.T) {
+	group := []byte(`<referencegroup anchor="STD1"></referencegroup>`)
+	doc := &ast.Document{}
+	ast.AppendChild(doc, &ast.Citation{Destination: [][]byte{[]byte("STD1")}, Type: []ast.CitationTypes{ast.CitationTypeInformative}})
+	ast.AppendChild(doc, &mast.ReferenceBlock{Leaf: ast.Leaf{Literal: group}})
+
+	d := diag.New()
+	_, inform := Initial{Diagnostics: d}.CitationToBibliography(doc)
+	if inform == nil {
+		t.Fatal("want an informative bibliography")
+	}
+	item := ast.GetFirstChild(inform).(*mast.BibliographyItem)
+	if string(item.Raw) != string(group) {
+		t.Errorf("want the raw <referencegroup>, got %q", item.Raw)
+	}
+	if d.Has(diag.Error) || !d.Has(diag.Warning) {
+		t.Errorf("want a warning, got %v", d.List())
+	}
+}
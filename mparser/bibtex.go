@@ -0,0 +1,87 @@
+package mparser
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/bibtex"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+)
+
+// knownSeries are the prefixes of citations that xml2rfc (and the renderers) include from the IETF bibliography.
+var knownSeries = [][]byte{[]byte("RFC"), []byte("I-D."), []byte("BCP"), []byte("STD"), []byte("W3C.")}
+
+// KnownSeries returns true if anchor is a citation of a document that is included automatically, without
+// the need for a reference, i.e. an RFC or I-D.
+func KnownSeries(anchor []byte) bool {
+	for _, prefix := range knownSeries {
+		if bytes.HasPrefix(anchor, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// BibTeXReferences returns the references from the BibTeX files named in the title block of doc and in in.BibTeX,
+// keyed by the lowercased citation key. Files from the title block are read relative to the document and
+// are subject to the same restrictions as includes. When a key is defined in multiple files, the first
+// one wins. Nil is returned when no BibTeX files are used.
+func (in Initial) BibTeXReferences(doc ast.Node) map[string]*reference.Reference {
+	files := []string{}
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if t, ok := node.(*mast.Title); ok {
+			for _, f := range t.TitleData.Bibliography {
+				path := in.path("", f)
+				if in.Flags&NoInclude != 0 {
+					in.Diagnostics.Errorf("include-disabled", in.file, "Failure to read BibTeX: %q: includes are disabled", path)
+					continue
+				}
+				if in.Flags&UnsafeInclude == 0 && !in.pathAllowed(path) {
+					in.Diagnostics.Errorf("include-not-allowed", in.file, "Failure to read BibTeX: %q: path is not on or below %q", path, strings.Join(in.roots(), ", "))
+					continue
+				}
+				files = append(files, path)
+			}
+			return ast.Terminate
+		}
+		return ast.GoToNext
+	})
+	if len(files)+len(in.BibTeX) == 0 {
+		return nil
+	}
+
+	refs := map[string]*reference.Reference{}
+	for n, f := range append(files, in.BibTeX...) {
+		read := in.readFile
+		if n >= len(files) {
+			read = ioutil.ReadFile // given by the caller, these aren't in in.FS.
+		}
+		data, err := read(f)
+		if err != nil {
+			in.Diagnostics.Errorf("bibtex-read", in.file, "Failure to read BibTeX: %s", err)
+			continue
+		}
+		in.dependency(f)
+		r, err := bibtex.References(data)
+		if err != nil {
+			d := diag.Diagnostic{Severity: diag.Error, Code: "bibtex-parse", File: f, Message: "Failure parsing BibTeX: " + err.Error()}
+			var perr *bibtex.ParseError
+			if errors.As(err, &perr) {
+				d.Line, d.Message = perr.Line, "Failure parsing BibTeX: "+perr.Message
+			}
+			in.Diagnostics.Add(d)
+			continue
+		}
+		for k, v := range r {
+			if _, ok := refs[k]; !ok {
+				refs[k] = v
+			}
+		}
+	}
+	return refs
+}
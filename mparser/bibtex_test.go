@@ -0,0 +1,50 @@
+package mparser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+func TestBibTeX(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	draft := filepath.Join(dir, "draft.md")
+	ioutil.WriteFile(draft, []byte("%%%\ntitle = \"x\"\nbibliography = [\"refs.bib\"]\n%%%\n\nSee [@knuth84], [@missing] and [@RFC2119].\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "refs.bib"), []byte("@article{Knuth84, title = {Literate Programming}, year = 1984}\n@book{unused, title = {Not cited}}\n"), 0644)
+
+	input, _ := ioutil.ReadFile(draft)
+	init := NewInitial(draft)
+	init.Diagnostics = diag.New()
+	p := parser.NewWithExtensions(Extensions)
+	p.Opts = parser.Options{ParserHook: init.Hook, ReadIncludeFn: init.ReadInclude}
+	doc := markdown.Parse(input, p)
+
+	_, informative := init.CitationToBibliography(doc)
+	items := map[string]*mast.BibliographyItem{}
+	for _, c := range informative.GetChildren() {
+		item := c.(*mast.BibliographyItem)
+		items[string(item.Anchor)] = item
+	}
+	if len(items) != 3 {
+		t.Errorf("expected %d bibliography items, got %d", 3, len(items))
+	}
+	if r := items["knuth84"].Reference; r == nil || r.Anchor != "knuth84" || r.Front.Title != "Literate Programming" {
+		t.Errorf("expected knuth84 to be resolved from BibTeX, got %v", r)
+	}
+
+	list := init.Diagnostics.List()
+	if len(list) != 1 || list[0].Code != "citation-unknown" {
+		t.Errorf("expected a single citation-unknown diagnostic, got %v", list)
+	}
+}
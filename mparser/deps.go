@@ -0,0 +1,114 @@
+package mparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// Dependencies records the files a document depends on: the included files, the BibTeX files and the local
+// images. It's safe for concurrent use, a nil *Dependencies doesn't record anything.
+type Dependencies struct {
+	mu    sync.Mutex
+	files []string
+	seen  map[string]bool
+}
+
+// NewDependencies returns an empty Dependencies.
+func NewDependencies() *Dependencies { return &Dependencies{seen: map[string]bool{}} }
+
+// Add adds file, a file is only added once.
+func (d *Dependencies) Add(file string) {
+	if d == nil || file == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen[file] {
+		return
+	}
+	d.seen[file] = true
+	d.files = append(d.files, file)
+}
+
+// Files returns the files in the order they were added.
+func (d *Dependencies) Files() []string {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string{}, d.files...)
+}
+
+// Rule returns the dependencies as a Makefile rule for target, i.e. "draft.xml: draft.md sections/intro.md".
+func (d *Dependencies) Rule(target string) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(escapeMake(target) + ":")
+	for _, f := range d.Files() {
+		buf.WriteString(" \\\n  " + escapeMake(f))
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// JSON returns the dependencies as a JSON list.
+func (d *Dependencies) JSON() ([]byte, error) {
+	files := d.Files()
+	if files == nil {
+		files = []string{}
+	}
+	return json.Marshal(files)
+}
+
+var makeEscaper = strings.NewReplacer(" ", `\ `, "#", `\#`, "$", "$$")
+
+// escapeMake escapes the characters that have a special meaning in a Makefile rule.
+func escapeMake(s string) string { return makeEscaper.Replace(s) }
+
+// dependency adds file to i.Dependencies. Files on the operating system's file system are named relative to
+// the current working directory when they are below it, like they would be in a Makefile.
+func (i Initial) dependency(file string) {
+	if i.Dependencies == nil {
+		return
+	}
+	if i.FS == nil {
+		if cwd, err := os.Getwd(); err == nil {
+			if x, err := filepath.Rel(cwd, file); err == nil && x != ".." && !strings.HasPrefix(x, ".."+string(filepath.Separator)) {
+				file = x
+			}
+		}
+	}
+	i.Dependencies.Add(file)
+}
+
+// AddImages adds the local images in doc to i.Dependencies, an image is taken relative to the document.
+func (i Initial) AddImages(doc ast.Node) {
+	if i.Dependencies == nil {
+		return
+	}
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		img, ok := node.(*ast.Image)
+		if !ok || !entering || !localImage(string(img.Destination)) {
+			return ast.GoToNext
+		}
+		i.dependency(i.path("", string(img.Destination)))
+		return ast.GoToNext
+	})
+}
+
+// localImage returns true if dest is a file, and not a URL.
+func localImage(dest string) bool {
+	if dest == "" || strings.HasPrefix(dest, "//") || strings.HasPrefix(dest, "#") {
+		return false
+	}
+	if i := strings.IndexAny(dest, ":/"); i > 0 && dest[i] == ':' {
+		return false // a scheme, "https:", "data:", etc.
+	}
+	return true
+}
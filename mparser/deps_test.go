@@ -0,0 +1,43 @@
+package mparser
+
+import "testing"
+
+func TestDependenciesRule(t *testing.T) {
+	d := NewDependencies()
+	d.Add("draft.md")
+	d.Add("my sections/intro.md")
+	d.Add("draft.md")
+	d.Add("cost$.md")
+
+	expect := "draft.xml: \\\n  draft.md \\\n  my\\ sections/intro.md \\\n  cost$$.md\n"
+	if got := string(d.Rule("draft.xml")); got != expect {
+		t.Errorf("expected %q, got %q", expect, got)
+	}
+	if got, _ := d.JSON(); string(got) != `["draft.md","my sections/intro.md","cost$.md"]` {
+		t.Errorf("unexpected JSON %s", got)
+	}
+
+	var none *Dependencies
+	none.Add("draft.md")
+	if got, _ := none.JSON(); string(got) != "[]" {
+		t.Errorf("expected an empty list, got %s", got)
+	}
+}
+
+func TestLocalImage(t *testing.T) {
+	tests := map[string]bool{
+		"fig.svg":                   true,
+		"images/fig.ascii-art":      true,
+		"/abs/fig.png":              true,
+		"https://example.org/x.png": false,
+		"//example.org/x.png":       false,
+		"data:image/png;base64,AA":  false,
+		"#anchor":                   false,
+		"":                          false,
+	}
+	for dest, expect := range tests {
+		if got := localImage(dest); got != expect {
+			t.Errorf("%q: expected %t, got %t", dest, expect, got)
+		}
+	}
+}
@@ -4,7 +4,8 @@ import (
 	"github.com/gomarkdown/markdown/parser"
 )
 
-// Extensions is the default set of extensions mmark requires.
+// Extensions is the default set of extensions mmark requires. Don't change it, parsers that need
+// more (or fewer) extensions should use a copy.
 var Extensions = parser.Tables | parser.FencedCode | parser.Autolink | parser.Strikethrough | parser.SpaceHeadings |
 	parser.HeadingIDs | parser.BackslashLineBreak | parser.SuperSubscript | parser.DefinitionLists | parser.MathJax |
 	parser.AutoHeadingIDs | parser.Footnotes | parser.Strikethrough | parser.OrderedListStart | parser.Attributes |
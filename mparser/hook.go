@@ -1,24 +1,85 @@
 package mparser
 
 import (
-	"io/ioutil"
-	"log"
-	"path/filepath"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/diag"
 )
 
+// UnsafeInclude is a flag for Initial that allows includes from anywhere.
 var UnsafeInclude parser.Flags = 1 << 3
 
+// NoInclude is a flag for Initial that disables includes.
+const NoInclude parser.Flags = 1 << 4
+
+// KeepInclude is a flag for Initial that makes Hook return includes, and their caption, as HTML blocks holding
+// the directives as written, so they can be output as is. It's used when the parser's Includes extension is
+// disabled.
+const KeepInclude parser.Flags = 1 << 5
+
 // Hook will call both TitleHook and ReferenceHook.
-func Hook(data []byte) (ast.Node, []byte, int) {
-	n, b, i := TitleHook(data)
+func Hook(data []byte) (ast.Node, []byte, int) { return Initial{}.Hook(data) }
+
+// Hook will call both TitleHook and ReferenceHook, problems are reported to i.Diagnostics.
+func (i Initial) Hook(data []byte) (ast.Node, []byte, int) {
+	i.buffer(data) // records the parser's copy of the initial document when it is first seen.
+
+	n, b, c := i.TitleHook(data)
+	if n != nil {
+		return n, b, c
+	}
+
+	if i.Flags&KeepInclude != 0 {
+		if n, c = i.includeHook(data); n != nil {
+			return n, nil, c
+		}
+	}
+
+	n, b, c = i.ReferenceHook(data)
 	if n != nil {
-		return n, b, i
+		if span, ok := i.span(data); ok {
+			i.Sources.Set(n, span)
+		}
 	}
+	return n, b, c
+}
 
-	return ReferenceHook(data)
+var reInclude = regexp.MustCompile(`^ {0,3}<?{{[^}]+}}(\[[^]]*\])? *$`)
+
+// includeHook returns the includes at the start of data, with the caption that follows them, as an HTML block.
+func (i Initial) includeHook(data []byte) (ast.Node, int) {
+	end := 0
+	for end < len(data) {
+		eol := bytes.IndexByte(data[end:], '\n') + 1
+		if eol == 0 {
+			eol = len(data) - end
+		}
+		if !reInclude.Match(bytes.TrimSuffix(data[end:end+eol], []byte("\n"))) {
+			break
+		}
+		end += eol
+	}
+	if end == 0 {
+		return nil, 0
+	}
+	for _, caption := range []string{"Figure: ", "Table: ", "Quote: "} {
+		if bytes.HasPrefix(data[end:], []byte(caption)) {
+			end += parser.LinesUntilEmpty(data[end:])
+			break
+		}
+	}
+	node := &ast.HTMLBlock{}
+	node.Literal = bytes.TrimRight(data[:end], "\n")
+	if span, ok := i.span(data); ok {
+		i.Sources.Set(node, span)
+	}
+	return node, end
 }
 
 // ReadInclude is the hook to read includes.
@@ -27,33 +88,70 @@ func Hook(data []byte) (ast.Node, []byte, int) {
 // 4,5 - line numbers separated by commas
 // N, - line numbers, end not specified, read until the end.
 // /start/,/end/ - regexp separated by commas
-// optional a prefix="" string.
+// region=name - the lines between the "START name" and "END name" marker lines
+// func=name, type=name - the declaration of a Go function, method ("Type.Method") or type
+// optional a prefix="" string and dedent.
+//
+// Includes must be in i.Roots (see pathAllowed), smaller than i.MaxIncludeSize and may not be nested deeper than
+// i.MaxIncludeDepth or include themselves.
 func (i Initial) ReadInclude(from, file string, address []byte) []byte {
 	path := i.path(from, file)
 
+	incs, at := i.inc.including(from, file)
+	if at.File == "" {
+		at.File = i.file
+	} else {
+		at.File = i.rel(at.File)
+	}
+	fail := func(code, format string, a ...interface{}) {
+		d := diag.Diagnostic{Severity: diag.Error, Code: code, File: at.File, Line: at.Line, Column: at.Column}
+		d.Message = fmt.Sprintf(format, a...)
+		i.Diagnostics.Add(d)
+	}
+
+	if i.Flags&NoInclude != 0 {
+		fail("include-disabled", "Failure to read: %q: includes are disabled", path)
+		return nil
+	}
 	if i.Flags&UnsafeInclude == 0 {
 		if ok := i.pathAllowed(path); !ok {
-			log.Printf("Failure to read: %q: path is not on or below %q", path, i.i)
+			fail("include-not-allowed", "Failure to read: %q: path is not on or below %q", path, strings.Join(i.roots(), ", "))
 			return nil
 		}
 	}
+	if err := i.nesting(incs, path); err != nil {
+		code := "include-cycle"
+		if errors.Is(err, errIncludeDepth) {
+			code = "include-depth"
+		}
+		fail(code, "Failure to include %q: %s", i.rel(path), err)
+		return nil
+	}
 
-	data, err := ioutil.ReadFile(path)
+	content, err := i.readFile(path)
 	if err != nil {
-		log.Printf("Failure to read: %q (from %q)", err, filepath.Join(from, "*"))
+		code := "include-read"
+		if errors.Is(err, errTooLarge) {
+			code = "include-size"
+		}
+		fail(code, "Failure to read: %q", err)
 		return nil
 	}
+	i.dependency(path)
 
-	data, err = parseAddress(address, data)
+	data, err := parseAddress(address, content, path)
 	if err != nil {
-		log.Printf("Failure to parse address for %q: %q (from %q)", path, err, filepath.Join(from, "*"))
+		code := "include-address"
+		if errors.Is(err, errUnknownLanguage) {
+			code = "include-language"
+		}
+		fail(code, "Failure to parse address for %q: %q", path, err)
 		return nil
 	}
-	if len(data) == 0 {
-		return data
-	}
-	if data[len(data)-1] != '\n' {
+	if len(data) > 0 && data[len(data)-1] != '\n' {
 		data = append(data, '\n')
 	}
+	i.inc.push(from, file, path, content, data)
+	i.include(path, content, data)
 	return data
 }
@@ -11,6 +11,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
@@ -19,34 +20,94 @@ import (
 	"strings"
 
 	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mast"
 )
 
 // Initial is the initial file we are working on, empty for stdin and adjusted is we we have an absolute or relative file.
 type Initial struct {
 	Flags parser.Flags
-	i     string
+
+	// Diagnostics collects all problems found while parsing, if nil they are logged.
+	Diagnostics *diag.Diagnostics
+
+	// Sources holds the source spans of the nodes, only set when Track is used.
+	Sources *mast.Sources
+
+	// BibTeX holds BibTeX files that are used to resolve citations, in addition to the ones from
+	// the title block. They are read from the operating system's file system, even if FS is set.
+	BibTeX []string
+
+	// Library is the directory of a local reference library, in the bibxml layout used by xml2rfc. It's used to
+	// resolve citations of RFCs, I-Ds, etc. when set.
+	Library string
+
+	// FS is the file system includes, code includes and BibTeX files from the title block are read from. If nil
+	// the operating system's file system is used. Names in FS are slash separated and relative to its root, see
+	// NewInitialFS.
+	FS fs.FS
+
+	// Roots are the directories includes may be read from, if empty only the directory of the initial file (and
+	// its subdirectories) is allowed. Symbolic links are resolved before a file is checked. Not used when the
+	// UnsafeInclude flag is set.
+	Roots []string
+
+	// MaxIncludeSize is the maximum size of an included file in bytes, if zero DefaultMaxIncludeSize is used.
+	MaxIncludeSize int64
+
+	// MaxIncludeDepth is how deep includes may be nested, if zero DefaultMaxIncludeDepth is used.
+	MaxIncludeDepth int
+
+	// Dependencies, if not nil, records the files that are read: includes, code includes and BibTeX files.
+	Dependencies *Dependencies
+
+	i    string
+	file string    // the initial file as given to NewInitial
+	src  *sources  // tracks buffers to determine source spans
+	inc  *includes // the files being included, only set by NewInitial and NewInitialFS
 }
 
 // NewInitial returns an initialized Initial.
 func NewInitial(s string) Initial {
 	if path.IsAbs(s) {
-		return Initial{i: path.Dir(s)}
+		return Initial{i: path.Dir(s), file: s, inc: &includes{}}
 	}
 
 	cwd, _ := os.Getwd()
 	if s == "" {
-		return Initial{i: cwd}
+		return Initial{i: cwd, inc: &includes{}}
 	}
-	return Initial{i: path.Dir(filepath.Join(cwd, s))}
+	return Initial{i: path.Dir(filepath.Join(cwd, s)), file: s, inc: &includes{}}
+}
+
+// NewInitialFS returns an initialized Initial that reads from fsys, s is the name of the initial file in fsys, or
+// empty if the document doesn't come from fsys. An absolute name is taken relative to the root of fsys.
+func NewInitialFS(fsys fs.FS, s string) Initial {
+	if s == "" {
+		return Initial{FS: fsys, i: ".", inc: &includes{}}
+	}
+	s = path.Clean(strings.TrimPrefix(s, "/"))
+	return Initial{FS: fsys, i: path.Dir(s), file: s, inc: &includes{}}
 }
 
 // path returns the full path we should use according to from, file and initial.
 func (i Initial) path(from, file string) string {
+	if i.FS != nil {
+		// names in an fs.FS are unrooted, absolute ones start at its root.
+		if path.IsAbs(file) {
+			return path.Clean(file[1:])
+		}
+		if path.IsAbs(from) {
+			return path.Join(from[1:], file)
+		}
+		return path.Join(i.i, from, file)
+	}
+
 	if path.IsAbs(file) {
 		return file
 	}
 	if path.IsAbs(from) {
-		filepath.Join(from, file)
+		return filepath.Join(from, file)
 	}
 
 	f1 := filepath.Join(i.i, from)
@@ -54,17 +115,23 @@ func (i Initial) path(from, file string) string {
 	return filepath.Join(f1, file)
 }
 
-// pathAllowed returns true is file is on the same level or below the initial file.
-func (i Initial) pathAllowed(file string) bool {
-	x, err := filepath.Rel(i.i, file)
-	if err != nil {
-		return false
+// rel returns file relative to the directory of the initial file, if file is below it.
+func (i Initial) rel(file string) string {
+	if i.FS != nil {
+		if i.i == "." {
+			return file
+		}
+		return strings.TrimPrefix(file, i.i+"/")
+	}
+	if x, err := filepath.Rel(i.i, file); err == nil {
+		return x
 	}
-	return !strings.Contains(x, "..")
+	return file
 }
 
-// parseAddress parses a code address directive and returns the bytes or an error.
-func parseAddress(addr []byte, data []byte) ([]byte, error) {
+// parseAddress parses a code address directive and returns the bytes or an error. File is the name of the
+// file data was read from, it's used to find the language for symbol addresses.
+func parseAddress(addr []byte, data []byte, file string) ([]byte, error) {
 	bytes.TrimSpace(addr)
 
 	if len(addr) == 0 {
@@ -84,26 +151,69 @@ func parseAddress(addr []byte, data []byte) ([]byte, error) {
 		}
 
 		end := SkipUntilChar(addr, start+1, quote)
-		prefix = addr[start+1 : end]
+		prefix = append([]byte{}, addr[start+1:end]...) // addr is changed below.
 		if len(prefix) == 0 {
 			return nil, fmt.Errorf("invalid prefix in address specification: %s", addr)
 		}
 
 		addr = append(addr[:x], addr[end+1:]...)
-		addr = bytes.Replace(addr, []byte(";"), []byte(""), 1)
-		if len(addr) == 0 {
-			data = addPrefix(data, prefix)
-			return data, nil
+	}
+
+	// check for a named region and dedent, these are separated with ; from the other options.
+	var region []byte
+	if m := reRegion.FindSubmatchIndex(addr); m != nil {
+		region = append([]byte{}, addr[m[4]:m[5]]...)
+		addr = append(addr[:m[0]], addr[m[1]:]...)
+	}
+	var symbol [][]byte // kind and name
+	if m := reSymbol.FindSubmatchIndex(addr); m != nil {
+		symbol = [][]byte{append([]byte{}, addr[m[4]:m[5]]...), append([]byte{}, addr[m[6]:m[7]]...)}
+		addr = append(addr[:m[0]], addr[m[1]:]...)
+	}
+	dedent := false
+	if m := reDedent.FindIndex(addr); m != nil {
+		dedent = true
+		addr = append(addr[:m[0]], addr[m[1]:]...)
+	}
+	addr = bytes.Trim(addr, "; ")
+
+	switch {
+	case region != nil && (len(addr) > 0 || symbol != nil):
+		return nil, fmt.Errorf("a region can't be combined with lines or a symbol in address specification: %s", addr)
+	case symbol != nil && len(addr) > 0:
+		return nil, fmt.Errorf("a symbol can't be combined with lines in address specification: %s", addr)
+	case symbol != nil:
+		lo, hi, err := addrSymbol(file, data, string(symbol[0]), string(symbol[1]))
+		if err != nil {
+			return nil, err
+		}
+		data = lines(data, lo, hi)
+	case region != nil:
+		var err error
+		if data, err = addrRegion(data, string(region)); err != nil {
+			return nil, err
+		}
+	case len(addr) > 0:
+		lo, hi, err := addrToByteRange(addr, data)
+		if err != nil {
+			return nil, err
 		}
+
+		data = lines(data, lo, hi)
 	}
 
-	lo, hi, err := addrToByteRange(addr, data)
-	if err != nil {
-		return nil, err
+	if dedent {
+		data = removeIndent(data)
+	}
+	if prefix != nil {
+		data = addPrefix(data, prefix)
 	}
+	return data, nil
+}
 
-	// Acme pattern matches can stop mid-line,
-	// so run to end of line in both directions if not at line start/end.
+// lines returns the lines of data between lo and hi. Acme pattern matches can stop mid-line,
+// so run to end of line in both directions if not at line start/end.
+func lines(data []byte, lo, hi int) []byte {
 	for lo > 0 && data[lo-1] != '\n' {
 		lo--
 	}
@@ -112,12 +222,84 @@ func parseAddress(addr []byte, data []byte) ([]byte, error) {
 			hi++
 		}
 	}
+	return data[lo:hi]
+}
 
-	data = data[lo:hi]
-	if prefix != nil {
-		data = addPrefix(data, prefix)
+var (
+	reSymbol = regexp.MustCompile(`(^|;)\s*(func|type)=([\w.()*]+)\s*(;|$)`)
+	reRegion = regexp.MustCompile(`(^|;)\s*region=([\w.-]+)\s*(;|$)`)
+	reDedent = regexp.MustCompile(`(^|;)\s*dedent\s*(;|$)`)
+
+	// reMarker matches the lines that start and end a region: "START name" or "END name", optionally in a
+	// comment, i.e. "// START handler" or "<!-- END example -->".
+	reMarker = regexp.MustCompile(`^\s*(?://|#|--|;|%|/\*|<!--|\(\*)?\s*(START|END)\s+([\w.-]+)\s*(?:\*/|-->|\*\))?\s*$`)
+)
+
+// addrRegion returns the lines between the "START name" and "END name" lines in data. The marker lines, also
+// the ones of regions nested in this region, are removed.
+func addrRegion(data []byte, name string) ([]byte, error) {
+	var (
+		buf   bytes.Buffer
+		found bool
+	)
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		m := reMarker.FindSubmatch(bytes.TrimRight(line, "\r\n"))
+		if m == nil {
+			if found {
+				buf.Write(line)
+			}
+			continue
+		}
+		if string(m[2]) != name {
+			continue
+		}
+		switch {
+		case string(m[1]) == "START" && !found:
+			found = true
+		case string(m[1]) == "END" && found:
+			return buf.Bytes(), nil
+		}
 	}
-	return data, nil
+	if !found {
+		return nil, fmt.Errorf("no start of region %q", name)
+	}
+	return nil, fmt.Errorf("no end of region %q", name)
+}
+
+// removeIndent removes the white space at the start of the lines in data that all non-blank lines have in common.
+func removeIndent(data []byte) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	var indent []byte
+	first := true
+	for _, l := range lines {
+		if len(bytes.TrimSpace(l)) == 0 {
+			continue
+		}
+		ws := l[:len(l)-len(bytes.TrimLeft(l, " \t"))]
+		if first {
+			indent, first = ws, false
+			continue
+		}
+		n := 0
+		for n < len(indent) && n < len(ws) && indent[n] == ws[n] {
+			n++
+		}
+		indent = indent[:n]
+	}
+	if len(indent) == 0 {
+		return data
+	}
+
+	buf := &bytes.Buffer{}
+	for _, l := range lines {
+		if bytes.HasPrefix(l, indent) {
+			l = l[len(indent):]
+		} else if len(bytes.TrimSpace(l)) == 0 {
+			l = bytes.TrimLeft(l, " \t")
+		}
+		buf.Write(l)
+	}
+	return buf.Bytes()
 }
 
 // addrToByteRange evaluates the given address. It returns the start and end index of the data we should return.
@@ -0,0 +1,270 @@
+package mparser
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/diag"
+)
+
+func TestReadIncludeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"doc/draft.md":          {Data: []byte("{{sections/intro.md}}\n")},
+		"doc/sections/intro.md": {Data: []byte("Intro.\n")},
+		"doc/code.go":           {Data: []byte("package main\n\nfunc main() {}\n")},
+		"secret.md":             {Data: []byte("Secret.\n")},
+	}
+
+	tests := []struct {
+		from, file, address string
+		expect              string
+		code                string // expected diagnostic code, if any
+	}{
+		{"", "sections/intro.md", "", "Intro.\n", ""},
+		{"sections", "../code.go", "3,", "func main() {}\n", ""},
+		{"", "/doc/code.go", "1,1", "package main\n", ""},
+		{"", "../secret.md", "", "", "include-not-allowed"},
+		{"", "missing.md", "", "", "include-read"},
+		{"", "code.go", "func=main", "func main() {}\n", ""},
+		{"", "sections/intro.md", "func=main", "", "include-language"},
+	}
+	for _, tc := range tests {
+		init := NewInitialFS(fsys, "doc/draft.md")
+		init.Diagnostics = diag.New()
+		got := init.ReadInclude(tc.from, tc.file, []byte(tc.address))
+		if string(got) != tc.expect {
+			t.Errorf("%s: expected %q, got %q", tc.file, tc.expect, got)
+		}
+		code := ""
+		if all := init.Diagnostics.List(); len(all) > 0 {
+			code = all[0].Code
+		}
+		if code != tc.code {
+			t.Errorf("%s: expected diagnostic %q, got %q", tc.file, tc.code, code)
+		}
+	}
+}
+
+func TestReadIncludeSandbox(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		name = filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(name), 0755)
+		if err := ioutil.WriteFile(name, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("secret.md", "Secret.\n")
+	write("shared/common.md", "Common.\n")
+	write("doc/a..b.md", "Dots.\n")
+	write("doc/large.md", strings.Repeat("x", 100)+"\n")
+	write("doc/cycle-a.md", "A.\n\n{{cycle-b.md}}\n")
+	write("doc/cycle-b.md", "B.\n\n{{cycle-a.md}}\n")
+	write("doc/fenced.md", "```\n{{fenced.md}}\n```\n")
+	write("doc/depth-1.md", "{{depth-2.md}}\n")
+	write("doc/depth-2.md", "{{depth-3.md}}\n")
+	write("doc/depth-3.md", "Deep.\n")
+	write("doc/list.md", "* item\n\n    {{quote.md}}\n")
+	write("doc/quote.md", "> {{list.md}}\n")
+	write("doc/twice.md", "* {{depth-3.md}}\n\n* {{depth-3.md}}\n")
+	if err := os.Symlink(filepath.Join(dir, "secret.md"), filepath.Join(dir, "doc", "link.md")); err != nil {
+		t.Fatal(err)
+	}
+	draft := filepath.Join(dir, "doc", "draft.md")
+
+	tests := []struct {
+		file  string
+		setup func(*Initial)
+		code  string // expected diagnostic code, empty if the include should succeed
+	}{
+		{"a..b.md", nil, ""},
+		{"../secret.md", nil, "include-not-allowed"},
+		{"link.md", nil, "include-not-allowed"},
+		{"link.md", func(i *Initial) { i.Flags |= UnsafeInclude }, ""},
+		{"../shared/common.md", func(i *Initial) { i.Roots = []string{filepath.Join(dir, "doc"), filepath.Join(dir, "shared")} }, ""},
+		{"large.md", func(i *Initial) { i.MaxIncludeSize = 50 }, "include-size"},
+		{"large.md", func(i *Initial) { i.MaxIncludeSize = 101 }, ""},
+		{"cycle-a.md", nil, "include-cycle"},
+		{"fenced.md", nil, ""},
+		{"depth-1.md", func(i *Initial) { i.MaxIncludeDepth = 2 }, "include-depth"},
+		{"depth-1.md", func(i *Initial) { i.MaxIncludeDepth = 3 }, ""},
+		{"list.md", nil, "include-cycle"},
+		{"twice.md", func(i *Initial) { i.MaxIncludeDepth = 2 }, ""},
+		{"a..b.md", func(i *Initial) { i.Flags |= NoInclude }, "include-disabled"},
+	}
+	for _, tc := range tests {
+		init := NewInitial(draft)
+		init.Diagnostics = diag.New()
+		if tc.setup != nil {
+			tc.setup(&init)
+		}
+		// the includes are nested, so they must be read by the parser.
+		var got []byte
+		p := parser.NewWithExtensions(Extensions)
+		p.Opts = parser.Options{ReadIncludeFn: func(from, file string, address []byte) []byte {
+			data := init.ReadInclude(from, file, address)
+			if from == "" {
+				got = data
+			}
+			return data
+		}}
+		markdown.Parse([]byte("{{"+tc.file+"}}\n"), p)
+		code := ""
+		if all := init.Diagnostics.List(); len(all) > 0 {
+			code = all[0].Code
+		}
+		if code != tc.code {
+			t.Errorf("%s: expected diagnostic %q, got %q: %v", tc.file, tc.code, code, init.Diagnostics.List())
+		}
+		if tc.code == "" && len(got) == 0 {
+			t.Errorf("%s: expected the file to be included", tc.file)
+		}
+	}
+}
+
+func TestParseAddressRegion(t *testing.T) {
+	data := []byte(`package main
+
+// START handler
+func handler() {
+	// START body
+	return
+	// END body
+}
+
+// END handler
+
+func main() {
+	/* START main */
+	handler()
+	/* END main */
+}
+`)
+	tests := []struct {
+		addr   string
+		expect string
+		err    bool
+	}{
+		{"region=handler", "func handler() {\n\treturn\n}\n\n", false},
+		{"region=body", "\treturn\n", false},
+		{"region=body;dedent", "return\n", false},
+		{`region=main;dedent;prefix="> "`, "> handler()", false}, // ReadInclude adds the final newline.
+		{`prefix="> ";region=body`, "> \treturn", false},
+		{"dedent; region=main", "handler()\n", false},
+		{"region=missing", "", true},
+		{"region=body;3,4", "", true},
+		{`3,5;prefix="C: "`, "C: // START handler\nC: func handler() {", false},
+	}
+	for _, tc := range tests {
+		got, err := parseAddress([]byte(tc.addr), data, "main.go")
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %q", tc.addr, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.addr, err)
+			continue
+		}
+		if string(got) != tc.expect {
+			t.Errorf("%s: expected %q, got %q", tc.addr, tc.expect, got)
+		}
+	}
+}
+
+func TestParseAddressSymbol(t *testing.T) {
+	data := []byte(`package main
+
+// Config configures the server.
+type Config struct {
+	Addr string
+}
+
+type (
+	// Handler handles requests.
+	Handler func()
+	Other   int
+)
+
+type Server[T any] struct{}
+
+// ServeHTTP serves the request.
+func (s *Server[T]) ServeHTTP() {
+	s.handle()
+}
+
+func (c Config) ServeHTTP() {}
+
+func main() {
+	var s Server[int]
+	s.ServeHTTP()
+}
+`)
+	tests := []struct {
+		addr   string
+		expect string
+		err    bool
+	}{
+		{"type=Config", "// Config configures the server.\ntype Config struct {\n\tAddr string\n}\n", false},
+		{"type=Handler", "\t// Handler handles requests.\n\tHandler func()\n", false},
+		{"type=Handler;dedent", "// Handler handles requests.\nHandler func()\n", false},
+		{"func=ServeHTTP", "// ServeHTTP serves the request.\nfunc (s *Server[T]) ServeHTTP() {\n\ts.handle()\n}\n", false},
+		{"func=Config.ServeHTTP", "func (c Config) ServeHTTP() {}\n", false},
+		{"func=(*Server).ServeHTTP", "// ServeHTTP serves the request.\nfunc (s *Server[T]) ServeHTTP() {\n\ts.handle()\n}\n", false},
+		{`func=main;prefix="> "`, "> func main() {\n> \tvar s Server[int]\n> \ts.ServeHTTP()\n> }", false},
+		{"func=missing", "", true},
+		{"type=main", "", true},
+		{"func=main;3,4", "", true},
+	}
+	for _, tc := range tests {
+		got, err := parseAddress([]byte(tc.addr), data, "server.go")
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %q", tc.addr, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.addr, err)
+			continue
+		}
+		if string(got) != tc.expect {
+			t.Errorf("%s: expected %q, got %q", tc.addr, tc.expect, got)
+		}
+	}
+
+	if _, err := parseAddress([]byte("func=main"), data, "server.c"); !errors.Is(err, errUnknownLanguage) {
+		t.Errorf("expected an unknown language error, got %v", err)
+	}
+}
+
+func TestReadIncludeLocation(t *testing.T) {
+	fsys := fstest.MapFS{
+		"doc/draft.md":   {Data: []byte("# Draft\n\n{{sub/a.md}}\n")},
+		"doc/sub/a.md":   {Data: []byte("A.\n\n* item\n\n    {{missing.md}}\n")},
+		"doc/missing.md": {Data: []byte("Not in sub.\n")},
+	}
+	input := fsys["doc/draft.md"].Data
+	init := NewInitialFS(fsys, "doc/draft.md")
+	init.Diagnostics = diag.New()
+	init.Track(input)
+	p := parser.NewWithExtensions(Extensions)
+	p.Opts = parser.Options{ParserHook: init.Hook, ReadIncludeFn: init.ReadInclude}
+	markdown.Parse(input, p)
+
+	all := init.Diagnostics.List()
+	if len(all) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", all)
+	}
+	if d := all[0]; d.Code != "include-read" || d.File != "sub/a.md" || d.Line != 5 || d.Column != 5 {
+		t.Errorf("expected %s at %s, got %s", "include-read", "sub/a.md:5:5", d)
+	}
+}
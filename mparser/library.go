@@ -0,0 +1,68 @@
+package mparser
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+)
+
+// libraryReference looks up the reference for item in the reference library. Both the bibxml layout
+// (bibxml/reference.RFC.2119.xml) and a flat directory (reference.RFC.2119.xml), as used by xml2rfc's
+// cache, are supported. BCP and STD references are <referencegroup>s.
+func (in Initial) libraryReference(item *mast.BibliographyItem) (*reference.Reference, *reference.ReferenceGroup) {
+	anchor := string(item.Anchor)
+	dir, file := reference.BibXML(anchor)
+
+	report := func(sev diag.Severity, code, format string, a ...interface{}) {
+		d := diag.Diagnostic{Severity: sev, Code: code, File: in.file, Message: fmt.Sprintf(format, a...)}
+		if span, ok := in.Sources.Span(item); ok {
+			d.File, d.Line, d.Column = span.File, span.Line, span.Column
+		}
+		in.Diagnostics.Add(d)
+	}
+
+	var data []byte
+	for _, path := range []string{filepath.Join(in.Library, dir, file), filepath.Join(in.Library, file)} {
+		var err error
+		if data, err = ioutil.ReadFile(path); err == nil {
+			break
+		}
+		if !os.IsNotExist(err) {
+			report(diag.Warning, "library-read", "Failure to read %q from the reference library: %s", path, err)
+			return nil, nil
+		}
+	}
+	if data == nil {
+		report(diag.Info, "library-missing", "Reference %q not found in the reference library %q", anchor, in.Library)
+		return nil, nil
+	}
+
+	// Strip the XML declaration, so we can see if this is a <referencegroup>.
+	if bytes.HasPrefix(data, []byte("<?xml")) {
+		if end := bytes.Index(data, []byte("?>")); end > 0 {
+			data = data[end+2:]
+		}
+	}
+	x, group, err := unmarshalReference(bytes.TrimSpace(data))
+	if err != nil {
+		report(diag.Warning, "library-read", "Failed to unmarshal reference %q from the reference library: %s", anchor, err)
+		return nil, nil
+	}
+	// The anchor must match the xref's target, which never has the I-D version.
+	if i := strings.Index(anchor, "#"); i > 0 {
+		anchor = anchor[:i]
+	}
+	if group != nil {
+		group.Anchor = anchor
+		return nil, group
+	}
+	x.Anchor = anchor
+	return x, nil
+}
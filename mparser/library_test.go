@@ -0,0 +1,56 @@
+package mparser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+func TestLibrary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Mkdir(filepath.Join(dir, "bibxml3"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "bibxml3", "reference.I-D.draft-ietf-foo-02.xml"), []byte(`<?xml version='1.0' encoding='UTF-8'?>
+<reference anchor="I-D.draft-ietf-foo-02" target="https://datatracker.ietf.org/doc/html/draft-ietf-foo-02">
+  <front><title>Foo</title></front>
+</reference>
+`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "reference.BCP.14.xml"), []byte(`<referencegroup anchor="BCP14"><reference anchor="RFC2119"><front><title>Key words</title></front></reference></referencegroup>`), 0644)
+
+	init := NewInitial("")
+	init.Diagnostics = diag.New()
+	init.Library = dir
+	p := parser.NewWithExtensions(Extensions)
+	p.Opts = parser.Options{ParserHook: init.Hook}
+	doc := markdown.Parse([]byte("See [@I-D.ietf-foo#02], [@BCP14] and [@RFC2119].\n"), p)
+
+	_, informative := init.CitationToBibliography(doc)
+	items := map[string]*mast.BibliographyItem{}
+	for _, c := range informative.GetChildren() {
+		item := c.(*mast.BibliographyItem)
+		items[string(item.Anchor)] = item
+	}
+
+	if r := items["I-D.ietf-foo#02"].Reference; r == nil || r.Anchor != "I-D.ietf-foo" || r.Front.Title != "Foo" {
+		t.Errorf("expected I-D to be resolved from the library, got %v", r)
+	}
+	if g := items["BCP14"].ReferenceGroup; g == nil || len(g.References) != 1 {
+		t.Errorf("expected BCP14 to be resolved to a <referencegroup> with one reference")
+	}
+	if r := items["RFC2119"]; r.Reference != nil || r.ReferenceGroup != nil {
+		t.Errorf("expected RFC2119 not to be resolved")
+	}
+	if list := init.Diagnostics.List(); len(list) != 1 || list[0].Code != "library-missing" {
+		t.Errorf("expected a single library-missing diagnostic, got %v", list)
+	}
+}
@@ -0,0 +1,178 @@
+package mparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+)
+
+// referenceTOML is a reference as it is written in a TOML reference block.
+type referenceTOML struct {
+	Anchor     string
+	Target     string
+	Title      string
+	Date       interface{} // TOML date, or a string or integer with (part of) the date
+	Author     []authorTOML
+	SeriesInfo []reference.SeriesInfo
+	RefContent []string
+	Annotation []string
+}
+
+// authorTOML is an author in a TOML reference block, the keys are the same as for authors in the title block.
+type authorTOML struct {
+	Initials           string
+	Surname            string
+	Fullname           string
+	Role               string
+	Organization       string
+	OrganizationAbbrev string `toml:"abbrev"`
+	Email              string
+	URI                string
+}
+
+var (
+	referenceTOMLStart = []byte("%%% reference\n")
+	referenceTOMLEnd   = []byte("\n%%%\n")
+)
+
+// IsReferenceTOML returns the TOML reference block data starts with, including the start and end markers.
+func IsReferenceTOML(data []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(data, referenceTOMLStart) {
+		return nil, false
+	}
+	end := bytes.Index(data[len(referenceTOMLStart)-1:], referenceTOMLEnd)
+	if end < 0 {
+		return nil, false
+	}
+	return data[:len(referenceTOMLStart)-1+end+len(referenceTOMLEnd)], true
+}
+
+// referenceTOMLHook parses a TOML reference block. The reference is converted to XML, so the returned node
+// can't be distinguished from one created from an XML reference, the TOML source is kept in its Content.
+func (in Initial) referenceTOMLHook(data []byte) (ast.Node, []byte, int) {
+	block, ok := IsReferenceTOML(data)
+	if !ok {
+		return nil, nil, 0
+	}
+	node := &mast.ReferenceBlock{}
+	node.Content = block
+	span, _ := in.span(data)
+
+	report := func(sev diag.Severity, line, column int, format string, a ...interface{}) {
+		d := diag.Diagnostic{Severity: sev, Code: "reference-toml", File: in.file, Message: fmt.Sprintf(format, a...)}
+		if !span.IsZero() {
+			d.File, d.Line = span.File, span.Line
+		}
+		// The TOML starts on the line after the opening marker.
+		if line > 0 {
+			d.Line, d.Column = line+1, column
+			if !span.IsZero() {
+				d.Line += span.Line - 1
+			}
+		}
+		in.Diagnostics.Add(d)
+	}
+
+	body := block[len(referenceTOMLStart) : len(block)-len(referenceTOMLEnd)+1]
+	ref, err := referenceFromTOML(body)
+	if err != nil {
+		line, column := 0, 0
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			line, column = perr.Position.Line, perr.Position.Col
+		}
+		report(diag.Error, line, column, "Failure parsing reference block: %s", err)
+		return node, nil, len(block)
+	}
+
+	out, err := xml.MarshalIndent(ref, "", "   ")
+	if err != nil {
+		report(diag.Error, 0, 0, "Failure converting reference block %q to XML: %s", ref.Anchor, err)
+		return node, nil, len(block)
+	}
+	node.Literal = out
+	return node, nil, len(block)
+}
+
+// referenceFromTOML decodes data into a reference.
+func referenceFromTOML(data []byte) (*reference.Reference, error) {
+	var r referenceTOML
+	meta, err := toml.Decode(string(data), &r)
+	if err != nil {
+		return nil, err
+	}
+	if keys := meta.Undecoded(); len(keys) > 0 {
+		return nil, fmt.Errorf("unknown key %q", keys[0].String())
+	}
+	if r.Anchor == "" {
+		return nil, fmt.Errorf("no anchor")
+	}
+
+	ref := &reference.Reference{
+		Anchor:     r.Anchor,
+		Target:     r.Target,
+		Front:      reference.Front{Title: r.Title},
+		Series:     r.SeriesInfo,
+		RefContent: r.RefContent,
+		Annotation: r.Annotation,
+	}
+	if r.Date != nil {
+		if ref.Front.Date, err = referenceDate(r.Date); err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range r.Author {
+		author := reference.Author{Fullname: a.Fullname, Initials: a.Initials, Surname: a.Surname, Role: a.Role}
+		if a.Organization != "" || a.OrganizationAbbrev != "" {
+			author.Organization = &reference.Organization{Value: a.Organization, Abbrev: a.OrganizationAbbrev}
+		}
+		if a.Email != "" || a.URI != "" {
+			author.Address = &reference.Address{Email: a.Email, URI: a.URI}
+		}
+		ref.Front.Authors = append(ref.Front.Authors, author)
+	}
+	return ref, nil
+}
+
+// referenceDate converts a TOML date to a reference date. A date can be a TOML date, a year as an integer, or
+// a string with the year, year-month or year-month-day.
+func referenceDate(v interface{}) (*reference.Date, error) {
+	switch d := v.(type) {
+	case time.Time:
+		return &reference.Date{Year: strconv.Itoa(d.Year()), Month: d.Month().String(), Day: strconv.Itoa(d.Day())}, nil
+	case int64:
+		return &reference.Date{Year: strconv.FormatInt(d, 10)}, nil
+	case string:
+		parts := strings.Split(d, "-")
+		date := &reference.Date{Year: parts[0]}
+		if _, err := strconv.Atoi(parts[0]); err != nil || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid date %q", d)
+		}
+		if len(parts) > 1 {
+			m, err := strconv.Atoi(parts[1])
+			if err != nil || m < 1 || m > 12 {
+				return nil, fmt.Errorf("invalid month in date %q", d)
+			}
+			date.Month = time.Month(m).String()
+		}
+		if len(parts) > 2 {
+			day, err := strconv.Atoi(parts[2])
+			if err != nil || day < 1 || day > 31 {
+				return nil, fmt.Errorf("invalid day in date %q", d)
+			}
+			date.Day = strconv.Itoa(day)
+		}
+		return date, nil
+	}
+	return nil, fmt.Errorf("invalid date %v", v)
+}
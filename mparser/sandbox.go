@@ -0,0 +1,262 @@
+package mparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// Include limits used when Initial doesn't set them.
+const (
+	DefaultMaxIncludeSize  = 10 << 20 // 10 MiB
+	DefaultMaxIncludeDepth = 10
+)
+
+var (
+	errTooLarge     = errors.New("file too large")
+	errIncludeCycle = errors.New("include cycle")
+	errIncludeDepth = errors.New("includes nested too deep")
+)
+
+// roots returns the directories includes may be read from.
+func (i Initial) roots() []string {
+	if len(i.Roots) == 0 {
+		return []string{i.i}
+	}
+	if i.FS == nil {
+		return i.Roots
+	}
+	roots := make([]string, len(i.Roots))
+	for n, r := range i.Roots {
+		roots[n] = path.Clean(strings.TrimPrefix(r, "/"))
+	}
+	return roots
+}
+
+// pathAllowed returns true if file is in one of the roots, by default the directory of the initial file. On the
+// operating system's file system symbolic links are resolved first, so a link can't point outside of the roots.
+// In an fs.FS the names are checked, following links is up to the FS.
+func (i Initial) pathAllowed(file string) bool {
+	if i.FS != nil {
+		if !fs.ValidPath(file) {
+			return false
+		}
+		for _, root := range i.roots() {
+			if root == "." || file == root || strings.HasPrefix(file, root+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	file = resolve(file)
+	for _, root := range i.roots() {
+		x, err := filepath.Rel(resolve(root), file)
+		if err != nil {
+			continue
+		}
+		if x != ".." && !strings.HasPrefix(x, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the absolute path of file with its symbolic links resolved. When file doesn't exist only its
+// directory is resolved, reading it will fail later on.
+func resolve(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	if r, err := filepath.EvalSymlinks(file); err == nil {
+		return r
+	}
+	if r, err := filepath.EvalSymlinks(filepath.Dir(file)); err == nil {
+		return filepath.Join(r, filepath.Base(file))
+	}
+	return file
+}
+
+// readFile reads the file name from i.FS, or from the operating system's file system when FS is nil. Files
+// larger than the maximum include size aren't read.
+func (i Initial) readFile(name string) ([]byte, error) {
+	var (
+		f   fs.File
+		err error
+	)
+	if i.FS != nil {
+		f, err = i.FS.Open(name)
+	} else {
+		f, err = os.Open(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	max := i.MaxIncludeSize
+	if max <= 0 {
+		max = DefaultMaxIncludeSize
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(f, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", errTooLarge, name, max)
+	}
+	return data, nil
+}
+
+// ReadFile reads the file name that the document refers to, such as an ascii-art image, a relative name is taken
+// relative to the initial file. The same rules as for includes apply: the file must be in i.Roots unless the
+// UnsafeInclude flag is set, it may not be larger than i.MaxIncludeSize and it's read from i.FS if set. Nothing is
+// read when the NoInclude flag is set.
+func (i Initial) ReadFile(name string) ([]byte, error) {
+	path := i.path("", name)
+	if i.Flags&NoInclude != 0 {
+		return nil, fmt.Errorf("%s: includes are disabled", path)
+	}
+	if i.Flags&UnsafeInclude == 0 && !i.pathAllowed(path) {
+		return nil, fmt.Errorf("%s: path is not on or below %q", path, strings.Join(i.roots(), ", "))
+	}
+	return i.readFile(path)
+}
+
+// ReadFileFunc returns read, or if that's nil the ReadFile of an Initial without a file name: files are read
+// relative to, and only from below, the current directory. The renderers use it for their ReadFile option.
+func ReadFileFunc(read func(name string) ([]byte, error)) func(name string) ([]byte, error) {
+	if read != nil {
+		return read
+	}
+	return NewInitial("").ReadFile
+}
+
+// includes is the stack of files that are being included, it's used to find include cycles and includes that
+// are nested too deep. The parser only gives ReadInclude the directory of the including file (from) and doesn't
+// tell when it's done with an included file, so files that are done stay on the stack until a later include
+// shows they are: the including file is the last file on the stack that is in from and has the include.
+type includes struct {
+	sync.Mutex
+	root  included // the initial file, only its content is set, see Track
+	stack []included
+}
+
+// included is a file on the include stack.
+type included struct {
+	file    string // the path of the file
+	dir     string // the file's directory as the parser gives it in from
+	data    []byte // the data handed to the parser
+	content []byte // the content of the file, to find the line of an include
+	next    int    // offset in content where the search for the next include starts
+}
+
+// including returns the files that include file, starting at the initial file's include, and drops the files
+// that are done from the stack. The span of the include is returned as well, its File is the path of the
+// including file, or empty for the initial file. Its Line is zero if the include can't be found.
+func (inc *includes) including(from, file string) ([]included, mast.Span) {
+	if inc == nil {
+		return nil, mast.Span{}
+	}
+	inc.Lock()
+	defer inc.Unlock()
+
+	directive := []byte("{{" + file + "}}")
+	n := -1 // the initial file, from is empty for its includes.
+	if from != "" {
+		for k := len(inc.stack) - 1; k >= 0; k-- {
+			if inc.stack[k].dir != from {
+				continue
+			}
+			if n < 0 {
+				n = k // if no file in from has the include, assume the last one.
+			}
+			if bytes.Contains(inc.stack[k].data, directive) {
+				n = k
+				break
+			}
+		}
+	}
+	inc.stack = inc.stack[:n+1]
+
+	f := &inc.root
+	if n >= 0 {
+		f = &inc.stack[n]
+	}
+	at := mast.Span{File: f.file}
+	if k := bytes.Index(f.content[f.next:], directive); k >= 0 {
+		k += f.next
+		f.next = k + len(directive)
+		at.Line = bytes.Count(f.content[:k], []byte("\n")) + 1
+		at.Column = k - bytes.LastIndexByte(f.content[:k], '\n')
+	}
+	return append([]included{}, inc.stack...), at
+}
+
+// push pushes file, which is included from from and read from name, on the stack. Data is handed to the parser,
+// content is the full file.
+func (inc *includes) push(from, file, name string, content, data []byte) {
+	if inc == nil {
+		return
+	}
+	// this is the directory the parser puts on its own include stack.
+	dir := path.Dir(filepath.Join(from, file))
+	if path.IsAbs(file) {
+		dir = path.Dir(file)
+	}
+	next := offset(content, data)
+	if next < 0 {
+		content, next = data, 0
+	}
+
+	inc.Lock()
+	defer inc.Unlock()
+	inc.stack = append(inc.stack, included{file: name, dir: dir, data: data, content: content, next: next})
+}
+
+// nesting checks if name may be included by the files in incs. An error is returned when it's already being
+// included, i.e. there is a cycle, or when it's nested deeper than the maximum include depth.
+func (i Initial) nesting(incs []included, name string) error {
+	max := i.MaxIncludeDepth
+	if max <= 0 {
+		max = DefaultMaxIncludeDepth
+	}
+
+	chain := make([]string, 0, len(incs)+2)
+	if i.file != "" {
+		chain = append(chain, i.path("", filepath.Base(i.file)))
+	}
+	for _, inc := range incs {
+		chain = append(chain, inc.file)
+	}
+	chain = append(chain, name)
+
+	for n, c := range chain[:len(chain)-1] {
+		if c == name {
+			return fmt.Errorf("%w: %s", errIncludeCycle, i.chain(chain[n:]))
+		}
+	}
+	if len(incs) >= max {
+		return fmt.Errorf("%w: %s (the maximum is %d)", errIncludeDepth, i.chain(chain), max)
+	}
+	return nil
+}
+
+// chain returns files as "a.md -> b.md -> a.md".
+func (i Initial) chain(files []string) string {
+	rel := make([]string, len(files))
+	for n, f := range files {
+		rel[n] = i.rel(f)
+	}
+	return strings.Join(rel, " -> ")
+}
@@ -0,0 +1,205 @@
+package mparser
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// sources keeps track of all the buffers the parser sees: the initial document and all included
+// files. The parser hands out sub slices of these buffers, so by comparing the address of the first
+// byte of a slice we can find out which file, and where in that file, it originated from.
+type sources struct {
+	sync.Mutex
+	input   []byte    // the normalized initial document
+	seen    bool      // did we see the parser's copy of input
+	buffers []*buffer // the initial document's buffer is added when first seen, includes as they are read.
+}
+
+type buffer struct {
+	file    string
+	content []byte // content of the file
+	data    []byte // data as seen by the parser
+	base    int    // offset of data in content
+	lines   []int  // offsets of the newlines in content
+}
+
+func newBuffer(file string, content, data []byte, base int) *buffer {
+	b := &buffer{file: file, content: content, data: data, base: base}
+	for i, c := range content {
+		if c == '\n' {
+			b.lines = append(b.lines, i)
+		}
+	}
+	return b
+}
+
+// offset returns the offset of data in b's content, or -1 if data does not share b's memory.
+func (b *buffer) offset(data []byte) int {
+	if len(data) == 0 || len(b.data) == 0 {
+		return -1
+	}
+	k := cap(b.data) - cap(data)
+	if k < 0 || k >= len(b.data) || &b.data[k] != &data[0] {
+		return -1
+	}
+	return b.base + k
+}
+
+// span returns the span for offset in b's content.
+func (b *buffer) span(offset int) mast.Span {
+	n := sort.SearchInts(b.lines, offset) // number of newlines before offset
+	column := offset + 1
+	if n > 0 {
+		column = offset - b.lines[n-1]
+	}
+	return mast.Span{File: b.file, Line: n + 1, Column: column}
+}
+
+// Track enables the tracking of source spans of the nodes in the document input, that is about to be
+// parsed. The spans are recorded in i.Sources, which is allocated if nil. Nodes created by Hook get their span
+// when they are created, for all other nodes call Spans after the document has been parsed.
+func (i *Initial) Track(input []byte) {
+	if i.Sources == nil {
+		i.Sources = mast.NewSources()
+	}
+	i.src = &sources{input: input}
+	if i.inc != nil {
+		i.inc.root.content = input
+	}
+}
+
+// span returns the span of data, if known.
+func (i Initial) span(data []byte) (mast.Span, bool) {
+	b, offset := i.buffer(data)
+	if b == nil {
+		return mast.Span{}, false
+	}
+	return b.span(offset), true
+}
+
+// buffer returns the buffer data is part of and its offset in that buffer's content.
+func (i Initial) buffer(data []byte) (*buffer, int) {
+	if i.src == nil || len(data) == 0 {
+		return nil, 0
+	}
+	i.src.Lock()
+	defer i.src.Unlock()
+
+	// The first time we see a sub slice of the initial document we add it, the parser makes a copy of the
+	// document, so we can't know its memory beforehand. Blocks are parsed from the start of the
+	// document, so data is a suffix of the initial document.
+	if !i.src.seen && len(data) == cap(data) && len(data) <= len(i.src.input) && bytes.HasSuffix(i.src.input, data) {
+		base := len(i.src.input) - len(data)
+		i.src.buffers = append(i.src.buffers, newBuffer(i.file, i.src.input, data, base))
+		i.src.seen = true
+	}
+
+	for _, b := range i.src.buffers {
+		if offset := b.offset(data); offset >= 0 {
+			return b, offset
+		}
+	}
+	return nil, 0
+}
+
+// include records an included file, data is what is handed to the parser, content is the full file.
+func (i Initial) include(path string, content, data []byte) {
+	if i.src == nil {
+		return
+	}
+	file := i.rel(path)
+
+	base := offset(content, data)
+	if base < 0 {
+		content, base = data, 0 // prefixed or otherwise rewritten, we can only track relative to data
+	}
+
+	i.src.Lock()
+	defer i.src.Unlock()
+	i.src.buffers = append(i.src.buffers, newBuffer(file, content, data, base))
+}
+
+// offset returns the offset of data in content, or -1 if data isn't a sub slice of content.
+func offset(content, data []byte) int {
+	if k := cap(content) - cap(data); len(data) > 0 && k >= 0 && k < len(content) && &content[k] == &data[0] {
+		return k
+	}
+	return -1
+}
+
+// Spans walks doc and records the span of each node for which it can be determined in i.Sources. Container
+// nodes get the span of their first child that has one. The parser copies the text of some blocks
+// (lists, block quotes), for leaf nodes in there the text is searched for after the last node with a
+// known span.
+func (i Initial) Spans(doc ast.Node) {
+	if i.src == nil {
+		return
+	}
+	i.Sources.Set(doc, mast.Span{File: i.file, Line: 1, Column: 1})
+
+	var (
+		last    *buffer
+		lastOff int
+	)
+	spans := map[ast.Node]mast.Span{}
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			data := sample(node)
+			b, offset := i.buffer(data)
+			if b == nil && last != nil && len(data) > 0 && node.AsLeaf() != nil {
+				if j := bytes.Index(last.content[lastOff:], data); j >= 0 {
+					b, offset = last, lastOff+j
+				}
+			}
+			if b == nil {
+				return ast.GoToNext
+			}
+			last, lastOff = b, offset
+			spans[node] = b.span(offset)
+			i.Sources.Set(node, spans[node])
+			return ast.GoToNext
+		}
+		if _, ok := spans[node]; ok || node == doc {
+			return ast.GoToNext
+		}
+		for _, child := range node.GetChildren() {
+			if span, ok := spans[child]; ok {
+				spans[node] = span
+				i.Sources.Set(node, span)
+				break
+			}
+		}
+		return ast.GoToNext
+	})
+}
+
+// sample returns the bytes of node that still point into the buffer the node was parsed from.
+func sample(node ast.Node) []byte {
+	switch n := node.(type) {
+	case *ast.Citation:
+		if len(n.Destination) > 0 {
+			return n.Destination[0]
+		}
+	case *ast.CrossReference:
+		return n.Destination
+	case *ast.Index:
+		return n.Item
+	case *ast.Link:
+		return n.Destination
+	case *ast.Image:
+		return n.Destination
+	case *mast.ReferenceBlock, *mast.Title:
+		return nil // span is set by the hook
+	}
+	if l := node.AsLeaf(); l != nil {
+		if len(l.Literal) > 0 {
+			return l.Literal
+		}
+		return l.Content
+	}
+	return nil
+}
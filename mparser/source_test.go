@@ -0,0 +1,89 @@
+package mparser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+func TestSpans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	draft := filepath.Join(dir, "draft.md")
+	os.Mkdir(filepath.Join(dir, "sections"), 0755)
+	ioutil.WriteFile(draft, []byte("%%%\ntitle = \"x\"\n%%%\n\n# Intro\n\nSome text [@RFC2119].\n\n{{sections/intro.md}}\n\n<reference anchor='x' target=''>\n<front><title>x</title></front>\n</reference>\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "sections", "intro.md"), []byte("Included para.\n\n## Sub (!item)\n\n> quote\n"), 0644)
+
+	input, _ := ioutil.ReadFile(draft)
+	init := NewInitial(draft)
+	init.Track(input)
+	p := parser.NewWithExtensions(Extensions)
+	p.Opts = parser.Options{ParserHook: init.Hook, ReadIncludeFn: init.ReadInclude}
+	doc := markdown.Parse(input, p)
+	init.Spans(doc)
+
+	found := map[string]string{}
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		span, _ := init.Sources.Span(node)
+		switch node.(type) {
+		case *mast.Title:
+			found["title"] = span.String()
+		case *mast.ReferenceBlock:
+			found["reference"] = span.String()
+		case *ast.Citation:
+			found["citation"] = span.String()
+		case *ast.Index:
+			found["index"] = span.String()
+		case *ast.BlockQuote:
+			found["blockquote"] = span.String()
+		}
+		return ast.GoToNext
+	})
+
+	want := map[string]string{
+		"title":      draft + ":1:1",
+		"reference":  draft + ":11:1",
+		"citation":   draft + ":7:13",
+		"index":      filepath.Join("sections", "intro.md") + ":3:10",
+		"blockquote": filepath.Join("sections", "intro.md") + ":5:3",
+	}
+	for k, v := range want {
+		if found[k] != v {
+			t.Errorf("expected span of %s to be %q, got %q", k, v, found[k])
+		}
+	}
+}
+
+func TestSpansNoTitle(t *testing.T) {
+	input := []byte("# Intro\n\nSome text [@RFC2119].\n")
+	init := NewInitial("draft.md")
+	init.Track(input)
+	p := parser.NewWithExtensions(Extensions)
+	p.Opts = parser.Options{ParserHook: init.Hook, ReadIncludeFn: init.ReadInclude}
+	doc := markdown.Parse(input, p)
+	init.Spans(doc)
+
+	var citation ast.Node
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if c, ok := node.(*ast.Citation); ok && entering {
+			citation = c
+		}
+		return ast.GoToNext
+	})
+	if span, _ := init.Sources.Span(citation); span.String() != "draft.md:3:13" {
+		t.Errorf("expected span of citation to be %q, got %q", "draft.md:3:13", span)
+	}
+}
@@ -0,0 +1,83 @@
+package mparser
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strings"
+)
+
+var errUnknownLanguage = errors.New("unknown language")
+
+// addrSymbol returns the lo and hi offsets of the declaration of the function or type name in data, including
+// its doc comment. The language is taken from the extension of file, only Go is supported. A method is named as
+// "Type.Method", just "Method" matches the first function or method with that name.
+func addrSymbol(file string, data []byte, kind, name string) (lo, hi int, err error) {
+	if ext := path.Ext(file); ext != ".go" {
+		return 0, 0, fmt.Errorf("%w: %s=%s needs Go source, not %q", errUnknownLanguage, kind, name, file)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, data, parser.ParseComments)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	offsets := func(doc *ast.CommentGroup, node ast.Node) (int, int, error) {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return fset.Position(start).Offset, fset.Position(node.End()).Offset, nil
+	}
+
+	name = strings.NewReplacer("(", "", ")", "", "*", "").Replace(name)
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if kind == "func" && (d.Name.Name == name || receiver(d)+"."+d.Name.Name == name) {
+				return offsets(d.Doc, d)
+			}
+		case *ast.GenDecl:
+			if kind != "type" || d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				if d.Lparen.IsValid() { // grouped, only return this type.
+					return offsets(ts.Doc, ts)
+				}
+				return offsets(d.Doc, d)
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("no %s %q in %s", kind, name, file)
+}
+
+// receiver returns the name of the receiver's type of the method d, or the empty string for a function.
+func receiver(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return ""
+	}
+	t := d.Recv.List[0].Type
+	for {
+		switch x := t.(type) {
+		case *ast.StarExpr:
+			t = x.X
+		case *ast.IndexExpr: // generic, Type[T]
+			t = x.X
+		case *ast.IndexListExpr:
+			t = x.X
+		case *ast.Ident:
+			return x.Name
+		default:
+			return ""
+		}
+	}
+}
@@ -1,16 +1,20 @@
 package mparser
 
 import (
-	"log"
+	"errors"
 
 	"github.com/BurntSushi/toml"
 	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/diag"
 	"github.com/mmarkdown/mmark/v2/mast"
 )
 
 // TitleHook will parse a title and returns it. The start and ending can
 // be signalled with %%%.
-func TitleHook(data []byte) (ast.Node, []byte, int) {
+func TitleHook(data []byte) (ast.Node, []byte, int) { return Initial{}.TitleHook(data) }
+
+// TitleHook will parse a title and returns it, problems are reported to i.Diagnostics.
+func (in Initial) TitleHook(data []byte) (ast.Node, []byte, int) {
 	i := 0
 	if len(data) < 4 {
 		return nil, nil, 0
@@ -42,6 +46,8 @@ func TitleHook(data []byte) (ast.Node, []byte, int) {
 
 	node := mast.NewTitle()
 	buf := data[beg:i]
+	span, _ := in.span(data)
+	in.Sources.Set(node, span)
 
 	if c == '-' {
 		node.Content = buf
@@ -49,7 +55,19 @@ func TitleHook(data []byte) (ast.Node, []byte, int) {
 	}
 
 	if _, err := toml.Decode(string(buf), node.TitleData); err != nil {
-		log.Printf("Failure parsing title block: %s", err)
+		d := diag.Diagnostic{Severity: diag.Error, Code: "title-block", File: in.file, Message: "Failure parsing title block: " + err.Error()}
+		if !span.IsZero() {
+			d.File, d.Line = span.File, span.Line
+		}
+		// buf starts on the line with the opening %%%, so the TOML line numbers are relative to that line.
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			d.Line, d.Column = perr.Position.Line, perr.Position.Col
+			if !span.IsZero() {
+				d.Line += span.Line - 1
+			}
+		}
+		in.Diagnostics.Add(d)
 	}
 	node.Content = buf
 
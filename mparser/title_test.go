@@ -0,0 +1,32 @@
+package mparser
+
+import (
+	"testing"
+
+	"github.com/mmarkdown/mmark/v2/diag"
+)
+
+func TestTitleHookDiagnostics(t *testing.T) {
+	title := []byte(`%%%
+title = "Test"
+area = "Internet
+%%%
+`)
+	init := NewInitial("draft.md")
+	init.Diagnostics = diag.New()
+
+	init.TitleHook(title)
+	ds := init.Diagnostics.List()
+	if len(ds) != 1 {
+		t.Fatalf("expected %d diagnostic, got %d", 1, len(ds))
+	}
+	if ds[0].Code != "title-block" || ds[0].Severity != diag.Error {
+		t.Errorf("expected title-block error, got %s", ds[0])
+	}
+	if ds[0].Line != 3 {
+		t.Errorf("expected error on line %d, got %d", 3, ds[0].Line)
+	}
+	if ds[0].File != "draft.md" {
+		t.Errorf("expected error in %q, got %q", "draft.md", ds[0].File)
+	}
+}
@@ -0,0 +1,144 @@
+package mparser
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// TocDepth is the default depth of the table of contents, xml2rfc uses the same default.
+const TocDepth = 3
+
+// TableOfContents crawls the sections of doc and returns a mast.TableOfContents that contains a tree of
+// mast.TableOfContentsEntry nodes, which can then be rendered by the renderer:
+//
+// TableOfContentsEntry
+// - TableOfContentsEntry
+// - TableOfContentsEntry
+//   - TableOfContentsEntry
+//
+// Only sections up to depth are included, if depth is zero TocDepth is used. The abstract, notes and the
+// sections in the front matter aren't part of the table of contents, neither are sections with the attribute
+// toc="exclude" and their subsections. Sections with the class "unnumbered" or the attribute numbered="false"
+// (and their subsections) are marked as unnumbered. If no sections are found nil is returned.
+//
+// The headings' IDs are made unique, so the entries can link to them.
+func TableOfContents(doc ast.Node, depth int) *mast.TableOfContents {
+	if depth <= 0 {
+		depth = TocDepth
+	}
+	uniqueHeadingIDs(doc)
+
+	toc := &mast.TableOfContents{}
+	var (
+		parents    = []ast.Node{toc} // the entries we're nested in, parents[i] is at level i
+		matter     = ast.DocumentMatterNone
+		excluded   = 0 // level of the excluded section we are in
+		unnumbered = 0 // level of the unnumbered section we are in
+	)
+	for _, node := range doc.GetChildren() {
+		switch node := node.(type) {
+		case *ast.DocumentMatter:
+			matter = node.Matter
+		case *ast.Heading:
+			if excluded > 0 && node.Level > excluded {
+				continue
+			}
+			excluded = 0
+			if node.IsSpecial || node.IsTitleblock || matter == ast.DocumentMatterFront {
+				continue
+			}
+			if string(mast.Attribute(node, "toc")) == "exclude" {
+				excluded = node.Level
+				continue
+			}
+			if unnumbered > 0 && node.Level <= unnumbered {
+				unnumbered = 0
+			}
+			if unnumbered == 0 && (mast.AttributeClass(node, "unnumbered") || string(mast.Attribute(node, "numbered")) == "false") {
+				unnumbered = node.Level
+			}
+			if node.Level > depth {
+				continue
+			}
+
+			entry := &mast.TableOfContentsEntry{Destination: []byte(node.HeadingID), Level: node.Level, Unnumbered: unnumbered > 0}
+			entry.Literal = []byte(mast.PlainText(node))
+			for len(parents) > node.Level {
+				parents = parents[:len(parents)-1]
+			}
+			ast.AppendChild(parents[len(parents)-1], entry)
+			for len(parents) <= node.Level {
+				parents = append(parents, entry)
+			}
+		}
+	}
+	if len(toc.GetChildren()) == 0 {
+		return nil
+	}
+	return toc
+}
+
+// AddTableOfContents adds a table of contents to doc, it's put before the first section, i.e. after the
+// abstract and notes. If no sections can be found this returns false and no table of contents will be added.
+func AddTableOfContents(doc ast.Node, depth int) bool {
+	toc := TableOfContents(doc, depth)
+	if toc == nil {
+		return false
+	}
+
+	children := doc.GetChildren()
+	i := 0
+	matter := ast.DocumentMatterNone
+	for ; i < len(children); i++ {
+		if m, ok := children[i].(*ast.DocumentMatter); ok {
+			matter = m.Matter
+			continue
+		}
+		if h, ok := children[i].(*ast.Heading); ok && !h.IsSpecial && !h.IsTitleblock && matter != ast.DocumentMatterFront {
+			break
+		}
+	}
+	toc.SetParent(doc)
+	doc.SetChildren(append(children[:i:i], append([]ast.Node{toc}, children[i:]...)...))
+	return true
+}
+
+// uniqueHeadingIDs makes the IDs of the sections in doc unique, the first one keeps its ID, the others get a
+// "-1", "-2", etc. suffix. An ID set with an attribute replaces the generated heading ID and a heading without
+// an ID gets one from its title.
+func uniqueHeadingIDs(doc ast.Node) {
+	taken := map[string]bool{}
+	for _, node := range doc.GetChildren() {
+		h, ok := node.(*ast.Heading)
+		if !ok {
+			continue
+		}
+		id := h.HeadingID
+		if a := mast.AttributeFromNode(h); a != nil && len(a.ID) > 0 {
+			id = string(a.ID)
+			a.ID = nil
+		}
+		if id == "" {
+			id = headingID(mast.PlainText(h))
+		}
+		base := id
+		for n := 1; taken[id]; n++ {
+			id = base + "-" + strconv.Itoa(n)
+		}
+		h.HeadingID = id
+		taken[id] = true
+	}
+}
+
+// headingID returns an ID for a heading with title, i.e. "Section Title" becomes "section-title".
+func headingID(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+	if len(words) == 0 {
+		return "section"
+	}
+	return strings.Join(words, "-")
+}
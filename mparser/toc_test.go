@@ -0,0 +1,97 @@
+package mparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+func TestTableOfContents(t *testing.T) {
+	input := `.# Abstract
+
+Abstract.
+
+{frontmatter}
+
+# Preface
+
+{mainmatter}
+
+{#intro}
+# Introduction
+
+## Background
+
+### Details
+
+{toc="exclude"}
+# Intro
+
+## Hidden
+
+{.unnumbered}
+# Unnumbered
+
+## Sub
+
+{#intro}
+# Introduction
+
+{backmatter}
+
+# Appendix
+`
+	p := parser.NewWithExtensions(Extensions)
+	doc := markdown.Parse([]byte(input), p)
+	if !AddTableOfContents(doc, 2) {
+		t.Fatal("expected a table of contents")
+	}
+
+	var toc *mast.TableOfContents
+	for i, c := range doc.GetChildren() {
+		if c, ok := c.(*mast.TableOfContents); ok {
+			toc = c
+			if _, ok := ast.GetNextNode(c).(*ast.Heading); !ok || i < 4 {
+				t.Errorf("expected the table of contents before the first section, got it at %d", i)
+			}
+		}
+	}
+	if toc == nil {
+		t.Fatal("expected a table of contents in the document")
+	}
+
+	lines := []string{}
+	ast.WalkFunc(toc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if e, ok := node.(*mast.TableOfContentsEntry); ok && entering {
+			line := strings.Repeat("  ", e.Level-1) + string(e.Literal) + " #" + string(e.Destination)
+			if e.Unnumbered {
+				line += " unnumbered"
+			}
+			lines = append(lines, line)
+		}
+		return ast.GoToNext
+	})
+	expect := []string{
+		"Introduction #intro",
+		"  Background #background",
+		"Unnumbered #unnumbered unnumbered",
+		"  Sub #sub unnumbered",
+		"Introduction #intro-2",
+		"Appendix #appendix",
+	}
+	if got := strings.Join(lines, "\n"); got != strings.Join(expect, "\n") {
+		t.Errorf("expected table of contents\n%s\ngot\n%s", strings.Join(expect, "\n"), got)
+	}
+}
+
+func TestTableOfContentsEmpty(t *testing.T) {
+	p := parser.NewWithExtensions(Extensions)
+	doc := markdown.Parse([]byte(".# Abstract\n\nAbstract.\n"), p)
+	if AddTableOfContents(doc, 0) {
+		t.Errorf("expected no table of contents")
+	}
+}
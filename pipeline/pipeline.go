@@ -0,0 +1,394 @@
+// Package pipeline wraps the entire mmark processing pipeline: parsing with the mmark hooks and
+// includes, adding the bibliography and index and rendering to one of the output formats. The
+// mmark command uses this package, so output from the library and the command is identical.
+package pipeline
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+	"github.com/mmarkdown/mmark/v2/mparser"
+	"github.com/mmarkdown/mmark/v2/render/cite"
+	"github.com/mmarkdown/mmark/v2/render/latex"
+	"github.com/mmarkdown/mmark/v2/render/man"
+	mmarkdown "github.com/mmarkdown/mmark/v2/render/markdown"
+	"github.com/mmarkdown/mmark/v2/render/mhtml"
+	"github.com/mmarkdown/mmark/v2/render/number"
+	"github.com/mmarkdown/mmark/v2/render/text"
+	"github.com/mmarkdown/mmark/v2/render/xml"
+	"github.com/mmarkdown/mmark/v2/rfcxml"
+)
+
+// Format is the output format.
+type Format int
+
+// Output formats.
+const (
+	FormatXML      Format = iota // XML2RFC v3 output, see RFC 7991.
+	FormatHTML                   // HTML5 output.
+	FormatMan                    // Manual pages (nroff).
+	FormatLaTeX                  // LaTeX, to be typeset with pdflatex.
+	FormatMarkdown               // Mmark markdown, used to format documents.
+	FormatText                   // Plain text in the style of RFC 7994.
+)
+
+// Flags control optional behavior of the pipeline.
+type Flags int
+
+// Pipeline configuration options.
+const (
+	FlagsNone     Flags = 0
+	Fragment      Flags = 1 << iota // Don't create a full document
+	UnsafeInclude                   // Allow includes from anywhere on the filesystem
+	Bibliography                    // Generate a bibliography section after the back matter
+	Index                           // Generate an index at the end of the document
+	IntraEmphasis                   // Interpret camel_case_value as emphasizing "case" (legacy behavior)
+	AllowUnicode                    // Allow bare unicode in XML output, otherwise wrap in <u>
+	Unpaginated                     // Don't break text output in pages
+	NoInclude                       // Disable includes, they are reported as errors
+
+	CommonFlags Flags = Bibliography | Index | AllowUnicode
+)
+
+// Options control how a document is parsed and rendered.
+type Options struct {
+	Format Format
+	Flags  Flags
+
+	// FileName is the name of the file being converted, it is used to resolve includes. If empty
+	// includes are resolved relative to the current working directory, or the root of FS.
+	FileName string
+
+	// FS, if not nil, is the file system the document's includes, the BibTeX files named in its title block and
+	// (for FormatMan, FormatText and FormatLaTeX) its ascii-art images are read from. FileName is then the name of
+	// the file in FS.
+	FS fs.FS
+
+	// IncludeRoots are the directories includes may be read from, defaults to the directory of FileName.
+	IncludeRoots []string
+
+	// MaxIncludeSize and MaxIncludeDepth limit the size of included files and how deep includes may be nested,
+	// if zero mparser.DefaultMaxIncludeSize and mparser.DefaultMaxIncludeDepth are used.
+	MaxIncludeSize  int64
+	MaxIncludeDepth int
+
+	// Dependencies, if not nil, records the files the document depends on: the document itself, its includes,
+	// BibTeX files and local images.
+	Dependencies *mparser.Dependencies
+
+	// Language is the language used when the title block doesn't specify one, defaults to "en".
+	Language string
+
+	CSS  string // link to a CSS stylesheet (only used with FormatHTML)
+	Head []byte // HTML to be included in head (only used with FormatHTML)
+
+	// Template, if not nil, is used to create complete HTML pages, see mhtml.TemplateData for the data it is
+	// executed with and mhtml.DefaultTemplate for the built-in one. Not used when creating a fragment.
+	Template *template.Template
+
+	// Diagnostics collects all problems found while parsing and rendering, if nil they are logged.
+	Diagnostics *diag.Diagnostics
+
+	// Sources, if not nil, receives the source spans of the nodes in the parsed document.
+	Sources *mast.Sources
+
+	// BibTeX holds BibTeX files used to resolve citations, in addition to the ones named in the title block.
+	BibTeX []string
+
+	// Library is the directory of a local reference library (xml2rfc's bibxml layout), used to resolve
+	// citations of RFCs, I-Ds, etc. If empty, these are included by xml2rfc from bib.ietf.org.
+	Library string
+}
+
+// Convert parses input and renders it according to opts.
+func Convert(input []byte, opts Options) ([]byte, error) {
+	doc := Parse(input, opts)
+	return Render(doc, opts)
+}
+
+// Parse parses input into an AST. When the Bibliography and Index flags are set the bibliography
+// and index are added to the document.
+func Parse(input []byte, opts Options) ast.Node {
+	input = markdown.NormalizeNewlines(input)
+
+	init := initial(opts)
+	init.Track(input)
+	opts.Dependencies.Add(opts.FileName)
+
+	extensions := mparser.Extensions
+	if opts.Flags&IntraEmphasis == 0 {
+		extensions |= parser.NoIntraEmphasis
+	}
+	if opts.Format == FormatMarkdown {
+		extensions &^= parser.Includes // includes must be kept as they are.
+		init.Flags |= mparser.KeepInclude
+	}
+
+	p := parser.NewWithExtensions(extensions)
+	parserFlags := parser.FlagsNone
+	if opts.Format == FormatXML {
+		parserFlags |= parser.SkipFootnoteList // xml doesn't deal with footnotes well.
+	}
+	p.Opts = parser.Options{
+		ParserHook:    init.Hook,
+		ReadIncludeFn: init.ReadInclude,
+		Flags:         parserFlags,
+	}
+
+	doc := markdown.Parse(input, p)
+	init.Spans(doc)
+	init.AddImages(doc)
+	if opts.Flags&Bibliography != 0 {
+		init.AddBibliography(doc)
+	}
+	if opts.Flags&Index != 0 {
+		mparser.AddIndex(doc)
+	}
+	Complete(doc, opts)
+	return doc
+}
+
+// Complete adds the nodes opts.Format needs to doc: the authors' addresses, the table of contents and, for a
+// manual page without one, a title block. Parse calls it, a document read with mast.FromJSON needs it too. Nodes
+// that doc already has aren't added again.
+func Complete(doc ast.Node, opts Options) {
+	if opts.Format != FormatHTML && opts.Format != FormatMan {
+		return
+	}
+	t := Title(doc)
+	if t == nil && opts.Format == FormatMan {
+		// If there isn't a title block the resulting manual page does not start
+		// with .TH, this messes up the entire rendering. Inject an empty one.
+		t = &mast.Title{TitleData: &mast.TitleData{Title: "User Commands 1"}}
+		c := doc.GetChildren()
+		newc := append([]ast.Node{t}, c...)
+		doc.SetChildren(newc) // t must be the first element.
+		t.SetParent(doc)
+		return
+	}
+	if t == nil {
+		return
+	}
+
+	var authors, toc bool
+	for _, c := range doc.GetChildren() {
+		switch c.(type) {
+		case *mast.Authors:
+			authors = true
+		case *mast.TableOfContents:
+			toc = true
+		}
+	}
+	if !authors {
+		ast.AppendChild(doc, &mast.Authors{}) // the authors' addresses end the document.
+	}
+	// Manual pages are usually short, they only get a table of contents when tocDepth is set.
+	if !toc && t.TitleData != nil && opts.Flags&Fragment == 0 && (opts.Format == FormatHTML || t.TocDepth > 0) {
+		mparser.AddTableOfContents(doc, t.TocDepth)
+	}
+}
+
+// Import converts the RFC 7991 XML document in input to mmark markdown. Only opts.FileName and
+// opts.Diagnostics are used.
+func Import(input []byte, opts Options) ([]byte, error) {
+	doc, err := rfcxml.Parse(input, rfcxml.Options{FileName: opts.FileName, Diagnostics: opts.Diagnostics})
+	if err != nil {
+		return nil, err
+	}
+	return markdown.Render(doc, mmarkdown.NewRenderer(mmarkdown.RendererOptions{Flags: mmarkdown.CommonFlags})), nil
+}
+
+// BibTeX returns the references from the BibTeX files used by doc, keyed by lowercased citation key. It
+// returns nil if no BibTeX files are used.
+func BibTeX(doc ast.Node, opts Options) map[string]*reference.Reference {
+	return initial(opts).BibTeXReferences(doc)
+}
+
+// initial returns the parser state for opts.
+func initial(opts Options) mparser.Initial {
+	init := mparser.NewInitial(opts.FileName)
+	if opts.FS != nil {
+		init = mparser.NewInitialFS(opts.FS, opts.FileName)
+	}
+	init.Diagnostics = opts.Diagnostics
+	init.Sources = opts.Sources
+	init.BibTeX = opts.BibTeX
+	init.Library = opts.Library
+	init.Roots = opts.IncludeRoots
+	init.MaxIncludeSize = opts.MaxIncludeSize
+	init.MaxIncludeDepth = opts.MaxIncludeDepth
+	init.Dependencies = opts.Dependencies
+	if opts.Flags&UnsafeInclude != 0 {
+		init.Flags |= mparser.UnsafeInclude
+	}
+	if opts.Flags&NoInclude != 0 {
+		init.Flags |= mparser.NoInclude
+	}
+	return init
+}
+
+// Render renders doc according to opts.
+func Render(doc ast.Node, opts Options) ([]byte, error) {
+	if opts.Format == FormatHTML && opts.Template != nil && opts.Flags&Fragment == 0 {
+		documentTitle, documentLanguage, style := settings(doc, opts)
+		renderer, _ := newHTMLRenderer(doc, opts, documentTitle, documentLanguage, style)
+		return executeTemplate(renderer, doc, []ast.Node{doc}, opts, documentLanguage, nil)
+	}
+	renderer, err := NewRenderer(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+	return markdown.Render(doc, renderer), nil
+}
+
+// NewRenderer returns the renderer for opts.Format. The title block in doc, if any, is used to set the
+// document's language and (for HTML) the document's title.
+func NewRenderer(doc ast.Node, opts Options) (markdown.Renderer, error) {
+	documentTitle, documentLanguage, style := settings(doc, opts)
+
+	switch opts.Format {
+	case FormatHTML:
+		renderer, _ := newHTMLRenderer(doc, opts, documentTitle, documentLanguage, style)
+		return renderer, nil
+
+	case FormatMan:
+		manOpts := man.RendererOptions{
+			Comments:    [][]byte{[]byte("//"), []byte("#")},
+			Language:    lang.New(documentLanguage),
+			Style:       style,
+			Diagnostics: opts.Diagnostics,
+			File:        opts.FileName,
+			Sources:     opts.Sources,
+			ReadFile:    initial(opts).ReadFile,
+		}
+		if opts.Flags&Fragment != 0 {
+			manOpts.Flags |= man.ManFragment
+		}
+		return man.NewRenderer(manOpts), nil
+
+	case FormatLaTeX:
+		latexOpts := latex.RendererOptions{
+			Comments:    [][]byte{[]byte("//"), []byte("#")},
+			Language:    lang.New(documentLanguage),
+			Style:       style,
+			Diagnostics: opts.Diagnostics,
+			File:        opts.FileName,
+			Sources:     opts.Sources,
+			ReadFile:    initial(opts).ReadFile,
+		}
+		if opts.Flags&Fragment != 0 {
+			latexOpts.Flags |= latex.LatexFragment
+		}
+		return latex.NewRenderer(latexOpts), nil
+
+	case FormatText:
+		textOpts := text.RendererOptions{
+			Language:    lang.New(documentLanguage),
+			Style:       style,
+			Diagnostics: opts.Diagnostics,
+			File:        opts.FileName,
+			Sources:     opts.Sources,
+			ReadFile:    initial(opts).ReadFile,
+		}
+		if opts.Flags&Fragment != 0 {
+			textOpts.Flags |= text.TextFragment
+		}
+		if opts.Flags&Unpaginated == 0 {
+			textOpts.Flags |= text.Paginate
+		}
+		return text.NewRenderer(textOpts), nil
+
+	case FormatMarkdown:
+		return mmarkdown.NewRenderer(mmarkdown.RendererOptions{Flags: mmarkdown.CommonFlags}), nil
+
+	case FormatXML:
+		xmlOpts := xml.RendererOptions{
+			Flags:       xml.CommonFlags,
+			Comments:    [][]byte{[]byte("//"), []byte("#")},
+			Language:    lang.New(documentLanguage),
+			Diagnostics: opts.Diagnostics,
+			File:        opts.FileName,
+			Sources:     opts.Sources,
+		}
+		if opts.Flags&Fragment != 0 {
+			xmlOpts.Flags |= xml.XMLFragment
+		}
+		if opts.Flags&AllowUnicode != 0 {
+			xmlOpts.Flags |= xml.AllowUnicode
+		}
+		return xml.NewRenderer(xmlOpts), nil
+	}
+
+	return nil, fmt.Errorf("unknown output format: %d", opts.Format)
+}
+
+// settings returns the document's title, language and citation style from the title block in doc.
+func settings(doc ast.Node, opts Options) (documentTitle, documentLanguage string, style cite.Style) {
+	documentLanguage = opts.Language
+	if documentLanguage == "" {
+		documentLanguage = "en"
+	}
+	style = cite.Default
+	if t := Title(doc); t != nil {
+		documentTitle = t.TitleData.Title
+		if t.TitleData.Language != "" {
+			documentLanguage = t.TitleData.Language
+		}
+		if name := t.TitleData.CitationStyle; name != "" {
+			if s, ok := cite.Lookup(name); ok {
+				style = s
+			} else {
+				opts.Diagnostics.Warningf("citation-style", opts.FileName, "Unknown citation style %q, using %q", name, "ietf")
+			}
+		}
+	}
+	return documentTitle, documentLanguage, style
+}
+
+// newHTMLRenderer returns the HTML renderer and the options of the hook that renders the mmark specific nodes.
+func newHTMLRenderer(doc ast.Node, opts Options, documentTitle, documentLanguage string, style cite.Style) (*html.Renderer, mhtml.RendererOptions) {
+	mhtmlOpts := mhtml.RendererOptions{
+		Language: lang.New(documentLanguage),
+		Style:    style,
+	}
+	if documentTitle != "" {
+		mhtmlOpts.Numbers = number.New(doc, mhtmlOpts.Language)
+	}
+	htmlOpts := html.RendererOptions{
+		Comments:       [][]byte{[]byte("//"), []byte("#")}, // TODO(miek): make this an option.
+		RenderNodeHook: mhtmlOpts.RenderHook,
+		Flags:          html.CommonFlags | html.FootnoteNoHRTag | html.FootnoteReturnLinks,
+		Generator:      `  <meta name="GENERATOR" content="github.com/mmarkdown/mmark Mmark Markdown Processor - mmark.miek.nl`,
+	}
+	if opts.Flags&Fragment == 0 {
+		htmlOpts.Flags |= html.CompletePage
+	}
+	htmlOpts.CSS = opts.CSS
+	htmlOpts.Head = append(mhtml.Meta(doc), opts.Head...)
+	if documentTitle != "" {
+		htmlOpts.Title = documentTitle
+	}
+	return html.NewRenderer(htmlOpts), mhtmlOpts
+}
+
+// Title returns the title block of doc, or nil if there isn't one.
+func Title(doc ast.Node) *mast.Title {
+	var title *mast.Title
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if t, ok := node.(*mast.Title); ok {
+			title = t
+			return ast.Terminate
+		}
+		return ast.GoToNext
+	})
+	return title
+}
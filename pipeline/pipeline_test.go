@@ -0,0 +1,437 @@
+package pipeline
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mparser"
+)
+
+var doc = []byte(`%%%
+title = "Test"
+%%%
+
+# Introduction
+
+This is a test [@RFC2119].
+
+{backmatter}
+`)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		format Format
+		expect string
+	}{
+		{FormatXML, `<xi:include href="https://bib.ietf.org/public/rfc/bibxml/reference.RFC.2119.xml"/>`},
+		{FormatHTML, `<title>Test</title>`},
+		{FormatMan, `.TH "TEST"`},
+		{FormatLaTeX, `\title{Test}`},
+		{FormatMarkdown, "# Introduction\n\nThis is a test [@RFC2119].\n\n{backmatter}\n"},
+		{FormatText, "1.  Introduction\n\n   This is a test [RFC2119]."},
+	}
+	for _, tc := range tests {
+		out, err := Convert(doc, Options{Format: tc.format, Flags: CommonFlags})
+		if err != nil {
+			t.Fatalf("format %d: unexpected error: %s", tc.format, err)
+		}
+		if !bytes.Contains(out, []byte(tc.expect)) {
+			t.Errorf("format %d: expected %q in output, got\n%s", tc.format, tc.expect, out)
+		}
+	}
+}
+
+var titleDoc = []byte(`%%%
+title = "Test"
+date = 2024-01-02T00:00:00Z
+workgroup = "Group"
+keyword = ["one", "two"]
+
+[seriesInfo]
+name = "Internet-Draft"
+value = "draft-test-00"
+status = "informational"
+
+[[author]]
+initials = "J."
+surname = "Doe"
+fullname = "John Doe"
+organization = "Example"
+  [author.address]
+  email = "john@example.org"
+%%%
+
+.# Abstract
+
+This is the abstract.
+
+# Introduction
+
+See (#introduction).
+`)
+
+func TestConvertHTMLTitle(t *testing.T) {
+	out, err := Convert(titleDoc, Options{Format: FormatHTML, Flags: CommonFlags})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, expect := range []string{
+		`<meta name="author" content="John Doe">`,
+		`<meta name="keywords" content="one, two">`,
+		`<meta name="description" content="This is the abstract.">`,
+		`<dd class="internet-draft">draft-test-00</dd>`,
+		`<dd class="status">Informational</dd>`,
+		`<time datetime="2024-07-05">5 July 2024</time>`,
+		`<div class="author-name">J. Doe</div>`,
+		`<h1 id="title">Test</h1>`,
+		`<nav class="toc">`,
+		`<li><a href="#introduction"><span class="section-number">1.</span> Introduction</a>`,
+		`<h1 id="introduction"><span class="section-number">1.</span> Introduction</h1>`,
+		`<a class="xref" href="#introduction">Section 1</a>`,
+		`<h1 id="authors-addresses">Authors' Addresses</h1>`,
+		`<a href="mailto:john@example.org">`,
+	} {
+		if !bytes.Contains(out, []byte(expect)) {
+			t.Errorf("expected %q in output, got\n%s", expect, out)
+		}
+	}
+}
+
+func TestConvertLanguage(t *testing.T) {
+	// titleDoc's title block doesn't set a language, so Options.Language is used.
+	out, err := Convert(titleDoc, Options{Format: FormatHTML, Flags: CommonFlags, Language: "nl"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expect := `<h1 id="authors-addresses">Adressen van de auteurs</h1>`; !bytes.Contains(out, []byte(expect)) {
+		t.Errorf("expected %q in output, got\n%s", expect, out)
+	}
+}
+
+func TestConvertUnknownFormat(t *testing.T) {
+	if _, err := Convert(doc, Options{Format: Format(42)}); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
+
+func TestConvertNoBibliography(t *testing.T) {
+	out, err := Convert(doc, Options{Format: FormatXML})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if bytes.Contains(out, []byte("<references>")) {
+		t.Errorf("expected no bibliography, got\n%s", out)
+	}
+}
+
+func TestConvertFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"draft/intro.md":        {Data: []byte("Included from the FS.\n")},
+		"draft/main.go":         {Data: []byte("package main\n")},
+		"draft/refs.bib":        {Data: []byte("@misc{fs, title = {From the FS}, author = {Doe, John}, year = {2024}}\n")},
+		"draft/image.ascii-art": {Data: []byte("+--+\n|fs|\n+--+\n")},
+	}
+	input := []byte(`%%%
+title = "Test"
+bibliography = ["refs.bib"]
+%%%
+
+# Introduction
+
+{{intro.md}}
+
+<{{main.go}}
+
+See [@fs].
+
+![Image](/draft/image.ascii-art)
+
+{backmatter}
+`)
+	tests := []struct {
+		format Format
+		expect []string
+	}{
+		{FormatHTML, []string{"Included from the FS.", "package main", "From the FS"}},
+		{FormatMan, []string{"Included from the FS.", "|fs|"}},
+	}
+	for _, tc := range tests {
+		opts := Options{Format: tc.format, Flags: CommonFlags, FS: fsys, FileName: "draft/draft.md", Diagnostics: diag.New()}
+		out, err := Convert(input, opts)
+		if err != nil {
+			t.Fatalf("format %d: unexpected error: %s", tc.format, err)
+		}
+		for _, expect := range tc.expect {
+			if !bytes.Contains(out, []byte(expect)) {
+				t.Errorf("format %d: expected %q in output, got\n%s", tc.format, expect, out)
+			}
+		}
+		if opts.Diagnostics.Has(diag.Error) {
+			t.Errorf("format %d: unexpected errors: %v", tc.format, opts.Diagnostics.List())
+		}
+	}
+}
+
+func TestConvertImageSandbox(t *testing.T) {
+	fsys := fstest.MapFS{
+		"draft/image.ascii-art": {Data: []byte("+--+\n|fs|\n+--+\n")},
+		"secret.ascii-art":      {Data: []byte("s3cr3t\n")},
+	}
+	input := []byte("# Introduction\n\n![Image](../secret.ascii-art)\n")
+	tests := []struct {
+		format Format
+		code   string
+	}{
+		{FormatMan, "man-image"},
+		{FormatLaTeX, "latex-image"},
+		{FormatText, "text-image"},
+	}
+	for _, tc := range tests {
+		opts := Options{Format: tc.format, Flags: CommonFlags, FS: fsys, FileName: "draft/draft.md", Diagnostics: diag.New()}
+		out, err := Convert(input, opts)
+		if err != nil {
+			t.Fatalf("format %d: unexpected error: %s", tc.format, err)
+		}
+		if bytes.Contains(out, []byte("s3cr3t")) {
+			t.Errorf("format %d: expected the image outside of the document's directory not to be read, got\n%s", tc.format, out)
+		}
+		code := ""
+		for _, d := range opts.Diagnostics.List() {
+			if d.Code == tc.code {
+				code = d.Code
+			}
+		}
+		if code != tc.code {
+			t.Errorf("format %d: expected diagnostic %q, got %v", tc.format, tc.code, opts.Diagnostics.List())
+		}
+	}
+}
+
+// TestConvertFormatIncludes checks that formatting keeps the includes and their addresses, so the formatted
+// document includes the same text as the original.
+func TestConvertFormatIncludes(t *testing.T) {
+	files, err := filepath.Glob("../testdata/include*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"main.go": {Data: []byte("package main\n\nfunc main() {\n\t// START handler\n\thandle()\n\t// END handler\n}\n")},
+	}
+	tests := map[string][]byte{
+		"region.md": []byte("<{{main.go}}[region=handler;dedent]\nFigure: The handler.\n\n{{includes}}[/^2/,/^3/;prefix=\"C: \"]\n"),
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsys[filepath.Base(f)] = &fstest.MapFile{Data: data}
+		if filepath.Ext(f) == ".md" {
+			tests[filepath.Base(f)] = data
+		}
+	}
+
+	for name, input := range tests {
+		formatted, err := Convert(input, Options{Format: FormatMarkdown, FileName: name})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		opts := Options{Format: FormatXML, Flags: CommonFlags | Fragment, FS: fsys, FileName: name, Diagnostics: diag.New()}
+		want, err := Convert(input, opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		got, err := Convert(formatted, opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		if len(opts.Diagnostics.List()) > 0 {
+			t.Errorf("%s: unexpected diagnostics: %v", name, opts.Diagnostics.List())
+		}
+		// The formatted text is wrapped differently.
+		if strings.Join(strings.Fields(string(got)), " ") != strings.Join(strings.Fields(string(want)), " ") {
+			t.Errorf("%s: formatted document renders differently, expected\n%s\ngot\n%s\nformatted:\n%s", name, want, got, formatted)
+		}
+	}
+}
+
+func TestConvertDependencies(t *testing.T) {
+	fsys := fstest.MapFS{
+		"draft/intro.md": {Data: []byte("Intro.\n\n![Figure](figure.svg)\n")},
+		"draft/main.go":  {Data: []byte("package main\n")},
+		"draft/refs.bib": {Data: []byte("@misc{fs, title = {From the FS}, year = {2024}}\n")},
+	}
+	input := []byte(`%%%
+title = "Test"
+bibliography = ["refs.bib"]
+%%%
+
+# Introduction
+
+{{intro.md}}
+
+<{{main.go}}
+
+{{missing.md}}
+
+See [@fs] and ![remote](https://example.org/image.png).
+
+{backmatter}
+`)
+	opts := Options{Format: FormatXML, Flags: CommonFlags, FS: fsys, FileName: "draft/draft.md", Diagnostics: diag.New()}
+	opts.Dependencies = mparser.NewDependencies()
+	Parse(input, opts)
+
+	expect := []string{"draft/draft.md", "draft/intro.md", "draft/main.go", "draft/figure.svg", "draft/refs.bib"}
+	if got := opts.Dependencies.Files(); strings.Join(got, " ") != strings.Join(expect, " ") {
+		t.Errorf("expected dependencies %q, got %q", expect, got)
+	}
+}
+
+var concurrentDoc = []byte(`%%%
+title = "Test 1"
+date = 2024-01-02T00:00:00Z
+area = "Area"
+workgroup = "Group"
+%%%
+
+# Introduction {#intro}
+
+{#para}
+This is a test [@RFC2119] with a footnote[^1] and an (!index) item, see (#intro).
+
+[^1]: The footnote.
+
+{backmatter}
+`)
+
+// TestConvertConcurrent renders all formats from multiple goroutines, run with -race to detect global state.
+func TestConvertConcurrent(t *testing.T) {
+	formats := []Format{FormatXML, FormatHTML, FormatMan, FormatLaTeX}
+	want := map[Format][]byte{}
+	for _, f := range formats {
+		out, err := Convert(concurrentDoc, Options{Format: f, Flags: CommonFlags})
+		if err != nil {
+			t.Fatalf("format %d: unexpected error: %s", f, err)
+		}
+		want[f] = out
+	}
+	if !bytes.Contains(want[FormatXML], []byte(`anchor="para"`)) {
+		t.Errorf("expected anchor attribute in XML output, got\n%s", want[FormatXML])
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for _, f := range formats {
+			wg.Add(1)
+			go func(f Format) {
+				defer wg.Done()
+				out, err := Convert(concurrentDoc, Options{Format: f, Flags: CommonFlags})
+				if err != nil {
+					t.Errorf("format %d: unexpected error: %s", f, err)
+					return
+				}
+				if !bytes.Equal(out, want[f]) {
+					t.Errorf("format %d: output differs when rendered concurrently", f)
+				}
+				if f == FormatHTML && bytes.Contains(out, []byte("anchor=")) {
+					t.Errorf("XML anchor attribute leaked into HTML output")
+				}
+			}(f)
+		}
+	}
+	wg.Wait()
+}
+
+// normalize collapses the white space in the XML in data. Empty paragraphs are removed, as is white space
+// around <tt> and <contact>, the importer can't keep these. BCP 14 keywords split over two lines are joined
+// when importing, so <strong> and <bcp14> are treated as the same.
+func normalize(data []byte) string {
+	s := strings.NewReplacer("<bcp14>", "<strong>", "</bcp14>", "</strong>").Replace(string(data))
+	s = reEmpty.ReplaceAllString(s, "")
+	s = reSpace.ReplaceAllString(s, "$1")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var (
+	reEmpty = regexp.MustCompile(`<t>\s*</t>`)
+	reSpace = regexp.MustCompile(`\s*(</?tt>|</?contact>)\s*`)
+)
+
+// TestImport converts the documents in rfc/ to XML, imports that XML and checks the imported document
+// converts to the same XML.
+// TestConvertFromJSON checks that a document written as JSON by -ast=json, which parses it for XML, renders
+// to the same HTML as the document itself, with the authors' addresses and table of contents.
+func TestConvertFromJSON(t *testing.T) {
+	files, err := filepath.Glob("../rfc/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		input, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := Options{Format: FormatHTML, Flags: CommonFlags, FileName: f, Diagnostics: diag.New()}
+		want, err := Convert(input, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xmlOpts := opts
+		xmlOpts.Format = FormatXML
+		data, err := mast.ToJSON(Parse(input, xmlOpts))
+		if err != nil {
+			t.Fatalf("%s: %s", f, err)
+		}
+		doc, err := mast.FromJSON(data)
+		if err != nil {
+			t.Fatalf("%s: %s", f, err)
+		}
+		Complete(doc, opts)
+		got, err := Render(doc, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s: document read from JSON renders differently, expected\n%s\ngot\n%s", f, want, got)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	files, err := filepath.Glob("../rfc/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		input, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := Options{Format: FormatXML, Flags: CommonFlags, FileName: f, Diagnostics: diag.New()}
+		want, err := Convert(input, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		imported, err := Import(want, opts)
+		if err != nil {
+			t.Fatalf("%s: %s", f, err)
+		}
+		got, err := Convert(imported, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if normalize(want) != normalize(got) {
+			t.Errorf("%s: imported document converts differently, expected\n%s\ngot\n%s\nimported:\n%s", f, want, got, imported)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"bytes"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/render/mhtml"
+)
+
+// Page is a page of HTML output that is split in pages.
+type Page struct {
+	Name string // file name of the page, i.e. "index.html"
+	Data []byte
+}
+
+// Split renders doc as HTML that is split in pages, the sections of level and lower each start a page, see
+// mhtml.RendererOptions.Split. The first page is "index.html", it has the title block, abstract and the table of
+// contents. Each page has links to the previous, next and up pages, and links to elements on other pages are
+// rewritten. If opts.Template is set the pages are created with it. The document should be parsed with FormatHTML.
+func Split(doc ast.Node, opts Options, level int) ([]Page, error) {
+	opts.Format = FormatHTML
+	opts.Flags &^= Fragment
+	documentTitle, documentLanguage, style := settings(doc, opts)
+	_, mhtmlOpts := newHTMLRenderer(doc, opts, documentTitle, documentLanguage, style)
+
+	pages := mhtmlOpts.Split(doc, level)
+	out := make([][]byte, len(pages))
+	for i, page := range pages {
+		renderer, _ := newHTMLRenderer(doc, opts, documentTitle, documentLanguage, style)
+		if i > 0 {
+			renderer.Opts.Title = page.Title
+			if documentTitle != "" {
+				renderer.Opts.Title += " - " + documentTitle
+			}
+		}
+		if opts.Template != nil {
+			navigation := &bytes.Buffer{}
+			mhtml.Navigation(navigation, pages, i)
+			data, err := executeTemplate(renderer, doc, page.Nodes, opts, documentLanguage, navigation.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			out[i] = data
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		renderer.RenderHeader(buf, doc)
+		mhtml.Navigation(buf, pages, i)
+		for _, node := range page.Nodes {
+			ast.WalkFunc(node, func(node ast.Node, entering bool) ast.WalkStatus {
+				return renderer.RenderNode(buf, node, entering)
+			})
+		}
+		mhtml.Navigation(buf, pages, i)
+		renderer.RenderFooter(buf, doc)
+		out[i] = buf.Bytes()
+	}
+	mhtml.Relink(pages, out)
+
+	split := make([]Page, len(pages))
+	for i := range pages {
+		split[i] = Page{Name: pages[i].Name, Data: out[i]}
+	}
+	return split, nil
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"bytes"
+	"testing"
+)
+
+var splitDoc = []byte(`%%%
+title = "Test"
+%%%
+
+.# Abstract
+
+This is the abstract.
+
+# Introduction
+
+See (#details) and [@RFC2119].
+
+## Details
+
+Details.
+
+# Conclusion {#conclusion}
+
+Back to (#introduction).
+
+{backmatter}
+`)
+
+func TestSplit(t *testing.T) {
+	opts := Options{Format: FormatHTML, Flags: CommonFlags}
+	pages, err := Split(Parse(splitDoc, opts), opts, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{"index.html", "introduction.html", "conclusion.html", "bibliography-section.html"}
+	if len(pages) != len(names) {
+		t.Fatalf("expected %d pages, got %d", len(names), len(pages))
+	}
+	for i, name := range names {
+		if pages[i].Name != name {
+			t.Errorf("expected page %d to be %q, got %q", i, name, pages[i].Name)
+		}
+	}
+
+	tests := []struct {
+		page   int
+		expect string
+	}{
+		{0, `<li><a href="introduction.html#introduction">`},
+		{0, `<a rel="next" href="introduction.html">1. Introduction</a>`},
+		{1, `<title>1. Introduction - Test</title>`},
+		{1, `<a class="xref" href="#details">Section 1.1</a>`},
+		{1, `href="bibliography-section.html#RFC2119"`},
+		{1, `<a rel="up" href="index.html">Test</a>`},
+		{2, `<a class="xref" href="introduction.html#introduction">Section 1</a>`},
+		{2, `<a rel="prev" href="introduction.html">1. Introduction</a>`},
+	}
+	for _, tc := range tests {
+		if !bytes.Contains(pages[tc.page].Data, []byte(tc.expect)) {
+			t.Errorf("expected %q in %s, got\n%s", tc.expect, pages[tc.page].Name, pages[tc.page].Data)
+		}
+	}
+	if bytes.Contains(pages[0].Data, []byte("Details.")) {
+		t.Errorf("expected sections not to be on the first page")
+	}
+}
@@ -0,0 +1,34 @@
+package pipeline
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/mmarkdown/mmark/v2/render/mhtml"
+)
+
+// executeTemplate renders nodes, which belong to doc, with renderer in fragments and executes opts.Template
+// with them. The page's title is taken from the renderer. Navigation holds the links to other pages, if any.
+func executeTemplate(renderer *html.Renderer, doc ast.Node, nodes []ast.Node, opts Options, documentLanguage string, navigation []byte) ([]byte, error) {
+	renderer.Opts.Flags &^= html.CompletePage // the template creates the page.
+
+	data := &mhtml.TemplateData{
+		Title:      renderer.Opts.Title,
+		Language:   documentLanguage,
+		CSS:        opts.CSS,
+		Head:       template.HTML(renderer.Opts.Head),
+		Navigation: template.HTML(navigation),
+	}
+	if t := Title(doc); t != nil {
+		data.Meta = t.TitleData
+	}
+	mhtml.Fragments(renderer, doc, nodes, data)
+
+	buf := &bytes.Buffer{}
+	if err := opts.Template.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/mmarkdown/mmark/v2/render/mhtml"
+)
+
+func TestTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("test").Parse(`<title>{{.Title}}</title>
+<meta>{{.Meta.Title}}</meta>
+<toc>{{.Contents}}</toc>
+<body>{{.Body}}</body>
+<refs>{{.Bibliography}}</refs>`))
+	opts := Options{Format: FormatHTML, Flags: CommonFlags, Template: tmpl}
+	out, err := Convert(splitDoc, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := string(out[strings.Index(string(out), "<body>"):strings.Index(string(out), "<refs>")])
+	tests := []struct {
+		in     string
+		expect string
+	}{
+		{string(out), "<title>Test</title>"},
+		{string(out), "<meta>Test</meta>"},
+		{string(out), `<toc><nav class="toc">`},
+		{string(out), `<refs><h1 id="bibliography-section">`},
+		{body, `<h2 id="details"><span class="section-number">1.1.</span> Details</h2>`},
+	}
+	for _, tc := range tests {
+		if !strings.Contains(tc.in, tc.expect) {
+			t.Errorf("expected %q in\n%s", tc.expect, tc.in)
+		}
+	}
+	for _, unexpected := range []string{"<!DOCTYPE", `class="toc"`, `id="bibliography-section"`} {
+		if strings.Contains(body, unexpected) {
+			t.Errorf("expected %q not in the body, got\n%s", unexpected, body)
+		}
+	}
+}
+
+func TestTemplateDefault(t *testing.T) {
+	opts := Options{Format: FormatHTML, Flags: CommonFlags, Template: mhtml.DefaultTemplate, CSS: "style.css"}
+	out, err := Convert(splitDoc, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{
+		`<html lang="en">`,
+		`<title>Test</title>`,
+		`<link rel="stylesheet" type="text/css" href="style.css">`,
+		`<h1 id="title">Test</h1>`,
+	} {
+		if !bytes.Contains(out, []byte(expect)) {
+			t.Errorf("expected %q in\n%s", expect, out)
+		}
+	}
+
+	pages, err := Split(Parse(splitDoc, opts), opts, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(pages[1].Data, []byte(`<a rel="up" href="index.html">Test</a>`)) {
+		t.Errorf("expected navigation in %s, got\n%s", pages[1].Name, pages[1].Data)
+	}
+}
+
+func TestTemplateDefaultBackMatter(t *testing.T) {
+	doc := append(append([]byte{}, splitDoc...), []byte("\n# Extra {#extra}\n\nAn appendix.\n")...)
+	opts := Options{Format: FormatHTML, Flags: CommonFlags, Template: mhtml.DefaultTemplate}
+	out, err := Convert(doc, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs, appendix := bytes.Index(out, []byte(`id="bibliography-section"`)), bytes.Index(out, []byte(`id="extra"`))
+	if refs < 0 || appendix < 0 || refs > appendix {
+		t.Errorf("expected the references before the appendix, got\n%s", out)
+	}
+	if n := bytes.Count(out, []byte("<section")); n != bytes.Count(out, []byte("</section>")) {
+		t.Errorf("expected balanced sections, got\n%s", out)
+	}
+}
@@ -0,0 +1,161 @@
+// Package cite formats references according to a citation style. The formatted reference is a list of
+// fields, so each renderer can add its own markup (links, italics, classes) and escaping.
+package cite
+
+import (
+	"strings"
+
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+)
+
+// Kind is the kind of a field in a formatted reference.
+type Kind int
+
+// Field kinds.
+const (
+	Text       Kind = iota // Punctuation and other connecting text.
+	Author                 // A single author.
+	EtAl                   // The "et al." when the author list is truncated.
+	Title                  // The title of the document.
+	Series                 // A single series info, i.e. "RFC 2119".
+	Content                // A single refcontent.
+	Date                   // The date.
+	Target                 // The URL of the document, the text is the URL.
+	Annotation             // A single annotation.
+)
+
+// Field is a part of a formatted reference.
+type Field struct {
+	Kind Kind
+	Text string
+}
+
+// Style formats a reference. Terms (like "and") are taken from the language.
+type Style interface {
+	Format(r *reference.Reference, l lang.Lang) []Field
+}
+
+// Default is the style used when none is selected.
+var Default = IETF
+
+// styles are the styles that can be selected from the title block.
+var styles = map[string]Style{
+	"ietf":        IETF,
+	"ieee":        IEEE,
+	"author-year": AuthorYear,
+}
+
+// Lookup returns the style with the name (case insensitive): "ietf", "ieee" or "author-year".
+func Lookup(name string) (Style, bool) {
+	s, ok := styles[strings.ToLower(name)]
+	return s, ok
+}
+
+// fields accumulates the fields of a formatted reference.
+type fields []Field
+
+func (f *fields) add(k Kind, s string) {
+	if s == "" {
+		return
+	}
+	n := len(*f)
+	if k == Text && n > 0 {
+		// Don't double the period after i.e. "Inc.".
+		if prev := (*f)[n-1].Text; strings.HasPrefix(s, ".") && strings.HasSuffix(prev, ".") {
+			if s = s[1:]; s == "" {
+				return
+			}
+		}
+		// Merge consecutive text fields.
+		if (*f)[n-1].Kind == Text {
+			(*f)[n-1].Text += s
+			return
+		}
+	}
+	*f = append(*f, Field{k, s})
+}
+
+func (f *fields) text(s string) { f.add(Text, s) }
+
+// authors adds the author list. Name formats the i-th author, max is the maximum number of authors
+// before the list is truncated to the first author and "et al.", zero means no truncation.
+func (f *fields) authors(as []reference.Author, l lang.Lang, max int, name func(i int, a reference.Author) string) {
+	names := []string{}
+	for i, a := range as {
+		if n := name(i, a); n != "" {
+			if strings.EqualFold(a.Role, "editor") {
+				n += ", Ed."
+			}
+			names = append(names, n)
+		}
+	}
+	if max > 0 && len(names) > max {
+		f.add(Author, names[0])
+		f.text(" ")
+		f.add(EtAl, l.EtAl())
+		return
+	}
+	for i, n := range names {
+		switch {
+		case i == 0:
+		case len(names) == 2:
+			f.text(" " + l.And() + " ")
+		case i == len(names)-1:
+			f.text(", " + l.And() + " ")
+		default:
+			f.text(", ")
+		}
+		f.add(Author, n)
+	}
+}
+
+// series returns the text for the series info s, i.e. "RFC 2119".
+func series(s reference.SeriesInfo) string {
+	return strings.TrimSpace(s.Name + " " + s.Value)
+}
+
+// date returns the month and year of d.
+func date(d *reference.Date) string {
+	if d == nil {
+		return ""
+	}
+	return strings.TrimSpace(d.Month + " " + d.Year)
+}
+
+// year returns the year of d.
+func year(d *reference.Date) string {
+	if d == nil {
+		return ""
+	}
+	return d.Year
+}
+
+// surname returns the surname of a, falling back to the full name or the organization.
+func surname(a reference.Author) string {
+	switch {
+	case a.Surname != "":
+		return a.Surname
+	case a.Fullname != "":
+		return a.Fullname
+	case a.Organization != nil:
+		return a.Organization.Value
+	}
+	return ""
+}
+
+// initialsSurname returns "I. Surname".
+func initialsSurname(a reference.Author) string {
+	if a.Surname == "" || a.Initials == "" {
+		return surname(a)
+	}
+	return a.Initials + " " + a.Surname
+}
+
+// surnameInitials returns "Surname, I.".
+func surnameInitials(a reference.Author) string {
+	if a.Surname == "" || a.Initials == "" {
+		return surname(a)
+	}
+	return a.Surname + ", " + a.Initials
+}
@@ -0,0 +1,76 @@
+package cite
+
+import (
+	"testing"
+
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+)
+
+var rfc2119 = &reference.Reference{
+	Anchor: "RFC2119",
+	Target: "https://www.rfc-editor.org/info/rfc2119",
+	Front: reference.Front{
+		Title:   "Key words for use in RFCs to Indicate Requirement Levels",
+		Authors: []reference.Author{{Initials: "S.", Surname: "Bradner", Fullname: "S. Bradner"}},
+		Date:    &reference.Date{Year: "1997", Month: "March"},
+	},
+	Series: []reference.SeriesInfo{{Name: "BCP", Value: "14"}, {Name: "RFC", Value: "2119"}},
+}
+
+func text(fs []Field) string {
+	s := ""
+	for _, f := range fs {
+		s += f.Text
+	}
+	return s
+}
+
+func TestStyles(t *testing.T) {
+	tests := []struct {
+		style Style
+		want  string
+	}{
+		{IETF, `Bradner, S., "Key words for use in RFCs to Indicate Requirement Levels", BCP 14, RFC 2119, March 1997, <https://www.rfc-editor.org/info/rfc2119>.`},
+		{IEEE, `S. Bradner, "Key words for use in RFCs to Indicate Requirement Levels," BCP 14, RFC 2119, March 1997. Available: https://www.rfc-editor.org/info/rfc2119`},
+		{AuthorYear, `Bradner, S. (1997). Key words for use in RFCs to Indicate Requirement Levels. BCP 14, RFC 2119. https://www.rfc-editor.org/info/rfc2119`},
+	}
+	for _, tc := range tests {
+		if got := text(tc.style.Format(rfc2119, lang.New("en"))); got != tc.want {
+			t.Errorf("want\n%s\ngot\n%s", tc.want, got)
+		}
+	}
+}
+
+func TestAuthors(t *testing.T) {
+	r := &reference.Reference{Front: reference.Front{Title: "x"}}
+	for _, s := range []string{"A", "B", "C", "D", "E", "F", "G"} {
+		r.Front.Authors = append(r.Front.Authors, reference.Author{Initials: s + ".", Surname: s + s})
+	}
+
+	if got, want := text(IETF.Format(r, lang.New("nl"))), `AA, A., B. BB, C. CC, D. DD, E. EE, F. FF, en G. GG, "x".`; got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+	fs := IEEE.Format(r, lang.New("en"))
+	if got, want := text(fs), `A. AA et al., "x."`; got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+	if fs[2].Kind != EtAl {
+		t.Errorf("want field %d to be %d, got %d", 2, EtAl, fs[2].Kind)
+	}
+
+	r.Front.Authors = r.Front.Authors[:2]
+	r.Front.Authors[1].Role = "editor"
+	if got, want := text(AuthorYear.Format(r, lang.New("de"))), `AA, A. und BB, B., Ed. x.`; got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if s, ok := Lookup("IEEE"); !ok || s != IEEE {
+		t.Errorf("want style %q to be found", "IEEE")
+	}
+	if _, ok := Lookup("chicago"); ok {
+		t.Errorf("want style %q not to be found", "chicago")
+	}
+}
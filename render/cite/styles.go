@@ -0,0 +1,174 @@
+package cite
+
+import (
+	"strings"
+
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+)
+
+// IETF formats references as described in RFC 7322, Section 4.8.6:
+//
+//	Bradner, S., "Key words for use in RFCs to Indicate Requirement Levels", BCP 14, RFC 2119, March 1997, <https://www.rfc-editor.org/info/rfc2119>.
+var IETF Style = ietf{}
+
+// IEEE formats references in the IEEE style, more than 6 authors are truncated:
+//
+//	S. Bradner, "Key words for use in RFCs to Indicate Requirement Levels," BCP 14, RFC 2119, March 1997. Available: https://www.rfc-editor.org/info/rfc2119
+var IEEE Style = ieee{}
+
+// AuthorYear formats references in an author-year style, more than 5 authors are truncated:
+//
+//	Bradner, S. (1997). Key words for use in RFCs to Indicate Requirement Levels. BCP 14, RFC 2119. https://www.rfc-editor.org/info/rfc2119
+var AuthorYear Style = authorYear{}
+
+type ietf struct{}
+
+func (ietf) Format(r *reference.Reference, l lang.Lang) []Field {
+	f := fields{}
+	f.authors(r.Front.Authors, l, 0, func(i int, a reference.Author) string {
+		if i == 0 {
+			return surnameInitials(a)
+		}
+		return initialsSurname(a)
+	})
+	sep := func() {
+		if len(f) > 0 {
+			f.text(", ")
+		}
+	}
+	if r.Front.Title != "" {
+		sep()
+		f.text(`"`)
+		f.add(Title, r.Front.Title)
+		f.text(`"`)
+	}
+	for _, s := range r.Series {
+		if x := series(s); x != "" {
+			sep()
+			f.add(Series, x)
+		}
+	}
+	for _, c := range r.RefContent {
+		sep()
+		f.add(Content, c)
+	}
+	if d := date(r.Front.Date); d != "" {
+		sep()
+		f.add(Date, d)
+	}
+	if r.Target != "" {
+		sep()
+		f.text("<")
+		f.add(Target, r.Target)
+		f.text(">")
+	}
+	f.text(".")
+	annotations(&f, r)
+	return f
+}
+
+type ieee struct{}
+
+func (ieee) Format(r *reference.Reference, l lang.Lang) []Field {
+	f := fields{}
+	f.authors(r.Front.Authors, l, 6, func(_ int, a reference.Author) string { return initialsSurname(a) })
+	rest := []func(){}
+	for _, s := range r.Series {
+		if x := series(s); x != "" {
+			rest = append(rest, func() { f.add(Series, x) })
+		}
+	}
+	for _, c := range r.RefContent {
+		c := c
+		rest = append(rest, func() { f.add(Content, c) })
+	}
+	if d := date(r.Front.Date); d != "" {
+		rest = append(rest, func() { f.add(Date, d) })
+	}
+
+	if len(f) > 0 {
+		f.text(", ")
+	}
+	if r.Front.Title != "" {
+		// The comma (or period) goes inside the quotes.
+		f.text(`"`)
+		f.add(Title, r.Front.Title)
+		if len(rest) > 0 {
+			f.text(`," `)
+		} else {
+			f.text(`."`)
+		}
+	}
+	for i, add := range rest {
+		add()
+		if i < len(rest)-1 {
+			f.text(", ")
+		} else {
+			f.text(".")
+		}
+	}
+	if r.Target != "" {
+		f.text(" Available: ")
+		f.add(Target, r.Target)
+	}
+	annotations(&f, r)
+	return f
+}
+
+type authorYear struct{}
+
+func (authorYear) Format(r *reference.Reference, l lang.Lang) []Field {
+	f := fields{}
+	f.authors(r.Front.Authors, l, 5, func(_ int, a reference.Author) string { return surnameInitials(a) })
+	if y := year(r.Front.Date); y != "" {
+		if len(f) > 0 {
+			f.text(" ")
+		}
+		f.text("(")
+		f.add(Date, y)
+		f.text(")")
+	}
+	if len(f) > 0 {
+		f.text(". ")
+	}
+	if r.Front.Title != "" {
+		f.add(Title, r.Front.Title)
+		f.text(". ")
+	}
+	for _, c := range r.RefContent {
+		f.add(Content, c)
+		f.text(". ")
+	}
+	ss := []string{}
+	for _, s := range r.Series {
+		if x := series(s); x != "" {
+			ss = append(ss, x)
+		}
+	}
+	for i, s := range ss {
+		f.add(Series, s)
+		if i < len(ss)-1 {
+			f.text(", ")
+		} else {
+			f.text(". ")
+		}
+	}
+	if r.Target != "" {
+		f.add(Target, r.Target)
+	}
+	// Remove the trailing space.
+	if n := len(f); n > 0 && f[n-1].Kind == Text {
+		f[n-1].Text = strings.TrimRight(f[n-1].Text, " ")
+	}
+	annotations(&f, r)
+	return f
+}
+
+// annotations adds the annotations of r, each as a separate sentence.
+func annotations(f *fields, r *reference.Reference) {
+	for _, a := range r.Annotation {
+		f.text(" ")
+		f.add(Annotation, a)
+	}
+}
@@ -0,0 +1,81 @@
+package latex
+
+import (
+	"io"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+	"github.com/mmarkdown/mmark/v2/render/cite"
+)
+
+// bibliography outputs a thebibliography environment, the normative and informative references each get
+// their own.
+func (r *Renderer) bibliography(w io.Writer, node *mast.Bibliography, entering bool) {
+	if !entering {
+		r.outs(w, "\\end{thebibliography}\n")
+		return
+	}
+	r.closeAbstract(w)
+
+	name := r.opts.Language.Bibliography()
+	switch node.Type {
+	case ast.CitationTypeInformative:
+		name = "Informative References"
+	case ast.CitationTypeNormative:
+		name = "Normative References"
+	}
+	// The widest label is used to indent the items.
+	widest := ""
+	for _, c := range node.GetChildren() {
+		if item, ok := c.(*mast.BibliographyItem); ok && len(item.Anchor) > len(widest) {
+			widest = string(item.Anchor)
+		}
+	}
+	r.outs(w, "\n\\renewcommand{\\refname}{"+escape(name)+"}\n")
+	r.outs(w, "\\begin{thebibliography}{"+escape(widest)+"}\n")
+}
+
+func (r *Renderer) bibliographyItem(w io.Writer, bib *mast.BibliographyItem, entering bool) {
+	if !entering {
+		return
+	}
+	r.outs(w, "\\bibitem["+escape(string(bib.Anchor))+"]{"+key(bib.Anchor)+"}\n")
+	switch {
+	case bib.Reference != nil:
+		r.reference(w, bib.Reference)
+	case bib.ReferenceGroup != nil:
+		group := bib.ReferenceGroup
+		for i := range group.References {
+			if i > 0 {
+				r.outs(w, "\\newline\n")
+			}
+			r.reference(w, &group.References[i])
+		}
+		if group.Target != "" {
+			r.outs(w, "\\newline\n\\url{"+escapeURL(group.Target)+"}\n")
+		}
+	default:
+		// Not resolved, i.e. an RFC that is included by xml2rfc.
+		r.outs(w, escape(string(bib.Anchor))+"\n")
+	}
+}
+
+// reference writes ref formatted according to the citation style.
+func (r *Renderer) reference(w io.Writer, ref *reference.Reference) {
+	style := r.opts.Style
+	if style == nil {
+		style = cite.Default
+	}
+	for _, f := range style.Format(ref, r.opts.Language) {
+		switch f.Kind {
+		case cite.Title:
+			r.outs(w, "\\emph{"+escape(f.Text)+"}")
+		case cite.Target:
+			r.outs(w, "\\url{"+escapeURL(f.Text)+"}")
+		default:
+			r.outs(w, escape(f.Text))
+		}
+	}
+	r.outs(w, "\n")
+}
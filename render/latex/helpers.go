@@ -0,0 +1,128 @@
+package latex
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+func (r *Renderer) out(w io.Writer, d []byte)  { w.Write(d) }
+func (r *Renderer) outs(w io.Writer, s string) { io.WriteString(w, s) }
+
+func (r *Renderer) outOneOf(w io.Writer, outFirst bool, first string, second string) {
+	if outFirst {
+		r.outs(w, first)
+	} else {
+		r.outs(w, second)
+	}
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`^`, `\textasciicircum{}`,
+	`_`, `\_`,
+	`%`, `\%`,
+	`~`, `\textasciitilde{}`,
+)
+
+// escape escapes the characters that are special in LaTeX.
+func escape(s string) string { return escaper.Replace(s) }
+
+// urlEscaper escapes the characters that can't be used in the argument of \href and \url.
+var urlEscaper = strings.NewReplacer(`\`, `\\`, `#`, `\#`, `%`, `\%`, `{`, `\{`, `}`, `\}`)
+
+// escapeURL escapes the URL u for use in \href and \url.
+func escapeURL(u string) string { return urlEscaper.Replace(u) }
+
+// key returns s as usable as a \label or \cite key.
+func key(s []byte) string {
+	return strings.NewReplacer("#", "-", ",", "-", `\`, "-", "{", "-", "}", "-", "%", "-", " ", "-").Replace(string(s))
+}
+
+// indexEscaper quotes the characters that are special to makeindex, after which the text is escaped for LaTeX.
+var indexEscaper = strings.NewReplacer(`"`, `""`, `!`, `"!`, `@`, `"@`, `|`, `"|`)
+
+// escapeIndex escapes s for use in \index.
+func escapeIndex(s []byte) string { return escape(indexEscaper.Replace(string(s))) }
+
+// escapeCode writes the code block text to w, callouts following a comment are typeset in bold. The
+// listing must use (@ and @) as its escape sequence.
+func (r *Renderer) escapeCode(w io.Writer, text []byte) {
+	lt := len(text)
+	start := 0
+Parse:
+	for i := 0; i < lt; i++ {
+		for _, comment := range r.opts.Comments {
+			if !bytes.HasPrefix(text[i:], comment) {
+				continue
+			}
+			lc := len(comment)
+			if i+lc < lt {
+				if id, consumed := parser.IsCallout(text[i+lc:]); consumed > 0 {
+					r.out(w, text[start:i])
+					r.outs(w, `(@\textbf{(`+escape(string(id))+`)}@)`)
+					i += consumed + lc - 1
+					start = i + 1
+					continue Parse
+				}
+			}
+		}
+	}
+	r.out(w, text[start:])
+}
+
+// hasCallouts returns true if text contains a callout after one of the comments.
+func (r *Renderer) hasCallouts(text []byte) bool {
+	for _, comment := range r.opts.Comments {
+		for i := bytes.Index(text, comment); i >= 0; {
+			if _, consumed := parser.IsCallout(text[i+len(comment):]); consumed > 0 {
+				return true
+			}
+			j := bytes.Index(text[i+len(comment):], comment)
+			if j < 0 {
+				break
+			}
+			i += len(comment) + j
+		}
+	}
+	return false
+}
+
+// return the table cells.
+func rows(node *ast.Table) [][]*ast.TableCell {
+	cells := [][]*ast.TableCell{}
+	ast.WalkFunc(node, func(node ast.Node, entering bool) ast.WalkStatus {
+		row, ok := node.(*ast.TableRow)
+		if !ok || !entering {
+			return ast.GoToNext
+		}
+		cs := []*ast.TableCell{}
+		for _, c := range row.GetChildren() {
+			if cell, ok := c.(*ast.TableCell); ok {
+				cs = append(cs, cell)
+			}
+		}
+		cells = append(cells, cs)
+		return ast.GoToNext
+	})
+	return cells
+}
+
+// images returns the images in paragraph p.
+func images(p ast.Node) []*ast.Image {
+	imgs := []*ast.Image{}
+	for _, c := range p.GetChildren() {
+		if img, ok := c.(*ast.Image); ok {
+			imgs = append(imgs, img)
+		}
+	}
+	return imgs
+}
@@ -0,0 +1,669 @@
+// The package latex outputs LaTeX from mmark markdown, the output can be typeset with pdflatex.
+package latex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mparser"
+	"github.com/mmarkdown/mmark/v2/render/cite"
+	"github.com/mmarkdown/mmark/v2/render/xml"
+)
+
+// Flags control optional behavior of LaTeX renderer.
+type Flags int
+
+// LaTeX renderer configuration options.
+const (
+	FlagsNone     Flags = 0
+	LatexFragment Flags = 1 << iota // Don't generate a complete document
+
+	CommonFlags Flags = FlagsNone
+)
+
+// RendererOptions is a collection of supplementary parameters tweaking
+// the behavior of various parts of LaTeX renderer.
+type RendererOptions struct {
+	Flags Flags // Flags allow customizing this renderer's behavior
+
+	Language lang.Lang // Output language for the document.
+
+	// if set, called at the start of RenderNode(). Allows replacing rendering of some nodes
+	RenderNodeHook html.RenderNodeFunc
+
+	// Comments is a list of comments the renderer should detect when
+	// parsing code blocks and detecting callouts.
+	Comments [][]byte
+
+	// Style is the citation style used to format the bibliography, defaults to cite.Default.
+	Style cite.Style
+
+	// Diagnostics collects all problems found while rendering, if nil they are logged.
+	Diagnostics *diag.Diagnostics
+
+	// ReadFile reads the .ascii-art images that end up in a listing. When nil, see mparser.ReadFileFunc.
+	ReadFile func(name string) ([]byte, error)
+
+	// File is the name of the document, used in diagnostics for nodes without a known source span.
+	File string
+
+	// Sources holds the source spans of the nodes, it's used to locate diagnostics. May be nil.
+	Sources *mast.Sources
+}
+
+// Renderer implements Renderer interface for LaTeX output.
+type Renderer struct {
+	opts RendererOptions
+
+	Title     *mast.Title
+	abstract  bool // true when an abstract environment is open
+	enumLevel int  // nesting level of enumerate environments
+}
+
+// NewRenderer creates and configures an Renderer object, which satisfies the Renderer interface.
+func NewRenderer(opts RendererOptions) *Renderer {
+	return &Renderer{opts: opts}
+}
+
+// sections are the sectioning commands for each heading level.
+var sections = []string{"section", "subsection", "subsubsection", "paragraph", "subparagraph"}
+
+// languages maps code block info strings to the listings package language names.
+var languages = map[string]string{
+	"bash":   "bash",
+	"c":      "C",
+	"c++":    "C++",
+	"cpp":    "C++",
+	"html":   "HTML",
+	"java":   "Java",
+	"perl":   "Perl",
+	"python": "Python",
+	"ruby":   "Ruby",
+	"sh":     "sh",
+	"sql":    "SQL",
+	"tex":    "TeX",
+	"xml":    "XML",
+}
+
+func (r *Renderer) closeAbstract(w io.Writer) {
+	if r.abstract {
+		r.outs(w, "\\end{abstract}\n")
+		r.abstract = false
+	}
+}
+
+func (r *Renderer) matter(w io.Writer, node *ast.DocumentMatter, entering bool) {
+	if !entering {
+		return
+	}
+	r.closeAbstract(w)
+	if node.Matter == ast.DocumentMatterBack {
+		r.outs(w, "\n\\appendix\n")
+	}
+}
+
+func (r *Renderer) heading(w io.Writer, node *ast.Heading, entering bool) ast.WalkStatus {
+	abstract := node.IsSpecial && xml.IsAbstract(node.Literal)
+	if !entering {
+		switch {
+		case abstract:
+		case node.IsSpecial:
+			r.outs(w, "}\n")
+		default:
+			r.outs(w, "}")
+			if node.HeadingID != "" {
+				r.outs(w, "\\label{"+key([]byte(node.HeadingID))+"}")
+			}
+			r.outs(w, "\n")
+		}
+		return ast.GoToNext
+	}
+
+	r.closeAbstract(w)
+	if abstract {
+		r.outs(w, "\n\\begin{abstract}\n")
+		r.abstract = true
+		return ast.SkipChildren
+	}
+	level := node.Level
+	if level > len(sections) {
+		level = len(sections)
+	}
+	if level < 1 {
+		level = 1
+	}
+	r.outs(w, "\n\\"+sections[level-1])
+	if node.IsSpecial {
+		r.outs(w, "*")
+	}
+	r.outs(w, "{")
+	return ast.GoToNext
+}
+
+func (r *Renderer) citation(w io.Writer, node *ast.Citation, entering bool) {
+	if !entering {
+		return
+	}
+	for i, c := range node.Destination {
+		if i > 0 && node.Type[i] != ast.CitationTypeSuppressed {
+			r.outs(w, ", ")
+		}
+		if node.Type[i] == ast.CitationTypeSuppressed {
+			r.outs(w, "\\nocite{"+key(c)+"}")
+			continue
+		}
+
+		// As in the XML renderer, a citation of an author or contact renders that author.
+		if author := xml.AuthorFromTitle(c, r.Title); author != nil {
+			r.outs(w, escape(author.Fullname))
+			continue
+		}
+		if contact := xml.ContactFromTitle(c, r.Title); contact != nil {
+			r.outs(w, escape(contact.Fullname))
+			continue
+		}
+
+		keys := key(c)
+		// RFC2119@BCP14 cites both documents.
+		if n := bytes.Index(c, []byte("@")); n > 0 && len(c[n+1:]) > 2 {
+			keys = key(c[:n]) + "," + key(c[n+1:])
+		}
+		r.outs(w, "\\cite")
+		if len(node.Suffix) > i {
+			if suf := bytes.TrimSpace(node.Suffix[i]); len(suf) > 0 {
+				r.outs(w, "["+escape(string(suf))+"]")
+			}
+		}
+		r.outs(w, "{"+keys+"}")
+	}
+}
+
+func (r *Renderer) paragraph(w io.Writer, para *ast.Paragraph, entering bool) {
+	// A term in a definition list is the optional argument of \item, it can't contain paragraph breaks.
+	if item, ok := para.Parent.(*ast.ListItem); ok && item.ListFlags&ast.ListTypeTerm != 0 {
+		return
+	}
+	if entering {
+		if ast.GetPrevNode(para) != nil {
+			r.outs(w, "\n")
+		}
+		return
+	}
+	r.outs(w, "\n")
+}
+
+func (r *Renderer) list(w io.Writer, list *ast.List, entering bool) ast.WalkStatus {
+	if list.IsFootnotesList {
+		// footnotes are typeset where they are referenced.
+		return ast.SkipChildren
+	}
+
+	env := "itemize"
+	switch {
+	case list.ListFlags&ast.ListTypeDefinition != 0:
+		env = "description"
+	case list.ListFlags&ast.ListTypeOrdered != 0:
+		env = "enumerate"
+	}
+	if !entering {
+		if env == "enumerate" {
+			r.enumLevel--
+		}
+		r.outs(w, "\\end{"+env+"}\n")
+		return ast.GoToNext
+	}
+
+	r.outs(w, "\\begin{"+env+"}\n")
+	if env == "enumerate" {
+		r.enumLevel++
+		if list.Start > 1 && r.enumLevel <= 4 {
+			counter := []string{"i", "ii", "iii", "iv"}[r.enumLevel-1]
+			r.outs(w, fmt.Sprintf("\\setcounter{enum%s}{%d}\n", counter, list.Start-1))
+		}
+	}
+	return ast.GoToNext
+}
+
+func (r *Renderer) listItem(w io.Writer, listItem *ast.ListItem, entering bool) {
+	x := listItem.ListFlags
+	switch {
+	case x&ast.ListTypeTerm != 0:
+		r.outOneOf(w, entering, "\\item[{", "}]\n")
+	case x&ast.ListTypeDefinition != 0:
+	default:
+		if entering {
+			r.outs(w, "\\item ")
+		}
+	}
+}
+
+func (r *Renderer) codeBlock(w io.Writer, codeBlock *ast.CodeBlock, entering bool) {
+	if !entering {
+		return
+	}
+	opts := []string{}
+	if l, ok := languages[strings.ToLower(string(codeBlock.Info))]; ok {
+		opts = append(opts, "language="+l)
+	}
+	if r.hasCallouts(codeBlock.Literal) {
+		opts = append(opts, "escapeinside={(@}{@)}")
+	}
+	r.outs(w, "\n\\begin{lstlisting}")
+	if len(opts) > 0 {
+		r.outs(w, "["+strings.Join(opts, ",")+"]")
+	}
+	r.outs(w, "\n")
+	r.escapeCode(w, codeBlock.Literal)
+	if !bytes.HasSuffix(codeBlock.Literal, []byte("\n")) {
+		r.outs(w, "\n")
+	}
+	r.outs(w, "\\end{lstlisting}\n")
+}
+
+func (r *Renderer) table(w io.Writer, tab *ast.Table, entering bool) {
+	_, figure := tab.Parent.(*ast.CaptionFigure)
+	if !entering {
+		r.outs(w, "\\end{tabular}\n")
+		if !figure {
+			r.outs(w, "\\end{center}\n")
+		}
+		return
+	}
+
+	cells := rows(tab)
+	spec := "|"
+	if len(cells) > 0 {
+		for _, cell := range cells[0] {
+			span := cell.ColSpan
+			if span < 1 {
+				span = 1
+			}
+			spec += strings.Repeat(align(cell.Align)+"|", span)
+		}
+	}
+	if !figure {
+		r.outs(w, "\n\\begin{center}\n")
+	}
+	r.outs(w, "\\begin{tabular}{"+spec+"}\n\\hline\n")
+}
+
+func align(a ast.CellAlignFlags) string {
+	switch a {
+	case ast.TableAlignmentRight:
+		return "r"
+	case ast.TableAlignmentCenter:
+		return "c"
+	}
+	return "l"
+}
+
+func (r *Renderer) tableRow(w io.Writer, tableRow *ast.TableRow, entering bool) {
+	if !entering {
+		r.outs(w, " \\\\ \\hline\n")
+	}
+}
+
+func (r *Renderer) tableCell(w io.Writer, tableCell *ast.TableCell, entering bool) {
+	if !entering {
+		if tableCell.IsHeader {
+			r.outs(w, "}")
+		}
+		if tableCell.ColSpan > 1 {
+			r.outs(w, "}")
+		}
+		return
+	}
+	if tableCell != ast.GetFirstChild(tableCell.Parent) {
+		r.outs(w, " & ")
+	}
+	if tableCell.ColSpan > 1 {
+		r.outs(w, fmt.Sprintf("\\multicolumn{%d}{%s|}{", tableCell.ColSpan, align(tableCell.Align)))
+	}
+	if tableCell.IsHeader {
+		r.outs(w, "\\textbf{")
+	}
+}
+
+func (r *Renderer) crossReference(w io.Writer, cr *ast.CrossReference, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.GoToNext
+	}
+	if string(cr.Suffix) == r.opts.Language.UseTitle() {
+		r.outs(w, "\\nameref{"+key(cr.Destination)+"}")
+	} else {
+		r.outs(w, "\\autoref{"+key(cr.Destination)+"}")
+	}
+	return ast.SkipChildren
+}
+
+func (r *Renderer) index(w io.Writer, index *ast.Index, entering bool) {
+	if !entering {
+		return
+	}
+	r.outs(w, "\\index{"+escapeIndex(index.Item))
+	if len(index.Subitem) > 0 {
+		r.outs(w, "!"+escapeIndex(index.Subitem))
+	}
+	if index.Primary {
+		r.outs(w, "|textbf")
+	}
+	r.outs(w, "}")
+}
+
+func (r *Renderer) link(w io.Writer, link *ast.Link, entering bool) ast.WalkStatus {
+	if link.Footnote != nil {
+		if !entering {
+			return ast.GoToNext
+		}
+		r.outs(w, "\\footnote{")
+		for _, child := range link.Footnote.GetChildren() {
+			ast.WalkFunc(child, func(node ast.Node, entering bool) ast.WalkStatus {
+				return r.RenderNode(w, node, entering)
+			})
+		}
+		r.outs(w, "}")
+		return ast.SkipChildren
+	}
+
+	dest := string(link.Destination)
+	if strings.HasPrefix(dest, "#") {
+		r.outOneOf(w, entering, "\\hyperref["+key([]byte(dest[1:]))+"]{", "}")
+		return ast.GoToNext
+	}
+	r.outOneOf(w, entering, "\\href{"+escapeURL(dest)+"}{", "}")
+	return ast.GoToNext
+}
+
+// figureImage returns the image that is typeset for a figure paragraph with multiple images. These are
+// alternatives of the same figure, i.e. an SVG and an ascii-art version. A bitmap or PDF is preferred, then
+// the ascii-art and lastly any other image.
+func figureImage(imgs []*ast.Image) *ast.Image {
+	if len(imgs) == 0 {
+		return nil
+	}
+	for _, img := range imgs {
+		switch strings.ToLower(path.Ext(string(img.Destination))) {
+		case ".pdf", ".png", ".jpg", ".jpeg", ".eps":
+			return img
+		}
+	}
+	for _, img := range imgs {
+		if bytes.HasSuffix(img.Destination, []byte(".ascii-art")) {
+			return img
+		}
+	}
+	return imgs[0]
+}
+
+func (r *Renderer) image(w io.Writer, node *ast.Image, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.GoToNext
+	}
+	if p := node.Parent; p != nil {
+		if _, ok := p.GetParent().(*ast.CaptionFigure); ok && figureImage(images(p)) != node {
+			return ast.SkipChildren
+		}
+	}
+
+	dest := string(node.Destination)
+	switch {
+	case strings.HasSuffix(dest, ".ascii-art"):
+		img, err := mparser.ReadFileFunc(r.opts.ReadFile)(dest)
+		if err != nil {
+			r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(node, diag.Warning, "latex-image", r.opts.File, "Failure to read image: %s", err))
+			return ast.SkipChildren
+		}
+		r.outs(w, "\n\\begin{lstlisting}\n")
+		r.out(w, img)
+		if !bytes.HasSuffix(img, []byte("\n")) {
+			r.outs(w, "\n")
+		}
+		r.outs(w, "\\end{lstlisting}\n")
+
+	case strings.EqualFold(path.Ext(dest), ".svg"):
+		r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(node, diag.Warning, "latex-image", r.opts.File, "SVG image %q can't be included by pdflatex, convert it to PDF", dest))
+		r.outs(w, "% "+dest+"\n")
+
+	default:
+		r.outs(w, "\\includegraphics[width=\\linewidth]{"+escapeURL(dest)+"}")
+	}
+	return ast.SkipChildren
+}
+
+func (r *Renderer) mathBlock(w io.Writer, mathBlock *ast.MathBlock, entering bool) ast.WalkStatus {
+	if entering {
+		r.outs(w, "\n\\[")
+		r.out(w, bytes.TrimSpace(mathBlock.Literal))
+		r.outs(w, "\\]\n")
+	}
+	return ast.SkipChildren
+}
+
+// figureEnvironment returns the environment used for the figure, an empty string for a quote with an attribution.
+func figureEnvironment(figure *ast.CaptionFigure) string {
+	if _, ok := figure.Parent.(*ast.CaptionFigure); ok {
+		return "subfigure"
+	}
+	switch ast.GetFirstChild(figure).(type) {
+	case *ast.Table:
+		return "table"
+	case *ast.BlockQuote:
+		return ""
+	}
+	return "figure"
+}
+
+func (r *Renderer) captionFigure(w io.Writer, figure *ast.CaptionFigure, entering bool) {
+	env := figureEnvironment(figure)
+	if env == "" {
+		return
+	}
+	if !entering {
+		r.outs(w, "\\end{"+env+"}\n")
+		return
+	}
+	switch env {
+	case "subfigure":
+		r.outs(w, "\n\\begin{subfigure}{\\linewidth}\n\\centering\n")
+	default:
+		r.outs(w, "\n\\begin{"+env+"}[htbp]\n\\centering\n")
+	}
+}
+
+func (r *Renderer) caption(w io.Writer, caption *ast.Caption, entering bool) {
+	figure, ok := caption.Parent.(*ast.CaptionFigure)
+	if !ok {
+		return
+	}
+	if figureEnvironment(figure) == "" {
+		r.outOneOf(w, entering, "\\begin{flushright}\n--- ", "\n\\end{flushright}\n")
+		return
+	}
+	if entering {
+		r.outs(w, "\n\\caption{")
+		return
+	}
+	r.outs(w, "}")
+	if id := mast.FigureID(figure); id != "" {
+		r.outs(w, "\\label{"+key([]byte(id))+"}")
+	}
+	r.outs(w, "\n")
+}
+
+func (r *Renderer) blockQuote(w io.Writer, block *ast.BlockQuote, entering bool) {
+	r.outOneOf(w, entering, "\n\\begin{quote}\n", "\\end{quote}\n")
+}
+
+func (r *Renderer) aside(w io.Writer, block *ast.Aside, entering bool) {
+	r.outOneOf(w, entering, "\n\\begin{quote}\n\\small\\itshape\n", "\\end{quote}\n")
+}
+
+func (r *Renderer) text(w io.Writer, node *ast.Text, entering bool) {
+	if !entering {
+		return
+	}
+	text := node.Literal
+	// Captions end with whitespace which looks odd before a \label.
+	if _, ok := node.Parent.(*ast.Caption); ok && ast.GetNextNode(node) == nil {
+		text = bytes.TrimRight(text, " \n")
+	}
+	r.outs(w, escape(string(text)))
+}
+
+// RenderNode renders a markdown node to LaTeX.
+func (r *Renderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast.WalkStatus {
+	if r.opts.RenderNodeHook != nil {
+		status, didHandle := r.opts.RenderNodeHook(w, node, entering)
+		if didHandle {
+			return status
+		}
+	}
+
+	switch node := node.(type) {
+	case *ast.Document:
+		// do nothing
+	case *mast.Title:
+		r.title(w, node, entering)
+		r.Title = node // save for later.
+	case *mast.Authors:
+		// the authors are part of the title
+	case *mast.TableOfContents:
+		// LaTeX makes its own table of contents.
+		return ast.SkipChildren
+	case *mast.BibliographyWrapper:
+		// each bibliography gets its own thebibliography environment.
+	case *mast.Bibliography:
+		r.bibliography(w, node, entering)
+	case *mast.BibliographyItem:
+		r.bibliographyItem(w, node, entering)
+	case *mast.DocumentIndex:
+		if entering {
+			r.closeAbstract(w)
+			r.outs(w, "\n\\printindex\n")
+		}
+		return ast.SkipChildren
+	case *mast.IndexLetter, *mast.IndexItem, *mast.IndexSubItem, *mast.IndexLink:
+	case *mast.ReferenceBlock:
+		// ignore
+	case *ast.Footnotes:
+		// footnotes are typeset where they are referenced.
+		return ast.SkipChildren
+	case *ast.Text:
+		r.text(w, node, entering)
+	case *ast.Softbreak:
+		r.outs(w, "\n")
+	case *ast.Hardbreak:
+		r.outs(w, "\\\\\n")
+	case *ast.NonBlockingSpace:
+		r.outs(w, "~")
+	case *ast.Callout:
+		r.outs(w, "\\textbf{("+escape(string(node.ID))+")}")
+	case *ast.Emph:
+		r.outOneOf(w, entering, "\\emph{", "}")
+	case *ast.Strong:
+		r.outOneOf(w, entering, "\\textbf{", "}")
+	case *ast.Del:
+		r.outOneOf(w, entering, "\\sout{", "}")
+	case *ast.Citation:
+		r.citation(w, node, entering)
+	case *ast.DocumentMatter:
+		r.matter(w, node, entering)
+	case *ast.Heading:
+		return r.heading(w, node, entering)
+	case *ast.HorizontalRule:
+		if entering {
+			r.outs(w, "\n\\noindent\\rule{\\linewidth}{0.4pt}\n")
+		}
+	case *ast.Paragraph:
+		r.paragraph(w, node, entering)
+	case *ast.HTMLSpan, *ast.HTMLBlock:
+		// HTML can't be typeset.
+	case *ast.List:
+		return r.list(w, node, entering)
+	case *ast.ListItem:
+		r.listItem(w, node, entering)
+	case *ast.CodeBlock:
+		r.codeBlock(w, node, entering)
+	case *ast.Caption:
+		r.caption(w, node, entering)
+	case *ast.CaptionFigure:
+		r.captionFigure(w, node, entering)
+	case *ast.Table:
+		r.table(w, node, entering)
+	case *ast.TableCell:
+		r.tableCell(w, node, entering)
+	case *ast.TableHeader:
+	case *ast.TableBody:
+	case *ast.TableFooter:
+	case *ast.TableRow:
+		r.tableRow(w, node, entering)
+	case *ast.BlockQuote:
+		r.blockQuote(w, node, entering)
+	case *ast.Aside:
+		r.aside(w, node, entering)
+	case *ast.CrossReference:
+		return r.crossReference(w, node, entering)
+	case *ast.Index:
+		r.index(w, node, entering)
+	case *ast.Link:
+		return r.link(w, node, entering)
+	case *ast.Math:
+		if entering {
+			r.outs(w, "$")
+			r.out(w, node.Literal)
+			r.outs(w, "$")
+		}
+	case *ast.Image:
+		return r.image(w, node, entering)
+	case *ast.Code:
+		r.outs(w, "\\texttt{"+escape(string(node.Literal))+"}")
+	case *ast.MathBlock:
+		return r.mathBlock(w, node, entering)
+	case *ast.Subscript:
+		r.outs(w, "\\textsubscript{"+escape(string(node.Literal))+"}")
+	case *ast.Superscript:
+		r.outs(w, "\\textsuperscript{"+escape(string(node.Literal))+"}")
+	default:
+		panic(fmt.Sprintf("Unknown node %T", node))
+	}
+	return ast.GoToNext
+}
+
+const preamble = `\documentclass{article}
+\usepackage[utf8]{inputenc}
+\usepackage[T1]{fontenc}
+\usepackage{amsmath}
+\usepackage{graphicx}
+\usepackage{listings}
+\usepackage{makeidx}
+\usepackage{subcaption}
+\usepackage[normalem]{ulem}
+\usepackage{hyperref}
+\lstset{basicstyle=\ttfamily\small,breaklines=true,columns=fullflexible}
+\makeindex
+`
+
+func (r *Renderer) RenderHeader(w io.Writer, _ ast.Node) {
+	if r.opts.Flags&LatexFragment != 0 {
+		return
+	}
+	r.outs(w, "% Generated by Mmark Markdown Processor - mmark.miek.nl\n")
+	r.outs(w, preamble)
+	r.outs(w, "\n\\begin{document}\n")
+}
+
+func (r *Renderer) RenderFooter(w io.Writer, _ ast.Node) {
+	r.closeAbstract(w)
+	if r.opts.Flags&LatexFragment != 0 {
+		return
+	}
+	r.outs(w, "\n\\end{document}\n")
+}
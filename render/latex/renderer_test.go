@@ -0,0 +1,74 @@
+package latex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mparser"
+)
+
+func render(t *testing.T, input string) string {
+	t.Helper()
+	init := mparser.NewInitial("")
+	p := parser.NewWithExtensions(mparser.Extensions)
+	p.Opts = parser.Options{ParserHook: init.Hook}
+	doc := markdown.Parse([]byte(input), p)
+	r := NewRenderer(RendererOptions{Flags: LatexFragment, Language: lang.New("en"), Comments: [][]byte{[]byte("//")}})
+	return string(markdown.Render(doc, r))
+}
+
+func TestRenderer(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"# Intro {#intro}\n\nSee (#intro), 50% _done_ and `a_b`.\n", []string{
+			`\section{Intro}\label{intro}`,
+			`See \autoref{intro}, 50\% \emph{done} and \texttt{a\_b}.`,
+		}},
+		{".# Abstract\n\nShort.\n\n# Intro\n", []string{"\\begin{abstract}\n\nShort.\n\\end{abstract}\n\n\\section{Intro}"}},
+		{"Note[^1].\n\n[^1]: The *note*.\n", []string{`Note\footnote{The \emph{note}.`}},
+		{"~~~ c\nx = 1; //<<1>>\n~~~\n", []string{
+			`\begin{lstlisting}[language=C,escapeinside={(@}{@)}]`,
+			`x = 1; (@\textbf{(1)}@)`,
+		}},
+		{"$$\nx^2\n$$\n", []string{`\[x^2\]`}},
+		{"Term\n: Definition\n", []string{"\\begin{description}\n\\item[{Term}]\nDefinition\n\\end{description}"}},
+		{"Name | Age\n-----|----\nBob  | 27\nTable: People {#people}\n", []string{
+			`\begin{table}[htbp]`,
+			`\begin{tabular}{|l|l|}`,
+			`\textbf{Name} & \textbf{Age} \\ \hline`,
+			`\caption{People}\label{people}`,
+		}},
+		{"{#fig1}\n~~~ go\nx := 1\n~~~\nFigure: Code.\n\nSee (#fig1).\n", []string{
+			`\caption{Code.}\label{fig1}`,
+			`See \autoref{fig1}.`,
+		}},
+		{"A> Aside.\n", []string{`\small\itshape`}},
+		{"See [@RFC2119, section 3] and [@-RFC8174].\n(!item, sub)\n", []string{
+			`\cite[section 3]{RFC2119}`,
+			`\nocite{RFC8174}`,
+			`\index{item!sub}`,
+		}},
+	}
+	for i, tc := range tests {
+		got := render(t, tc.input)
+		for _, want := range tc.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("test %d: expected %q in output, got\n%s", i, want, got)
+			}
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got, want := escape(`\{}$&#^_%~`), `\textbackslash{}\{\}\$\&\#\textasciicircum{}\_\%\textasciitilde{}`; got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+	if got, want := escapeIndex([]byte(`a!b@c`)), `a"!b"@c`; got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
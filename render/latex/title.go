@@ -0,0 +1,50 @@
+package latex
+
+import (
+	"io"
+	"strings"
+
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// title outputs the title, authors and date, and typesets them with \maketitle. The authors' organization
+// and email are put on separate lines below their names.
+func (r *Renderer) title(w io.Writer, node *mast.Title, entering bool) {
+	if !entering {
+		return
+	}
+
+	authors := []string{}
+	names := []string{}
+	for _, a := range node.Author {
+		lines := []string{escape(a.Fullname)}
+		if a.Organization != "" {
+			lines = append(lines, escape(a.Organization))
+		}
+		if a.Address.Email != "" {
+			lines = append(lines, "\\texttt{"+escape(a.Address.Email)+"}")
+		}
+		authors = append(authors, strings.Join(lines, " \\\\ "))
+		names = append(names, escape(a.Fullname))
+	}
+
+	r.outs(w, "\\hypersetup{pdftitle={"+escape(node.Title)+"}")
+	if len(names) > 0 {
+		r.outs(w, ",pdfauthor={"+strings.Join(names, ", ")+"}")
+	}
+	if len(node.Keyword) > 0 {
+		kw := make([]string, len(node.Keyword))
+		for i := range node.Keyword {
+			kw[i] = escape(node.Keyword[i])
+		}
+		r.outs(w, ",pdfkeywords={"+strings.Join(kw, ", ")+"}")
+	}
+	r.outs(w, "}\n")
+
+	r.outs(w, "\\title{"+escape(node.Title)+"}\n")
+	r.outs(w, "\\author{"+strings.Join(authors, " \\and ")+"}\n")
+	if !node.Date.IsZero() {
+		r.outs(w, "\\date{"+node.Date.Format("January 2, 2006")+"}\n")
+	}
+	r.outs(w, "\\maketitle\n")
+}
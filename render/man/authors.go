@@ -25,7 +25,7 @@ func (r *Renderer) authors(w io.Writer, _ *mast.Authors, entering bool) {
 	// create a node and call render on it.
 	node := &ast.Heading{Level: 1}
 	authors := r.opts.Language.Authors()
-	ast.AppendChild(node, &ast.Text{ast.Leaf{Literal: []byte(authors)}})
+	ast.AppendChild(node, &ast.Text{Leaf: ast.Leaf{Literal: []byte(authors)}})
 	la := len(author)
 
 	// Needs to use the translation stuff
@@ -47,7 +47,7 @@ func (r *Renderer) authors(w io.Writer, _ *mast.Authors, entering bool) {
 	}
 	text += "."
 
-	ast.AppendChild(para, &ast.Text{ast.Leaf{Literal: []byte(text)}})
+	ast.AppendChild(para, &ast.Text{Leaf: ast.Leaf{Literal: []byte(text)}})
 	ast.AppendChild(node, para)
 
 	ast.WalkFunc(node, func(node ast.Node, entering bool) ast.WalkStatus {
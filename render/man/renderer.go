@@ -8,16 +8,18 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"log"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/html"
+	"github.com/mmarkdown/mmark/v2/diag"
 	"github.com/mmarkdown/mmark/v2/lang"
 	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+	"github.com/mmarkdown/mmark/v2/mparser"
+	"github.com/mmarkdown/mmark/v2/render/cite"
 )
 
 // Flags control optional behavior of Markdown renderer.
@@ -44,6 +46,22 @@ type RendererOptions struct {
 	// Comments is a list of comments the renderer should detect when
 	// parsing code blocks and detecting callouts.
 	Comments [][]byte
+
+	// Style is the citation style used to format the bibliography, defaults to cite.Default.
+	Style cite.Style
+
+	// Diagnostics collects all problems found while rendering, if nil they are logged.
+	Diagnostics *diag.Diagnostics
+
+	// ReadFile returns the contents of an ascii-art image, these are typeset as preformatted text. See
+	// mparser.ReadFileFunc for what is used when it's nil.
+	ReadFile func(name string) ([]byte, error)
+
+	// File is the name of the document, used in diagnostics for nodes without a known source span.
+	File string
+
+	// Sources holds the source spans of the nodes, it's used to locate diagnostics. May be nil.
+	Sources *mast.Sources
 }
 
 // Renderer implements Renderer interface for Markdown output.
@@ -88,14 +106,14 @@ func (r *Renderer) title(w io.Writer, node *mast.Title, entering bool) {
 	case i > 0:
 		d, err := strconv.Atoi(node.Title[i:])
 		if err != nil {
-			log.Print("No section number found at end of title, defaulting to 1")
+			r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(node, diag.Warning, "man-section", r.opts.File, "No section number found at end of title, defaulting to 1"))
 		} else {
 			section = d
 			title = node.Title[:i-1]
 		}
 	}
 	if i == 0 {
-		log.Print("No section number found at end of title, defaulting to 1")
+		r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(node, diag.Warning, "man-section", r.opts.File, "No section number found at end of title, defaulting to 1"))
 	}
 
 	r.outs(w, fmt.Sprintf(".TH %q", strings.ToUpper(title)))
@@ -340,9 +358,10 @@ func (r *Renderer) image(w io.Writer, node *ast.Image, entering bool) {
 	}
 	node.SetChildren(nil) // remove Title, if any, we can type set it.
 	r.outs(w, "\n.PP\n.RS\n\n.nf\n")
-	img, err := ioutil.ReadFile(string(node.Destination)) // markdown, doens't err, this can be an empty image, log maybe??
+	img, err := mparser.ReadFileFunc(r.opts.ReadFile)(string(node.Destination))
 	if err != nil {
-		img = []byte(err.Error())
+		r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(node, diag.Warning, "man-image", r.opts.File, "Failure to read image: %s", err))
+		return
 	}
 	escapeSpecialChars(r, w, img)
 }
@@ -445,6 +464,10 @@ func (r *Renderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast.Wal
 		r.Title = node // save for later.
 	case *mast.Authors:
 		r.authors(w, node, entering)
+	case *mast.TableOfContents:
+		r.tableOfContents(w, node, entering)
+	case *mast.TableOfContentsEntry:
+		r.tableOfContentsEntry(w, node, entering)
 	case *mast.Bibliography, *mast.BibliographyWrapper:
 		if entering {
 			r.outs(w, "\n.SH \"")
@@ -598,32 +621,43 @@ func (r *Renderer) bibliographyItem(w io.Writer, bib *mast.BibliographyItem, ent
 	if !entering {
 		return
 	}
-	if bib.Reference == nil {
+	if bib.Reference == nil && bib.ReferenceGroup == nil {
 		return
 	}
 	r.outs(w, ".TP\n")
 	r.outs(w, fmt.Sprintf("[%s]\n", bib.Anchor))
-	for _, author := range bib.Reference.Front.Authors {
-		writeNonEmptyString(w, author.Fullname)
-		if author.Organization != nil {
-			writeNonEmptyString(w, author.Organization.Value)
+	if bib.Reference != nil {
+		r.reference(w, bib.Reference)
+		return
+	}
+	group := bib.ReferenceGroup
+	for i := range group.References {
+		if i > 0 {
+			r.outs(w, ".br\n")
 		}
+		r.reference(w, &group.References[i])
 	}
-
-	writeNonEmptyString(w, bib.Reference.Front.Title)
-	if bib.Reference.Target != "" {
-		r.outs(w, "\\[la]")
-		r.outs(w, bib.Reference.Target)
-		r.outs(w, "\\[ra]")
+	if group.Target != "" {
+		r.outs(w, ".br\n")
+		r.outs(w, strings.Replace(group.Target, "-", "\\-", -1)+"\n")
 	}
-	writeNonEmptyString(w, bib.Reference.Front.Date.Year)
-	r.outs(w, "\n")
 }
 
-func writeNonEmptyString(w io.Writer, s string) {
-	if s == "" {
-		return
+// reference writes ref formatted according to the citation style on a single line.
+func (r *Renderer) reference(w io.Writer, ref *reference.Reference) {
+	style := r.opts.Style
+	if style == nil {
+		style = cite.Default
+	}
+	r.outs(w, "\\&") // the reference may start with a period.
+	for _, f := range style.Format(ref, r.opts.Language) {
+		text := strings.NewReplacer("\\", "\\e", "-", "\\-", "\n", " ").Replace(f.Text)
+		switch f.Kind {
+		case cite.Title:
+			r.outs(w, "\\fI"+text+"\\fP")
+		default:
+			r.outs(w, text)
+		}
 	}
-	io.WriteString(w, s)
-	io.WriteString(w, "\n")
+	r.outs(w, "\n")
 }
@@ -0,0 +1,28 @@
+package man
+
+import (
+	"io"
+	"strings"
+
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// tableOfContents creates a section with the table of contents, the entries are indented according to their
+// level.
+func (r *Renderer) tableOfContents(w io.Writer, _ *mast.TableOfContents, entering bool) {
+	if !entering {
+		r.outs(w, ".fi\n")
+		return
+	}
+	r.outs(w, "\n.SH \""+strings.ToUpper(r.opts.Language.Contents())+"\"\n")
+	r.outs(w, ".nf\n")
+}
+
+func (r *Renderer) tableOfContentsEntry(w io.Writer, entry *mast.TableOfContentsEntry, entering bool) {
+	if !entering {
+		return
+	}
+	r.outs(w, strings.Repeat("  ", entry.Level-1))
+	escapeSpecialChars(r, w, entry.Literal)
+	r.outs(w, "\n")
+}
@@ -0,0 +1,363 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/render/xml"
+)
+
+const (
+	space     = "\x00" // a space that can't be used to wrap a line
+	hardbreak = "\x01" // a forced line break
+)
+
+// protect makes s unbreakable when wrapping.
+func protect(s string) string { return strings.NewReplacer(" ", space, "\n", space).Replace(s) }
+
+// unprotect reverts protect and turns hard breaks into newlines.
+func unprotect(s string) string {
+	return strings.NewReplacer(space, " ", hardbreak, "\n").Replace(s)
+}
+
+func (r *Renderer) outs(w io.Writer, s string) { io.WriteString(w, s) }
+
+// inlineWriter returns the writer inline elements are written to.
+func (r *Renderer) inlineWriter(w io.Writer) io.Writer {
+	if r.inline != nil {
+		return r.inline
+	}
+	return w
+}
+
+// inlineString renders the children of node as a single line.
+func (r *Renderer) inlineString(node ast.Node) string {
+	saved := r.inline
+	r.inline = &bytes.Buffer{}
+	for _, child := range node.GetChildren() {
+		ast.WalkFunc(child, func(node ast.Node, entering bool) ast.WalkStatus {
+			return r.RenderNode(r.inline, node, entering)
+		})
+	}
+	s := r.inline.String()
+	r.inline = saved
+	return unprotect(strings.Join(strings.Fields(strings.Replace(s, hardbreak, " ", -1)), " "))
+}
+
+func (r *Renderer) renderInline(w io.Writer, node ast.Node, entering bool) ast.WalkStatus {
+	switch node := node.(type) {
+	case *ast.Text:
+		if entering {
+			text := r.escapeText(adjacent(node))
+			// BCP 14 keywords must not be wrapped, "MUST\nNOT" isn't seen as a keyword.
+			if _, ok := node.Parent.(*ast.Strong); ok && xml.Is2119([]byte(strings.Join(strings.Fields(text), " "))) {
+				text = protect(strings.Join(strings.Fields(text), " "))
+			}
+			r.outs(w, text)
+		}
+	case *ast.Softbreak:
+		r.outs(w, " ")
+	case *ast.Hardbreak:
+		r.outs(w, "\\"+hardbreak)
+	case *ast.NonBlockingSpace:
+		r.outs(w, "\\"+space)
+	case *ast.Emph:
+		r.outs(w, "*")
+	case *ast.Strong:
+		r.outs(w, "**")
+	case *ast.Del:
+		r.outs(w, "~~")
+	case *ast.Code:
+		r.outs(w, protect(codeSpan(node.Literal)))
+	case *ast.HTMLSpan:
+		r.outs(w, protect(string(node.Literal)))
+	case *ast.Math:
+		r.outs(w, protect("$"+string(node.Literal)+"$"))
+	case *ast.Subscript:
+		r.outs(w, protect("~"+string(node.Literal)+"~"))
+	case *ast.Superscript:
+		r.outs(w, protect("^"+string(node.Literal)+"^"))
+	case *ast.Callout:
+		r.outs(w, "<<"+string(node.ID)+">>")
+	case *ast.Index:
+		idx := "(!"
+		if node.Primary {
+			idx += "!"
+		}
+		idx += string(node.Item)
+		if len(node.Subitem) > 0 {
+			idx += ", " + string(node.Subitem)
+		}
+		r.outs(w, protect(idx+")"))
+	case *ast.CrossReference:
+		if entering {
+			ref := "(#" + string(node.Destination)
+			if len(node.Suffix) > 0 {
+				ref += ", " + string(node.Suffix)
+			}
+			r.outs(w, protect(ref+")"))
+		}
+		return ast.SkipChildren
+	case *ast.Citation:
+		r.outs(w, protect(citation(node)))
+	case *ast.Link:
+		return r.link(w, node, entering)
+	case *ast.Image:
+		if entering {
+			r.outs(w, "![")
+			return ast.GoToNext
+		}
+		r.outs(w, protect("]("+destination(node.Destination, node.Title)+")"))
+	default:
+		panic(fmt.Sprintf("Unknown node %T", node))
+	}
+	return ast.GoToNext
+}
+
+func (r *Renderer) link(w io.Writer, link *ast.Link, entering bool) ast.WalkStatus {
+	if link.Footnote != nil || len(link.DeferredID) > 0 && link.NoteID > 0 {
+		if entering {
+			id := link.DeferredID
+			if len(id) == 0 {
+				id = link.Destination
+			}
+			r.outs(w, protect("[^"+string(id)+"]"))
+		}
+		return ast.SkipChildren
+	}
+	// An autolink.
+	if len(link.Title) == 0 && len(link.Children) == 1 {
+		if t, ok := link.Children[0].(*ast.Text); ok {
+			if dest := string(link.Destination); dest == string(t.Literal) || dest == "mailto:"+string(t.Literal) {
+				if entering {
+					r.outs(w, protect("<"+string(t.Literal)+">"))
+				}
+				return ast.SkipChildren
+			}
+		}
+	}
+	if entering {
+		r.outs(w, "[")
+		return ast.GoToNext
+	}
+	r.outs(w, protect("]("+destination(link.Destination, link.Title)+")"))
+	return ast.GoToNext
+}
+
+// destination returns the destination and optional title of a link or image.
+func destination(dest, title []byte) string {
+	d := string(dest)
+	if strings.ContainsAny(d, " ()") {
+		d = "<" + d + ">"
+	}
+	if len(title) > 0 {
+		d += ` "` + strings.Replace(string(title), `"`, `\"`, -1) + `"`
+	}
+	return d
+}
+
+// citation returns the citation, the modifier is added to each citation.
+func citation(node *ast.Citation) string {
+	cites := make([]string, len(node.Destination))
+	for i, dest := range node.Destination {
+		c := "@"
+		if i < len(node.Type) {
+			switch node.Type[i] {
+			case ast.CitationTypeNormative:
+				c += "!"
+			case ast.CitationTypeSuppressed:
+				c += "-"
+			}
+		}
+		c += string(dest)
+		if i < len(node.Suffix) && len(node.Suffix[i]) > 0 {
+			c += ", " + string(node.Suffix[i])
+		}
+		cites[i] = c
+	}
+	return "[" + strings.Join(cites, ";") + "]"
+}
+
+// codeSpan returns code between enough backticks to not clash with the backticks in code.
+func codeSpan(code []byte) string {
+	longest, run := 0, 0
+	for _, c := range code {
+		if c == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+			continue
+		}
+		run = 0
+	}
+	ticks := strings.Repeat("`", longest+1)
+	if bytes.HasPrefix(code, []byte("`")) || bytes.HasSuffix(code, []byte("`")) {
+		return ticks + " " + string(code) + " " + ticks
+	}
+	return ticks + string(code) + ticks
+}
+
+// adjacent returns text with the last byte of the previous and the first byte of the next text node, if any,
+// added. The parser splits text at escaped characters, these bytes are needed to decide what to escape.
+func adjacent(node *ast.Text) []byte {
+	text := []byte{' '}
+	if prev, ok := ast.GetPrevNode(node).(*ast.Text); ok && len(prev.Literal) > 0 {
+		text[0] = prev.Literal[len(prev.Literal)-1]
+	}
+	text = append(text, node.Literal...)
+	if next, ok := ast.GetNextNode(node).(*ast.Text); ok && len(next.Literal) > 0 {
+		text = append(text, next.Literal[0])
+	} else {
+		text = append(text, 0)
+	}
+	return text
+}
+
+// escapeText escapes the characters in text that would otherwise be parsed as markup. The first and last
+// byte of text are context from the adjacent text and are not written, see adjacent.
+func (r *Renderer) escapeText(text []byte) string {
+	buf := &bytes.Buffer{}
+	for i := 1; i < len(text)-1; i++ {
+		c, next := text[i], text[i+1]
+		switch c {
+		case '\\', '`', '*', '[', ']', '~', '^', '$':
+			buf.WriteByte('\\')
+		case '_':
+			// only at the start or end of a word, intra word emphasis is disabled.
+			if !isAlnum(text[i-1]) || !isAlnum(next) {
+				buf.WriteByte('\\')
+			}
+		case '<':
+			if isAlnum(next) || next == '/' || next == '!' || next == '?' || next == '<' {
+				buf.WriteByte('\\')
+			}
+		case ':':
+			// a bare URL is turned into a link.
+			if next == '/' && isScheme(text[:i]) {
+				buf.WriteByte('\\')
+			}
+		case '(':
+			if next == '#' || next == '!' || next == '@' {
+				buf.WriteByte('\\')
+			}
+		case '|':
+			if r.inTable {
+				buf.WriteByte('\\')
+			}
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// isScheme returns true if text ends with a URL scheme that the parser turns into a link.
+func isScheme(text []byte) bool {
+	for _, scheme := range []string{"http", "https", "ftp", "file"} {
+		if len(text) >= len(scheme) && strings.EqualFold(string(text[len(text)-len(scheme):]), scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= utf8.RuneSelf
+}
+
+var (
+	reOrdered = regexp.MustCompile(`^[0-9]+[.)]$`)
+	reAside   = regexp.MustCompile(`^[A-Z]>`)
+)
+
+// escapeStart returns word escaped so that it has no special meaning at the start of a line, i.e. a "#"
+// that would start a heading.
+func escapeStart(word string) string {
+	switch {
+	case word == "":
+		return word
+	case strings.Trim(word, "#") == "", word == "*", word == "+", word == "-":
+		return `\` + word
+	case strings.Trim(word, "-") == "" || strings.Trim(word, "_") == "":
+		return `\` + word
+	case word[0] == '>' || word[0] == ':' || word[0] == '|':
+		return `\` + word
+	case strings.HasPrefix(word, "```") || strings.HasPrefix(word, "~~~") || strings.HasPrefix(word, "!---"):
+		return `\` + word
+	case strings.HasPrefix(word, "[^") && strings.Contains(word, "]:"):
+		return `\` + word
+	case reOrdered.MatchString(word):
+		return word[:len(word)-1] + `\` + word[len(word)-1:]
+	case reAside.MatchString(word):
+		return word[:1] + `\` + word[1:]
+	case word == "Figure:" || word == "Table:" || word == "Quote:":
+		return word[:len(word)-1] + `\:`
+	}
+	return word
+}
+
+// safeStart returns true if word can start a line.
+func safeStart(word string) bool {
+	if escapeStart(word) != word {
+		return false
+	}
+	switch {
+	case word[0] == '<', word[0] == '{', word[0] == '=', strings.HasPrefix(word, "%%%"), strings.HasPrefix(word, "$$"):
+		return false
+	}
+	return true
+}
+
+// wrap wraps text at width, it never breaks a line before a word that would have special meaning at the
+// start of a line.
+func wrap(text string, width int) []string {
+	lines := []string{}
+	for _, segment := range strings.Split(text, hardbreak) {
+		words := strings.Fields(segment)
+		if len(words) == 0 {
+			continue
+		}
+		words[0] = escapeStart(words[0])
+		cur, n := words[0], utf8.RuneCountInString(words[0])
+		for _, word := range words[1:] {
+			l := utf8.RuneCountInString(word)
+			if n+1+l > width && safeStart(word) {
+				lines = append(lines, unprotect(cur))
+				cur, n = word, l
+				continue
+			}
+			cur += " " + word
+			n += 1 + l
+		}
+		lines = append(lines, unprotect(cur))
+	}
+	return lines
+}
+
+// sanitizeHeadingID returns the heading ID the parser generates for text.
+func sanitizeHeadingID(text string) string {
+	var anchorName []rune
+	futureDash := false
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if futureDash && len(anchorName) > 0 {
+				anchorName = append(anchorName, '-')
+			}
+			futureDash = false
+			anchorName = append(anchorName, unicode.ToLower(r))
+		default:
+			futureDash = true
+		}
+	}
+	if len(anchorName) == 0 {
+		return "empty"
+	}
+	return string(anchorName)
+}
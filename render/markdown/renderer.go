@@ -0,0 +1,458 @@
+// The package markdown outputs mmark markdown. It is used to format (normalize) mmark documents and to
+// write documents back after they have been edited programmatically.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// Flags control optional behavior of Markdown renderer.
+type Flags int
+
+// Markdown renderer configuration options.
+const (
+	FlagsNone Flags = 0
+
+	CommonFlags Flags = FlagsNone
+)
+
+// RendererOptions is a collection of supplementary parameters tweaking
+// the behavior of various parts of Markdown renderer.
+type RendererOptions struct {
+	Flags Flags // Flags allow customizing this renderer's behavior
+
+	// TextWidth is the width paragraphs are wrapped at, defaults to 100.
+	TextWidth int
+
+	// if set, called at the start of RenderNode(). Allows replacing rendering of some nodes
+	RenderNodeHook html.RenderNodeFunc
+}
+
+// Renderer implements Renderer interface for Markdown output.
+type Renderer struct {
+	opts RendererOptions
+
+	// prefix holds what each line starts with, one for each nested block, i.e. "> " for a block quote. If
+	// the marker of a level is set it is used instead on the next line, i.e. "* " for a list item.
+	prefix []string
+	marker []string
+
+	inline  *bytes.Buffer // when not nil, inline elements are written here
+	inTable bool          // when rendering table cells, pipes need escaping
+}
+
+// NewRenderer creates and configures an Renderer object, which satisfies the Renderer interface.
+func NewRenderer(opts RendererOptions) *Renderer {
+	if opts.TextWidth == 0 {
+		opts.TextWidth = 100
+	}
+	return &Renderer{opts: opts}
+}
+
+func (r *Renderer) push(prefix, marker string) {
+	r.prefix = append(r.prefix, prefix)
+	r.marker = append(r.marker, marker)
+}
+
+func (r *Renderer) pop() {
+	r.prefix = r.prefix[:len(r.prefix)-1]
+	r.marker = r.marker[:len(r.marker)-1]
+}
+
+// line writes a single line, starting with the current prefix.
+func (r *Renderer) line(w io.Writer, s string) {
+	prefix := ""
+	for i := range r.prefix {
+		if r.marker[i] != "" {
+			prefix += r.marker[i]
+			r.marker[i] = ""
+			continue
+		}
+		prefix += r.prefix[i]
+	}
+	if s == "" {
+		prefix = strings.TrimRight(prefix, " ")
+	}
+	r.outs(w, prefix+s+"\n")
+}
+
+// lines writes each line in s with line.
+func (r *Renderer) lines(w io.Writer, s string) {
+	for _, l := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		r.line(w, l)
+	}
+}
+
+// width returns the width available for text, after the prefix.
+func (r *Renderer) width() int {
+	width := r.opts.TextWidth
+	for _, p := range r.prefix {
+		width -= len(p)
+	}
+	if width < 20 {
+		width = 20
+	}
+	return width
+}
+
+// blockStart separates the block from the previous one with an empty line and outputs its block level
+// attributes, if any.
+func (r *Renderer) blockStart(w io.Writer, node ast.Node) {
+	if ast.GetPrevNode(node) != nil && !tight(node) {
+		r.line(w, "")
+	}
+	attr := mast.AttributeFromNode(node)
+	if attr == nil || emptyAttribute(attr) {
+		return
+	}
+	// The attribute of a figure is also set on its first block.
+	if figure, ok := node.GetParent().(*ast.CaptionFigure); ok && mast.AttributeFromNode(figure) != nil {
+		if bytes.Equal(mast.AttributeBytes(attr), mast.AttributeBytes(mast.AttributeFromNode(figure))) {
+			return
+		}
+	}
+	r.line(w, string(mast.AttributeBytes(attr)))
+}
+
+// tight returns true if node is in a list item that doesn't contain blocks, these are not separated by
+// empty lines. The parser sets ListItemContainsBlock on all items after the first empty line in a list.
+func tight(node ast.Node) bool {
+	item, ok := node.GetParent().(*ast.ListItem)
+	return ok && item.ListFlags&ast.ListItemContainsBlock == 0
+}
+
+func emptyAttribute(attr *ast.Attribute) bool {
+	return len(attr.ID) == 0 && len(attr.Classes) == 0 && len(attr.Attrs) == 0
+}
+
+func (r *Renderer) title(w io.Writer, node *mast.Title) {
+	content := bytes.Trim(node.Content, "\n")
+	if len(content) == 0 {
+		content = encodeTitle(node.TitleData)
+	}
+	r.line(w, "%%%")
+	r.lines(w, string(content))
+	r.line(w, "%%%")
+}
+
+func (r *Renderer) matter(w io.Writer, node *ast.DocumentMatter) {
+	r.blockStart(w, node)
+	switch node.Matter {
+	case ast.DocumentMatterFront:
+		r.line(w, "{frontmatter}")
+	case ast.DocumentMatterMain:
+		r.line(w, "{mainmatter}")
+	case ast.DocumentMatterBack:
+		r.line(w, "{backmatter}")
+	}
+}
+
+func (r *Renderer) heading(w io.Writer, node *ast.Heading) {
+	r.blockStart(w, node)
+	text := r.inlineString(node)
+	if node.IsSpecial {
+		r.line(w, ".# "+text)
+		return
+	}
+	// Only output the ID when it isn't the one generated from the text.
+	source := string(node.Content)
+	if source == "" {
+		source = unprotect(text)
+	}
+	if node.HeadingID != "" && node.HeadingID != sanitizeHeadingID(source) {
+		text += " {#" + node.HeadingID + "}"
+	}
+	r.line(w, strings.Repeat("#", node.Level)+" "+text)
+}
+
+func (r *Renderer) paragraph(w io.Writer, para *ast.Paragraph, entering bool) {
+	if entering {
+		r.blockStart(w, para)
+		r.inline = &bytes.Buffer{}
+		return
+	}
+	text := r.inline.String()
+	r.inline = nil
+	r.text(w, para, text)
+}
+
+// text writes the inline text of node, wrapped at the available width.
+func (r *Renderer) text(w io.Writer, node ast.Node, text string) {
+	// A term in a definition list must be on a single line.
+	if item, ok := node.GetParent().(*ast.ListItem); ok && item.ListFlags&ast.ListTypeTerm != 0 {
+		r.line(w, unprotect(strings.Join(strings.Fields(strings.Replace(text, hardbreak, " ", -1)), " ")))
+		return
+	}
+	for _, l := range wrap(text, r.width()) {
+		r.line(w, l)
+	}
+}
+
+func (r *Renderer) list(w io.Writer, list *ast.List, entering bool) {
+	if entering {
+		r.blockStart(w, list)
+	}
+}
+
+func (r *Renderer) listItem(w io.Writer, item *ast.ListItem, entering bool) {
+	if !entering {
+		if r.inline != nil {
+			text := r.inline.String()
+			r.inline = nil
+			r.text(w, item, text)
+		}
+		r.pop()
+		return
+	}
+	list, _ := item.Parent.(*ast.List)
+	if prev, ok := ast.GetPrevNode(item).(*ast.ListItem); ok {
+		switch {
+		case item.ListFlags&ast.ListTypeTerm != 0:
+			// a term must be separated from the previous definition, otherwise it becomes part of it.
+			r.line(w, "")
+		case prev.ListFlags&ast.ListItemContainsBlock != 0:
+			r.line(w, "")
+		}
+	}
+
+	x := item.ListFlags
+	switch {
+	case item.RefLink != nil:
+		r.push("    ", "[^"+string(item.RefLink)+"]: ")
+	case x&ast.ListTypeTerm != 0:
+		r.push("", "")
+	case x&ast.ListTypeDefinition != 0:
+		r.push("    ", ": ")
+	case x&ast.ListTypeOrdered != 0:
+		start := 1
+		if list != nil && list.Start > 1 {
+			start = list.Start
+		}
+		delim := item.Delimiter
+		if delim == 0 {
+			delim = '.'
+		}
+		r.push("    ", fmt.Sprintf("%d%c ", start+mast.ChildIndex(item), delim))
+	default:
+		r.push("    ", "* ")
+	}
+	// A footnote with a single paragraph holds the inline elements directly.
+	if first := ast.GetFirstChild(item); first != nil && !isBlock(first) {
+		r.inline = &bytes.Buffer{}
+	}
+}
+
+// isBlock returns true if node is a block level element.
+func isBlock(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.Paragraph, *ast.List, *ast.ListItem, *ast.Heading, *ast.CodeBlock, *ast.MathBlock, *ast.BlockQuote,
+		*ast.Aside, *ast.Table, *ast.CaptionFigure, *ast.HTMLBlock, *ast.HorizontalRule, *mast.ReferenceBlock:
+		return true
+	}
+	return false
+}
+
+func (r *Renderer) codeBlock(w io.Writer, codeBlock *ast.CodeBlock) {
+	r.blockStart(w, codeBlock)
+	fence := "~~~"
+	for strings.Contains(string(codeBlock.Literal), fence) {
+		fence += "~"
+	}
+	if len(codeBlock.Info) > 0 {
+		r.line(w, fence+" "+string(codeBlock.Info))
+	} else {
+		r.line(w, fence)
+	}
+	if len(codeBlock.Literal) > 0 {
+		r.lines(w, string(codeBlock.Literal))
+	}
+	r.line(w, fence)
+}
+
+// mathBlock writes the math between $$ lines, or on a single line when it was written that way, the
+// newlines are part of the math.
+func (r *Renderer) mathBlock(w io.Writer, mathBlock *ast.MathBlock) {
+	r.blockStart(w, mathBlock)
+	if !bytes.Contains(mathBlock.Literal, []byte("\n")) {
+		r.line(w, "$$"+string(mathBlock.Literal)+"$$")
+		return
+	}
+	r.line(w, "$$")
+	if math := bytes.Trim(mathBlock.Literal, "\n"); len(math) > 0 {
+		r.lines(w, string(math))
+	}
+	r.line(w, "$$")
+}
+
+// fenced returns true if the figure needs the !--- fences, only a single table, code block or quote can
+// be captioned without them.
+func fenced(figure *ast.CaptionFigure) bool {
+	children := figure.GetChildren()
+	if len(children) != 2 {
+		return true
+	}
+	switch children[0].(type) {
+	case *ast.Table, *ast.CodeBlock, *ast.BlockQuote:
+		return false
+	}
+	return true
+}
+
+func (r *Renderer) captionFigure(w io.Writer, figure *ast.CaptionFigure, entering bool) {
+	if !entering {
+		if _, ok := ast.GetLastChild(figure).(*ast.Caption); !ok && fenced(figure) {
+			r.line(w, "!---")
+		}
+		return
+	}
+	r.blockStart(w, figure)
+	if fenced(figure) {
+		r.line(w, "!---")
+	}
+}
+
+func (r *Renderer) caption(w io.Writer, caption *ast.Caption) {
+	figure, ok := caption.Parent.(*ast.CaptionFigure)
+	if !ok {
+		return
+	}
+	if fenced(figure) {
+		r.line(w, "!---")
+	}
+	kind := "Figure: "
+	switch ast.GetFirstChild(figure).(type) {
+	case *ast.Table:
+		kind = "Table: "
+	case *ast.BlockQuote:
+		kind = "Quote: "
+		// A quote's caption is separated from the quote.
+		r.line(w, "")
+	}
+	text := r.inlineString(caption)
+	if figure.HeadingID != "" {
+		text += " {#" + figure.HeadingID + "}"
+	}
+	r.line(w, kind+text)
+}
+
+func (r *Renderer) blockQuote(w io.Writer, block *ast.BlockQuote, entering bool) {
+	if !entering {
+		r.pop()
+		return
+	}
+	r.blockStart(w, block)
+	r.push("> ", "")
+}
+
+func (r *Renderer) aside(w io.Writer, block *ast.Aside, entering bool) {
+	if !entering {
+		r.pop()
+		return
+	}
+	r.blockStart(w, block)
+	r.push("A> ", "")
+}
+
+func (r *Renderer) referenceBlock(w io.Writer, node *mast.ReferenceBlock) {
+	r.blockStart(w, node)
+	if len(node.Content) > 0 {
+		r.lines(w, string(bytes.TrimRight(node.Content, "\n")))
+		return
+	}
+	r.lines(w, string(bytes.TrimRight(node.Literal, "\n")))
+}
+
+// RenderNode renders a markdown node to markdown.
+func (r *Renderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast.WalkStatus {
+	if r.opts.RenderNodeHook != nil {
+		status, didHandle := r.opts.RenderNodeHook(w, node, entering)
+		if didHandle {
+			return status
+		}
+	}
+
+	switch node := node.(type) {
+	case *ast.Document:
+		// do nothing
+	case *mast.Title:
+		if entering {
+			r.title(w, node)
+		}
+	case *mast.Authors, *mast.TableOfContents, *mast.BibliographyWrapper, *mast.Bibliography, *mast.BibliographyItem, *mast.DocumentIndex:
+		// generated from the document, these aren't part of it.
+		return ast.SkipChildren
+	case *mast.IndexLetter, *mast.IndexItem, *mast.IndexSubItem, *mast.IndexLink:
+		return ast.SkipChildren
+	case *mast.ReferenceBlock:
+		if entering {
+			r.referenceBlock(w, node)
+		}
+	case *ast.Footnotes:
+		// the footnotes list follows.
+	case *ast.DocumentMatter:
+		if entering {
+			r.matter(w, node)
+		}
+	case *ast.Heading:
+		if entering {
+			r.heading(w, node)
+		}
+		return ast.SkipChildren
+	case *ast.HorizontalRule:
+		if entering {
+			r.blockStart(w, node)
+			r.line(w, "---")
+		}
+	case *ast.Paragraph:
+		r.paragraph(w, node, entering)
+	case *ast.HTMLBlock:
+		if entering {
+			r.blockStart(w, node)
+			r.lines(w, string(node.Literal))
+		}
+	case *ast.List:
+		r.list(w, node, entering)
+	case *ast.ListItem:
+		r.listItem(w, node, entering)
+	case *ast.CodeBlock:
+		if entering {
+			r.codeBlock(w, node)
+		}
+	case *ast.MathBlock:
+		if entering {
+			r.mathBlock(w, node)
+		}
+		return ast.SkipChildren
+	case *ast.Caption:
+		if entering {
+			r.caption(w, node)
+		}
+		return ast.SkipChildren
+	case *ast.CaptionFigure:
+		r.captionFigure(w, node, entering)
+	case *ast.Table:
+		if entering {
+			r.table(w, node)
+		}
+		return ast.SkipChildren
+	case *ast.BlockQuote:
+		r.blockQuote(w, node, entering)
+	case *ast.Aside:
+		r.aside(w, node, entering)
+	default:
+		// all other nodes are inline elements.
+		return r.renderInline(r.inlineWriter(w), node, entering)
+	}
+	return ast.GoToNext
+}
+
+func (r *Renderer) RenderHeader(w io.Writer, _ ast.Node) {}
+
+func (r *Renderer) RenderFooter(w io.Writer, _ ast.Node) {}
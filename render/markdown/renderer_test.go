@@ -0,0 +1,115 @@
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mparser"
+	"github.com/mmarkdown/mmark/v2/render/xml"
+)
+
+func parse(input []byte) ast.Node {
+	init := mparser.NewInitial("")
+	init.Flags |= mparser.KeepInclude
+	p := parser.NewWithExtensions((mparser.Extensions | parser.NoIntraEmphasis) &^ parser.Includes)
+	p.Opts = parser.Options{ParserHook: init.Hook}
+	return markdown.Parse(markdown.NormalizeNewlines(input), p)
+}
+
+func format(input []byte) []byte {
+	return markdown.Render(parse(input), NewRenderer(RendererOptions{}))
+}
+
+// toXML renders input as XML, with all white space collapsed. BCP 14 keywords split over two lines are
+// joined when formatting, so <strong> and <bcp14> are treated as the same.
+func toXML(input []byte) string {
+	r := xml.NewRenderer(xml.RendererOptions{Flags: xml.CommonFlags | xml.XMLFragment, Language: lang.New("en")})
+	out := strings.Join(strings.Fields(string(markdown.Render(parse(input), r))), " ")
+	return strings.NewReplacer("<bcp14>", "<strong>", "</bcp14>", "</strong>").Replace(out)
+}
+
+func TestRenderer(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"#  Intro\nSome   *text*\nhere.\n", "# Intro\n\nSome *text* here.\n"},
+		{"# Intro {#start}\n\n.# Abstract\n", "# Intro {#start}\n\n.# Abstract\n"},
+		{"- a\n- b\n\n1) one\n2) two\n", "* a\n* b\n\n1) one\n2) two\n"},
+		{"Term\n:   Definition\n", "Term\n: Definition\n"},
+		{"See [@!RFC2119, section 3;@-RFC8174] and (#intro) (!!item, sub).\n",
+			"See [@!RFC2119, section 3;@-RFC8174] and (#intro) (!!item, sub).\n"},
+		{"A> An aside.\n", "A> An aside.\n"},
+		{"{#fig-1 style=\"x\"}\n```go\nx := 1 //<<1>>\n```\nFigure: Code.\n",
+			"{#fig-1 style=\"x\"}\n~~~ go\nx := 1 //<<1>>\n~~~\nFigure: Code.\n"},
+		{"Name | Age\n--|--:\nBob | 27\nTable: People {#people}\n",
+			"Name | Age\n-----|---:\nBob  | 27\nTable: People {#people}\n"},
+		{"Name | Age\n--|--:\nBob | 27\n\nTable: People\n", "Name | Age\n-----|---:\nBob  | 27\n\nTable\\: People\n"},
+		{"$$ x = y $$\n", "$$ x = y $$\n"},
+		{"$$\nx = y\n$$\n", "$$\nx = y\n$$\n"},
+		{"| a |\n|---|\n| b |\n", "| a   |\n|-----|\n| b   |\n"},
+		{"| Name |\n|:-:|\n| Bob |\n", "| Name |\n|:----:|\n| Bob  |\n"},
+		{"Note[^1].\n\n[^1]: The note.\n", "Note[^1].\n\n[^1]: The note.\n"},
+		{"Some \\*stars\\* and 1\\. a [link](https://example.org).\n",
+			"Some \\*stars\\* and 1. a [link](https://example.org).\n"},
+		{"{{include.md}}\n", "{{include.md}}\n"},
+		{"{{includes}}[1,1]\n\n<{{main.go}}[region=handler;dedent]\n", "{{includes}}[1,1]\n\n<{{main.go}}[region=handler;dedent]\n"},
+		{"{{a.md}}[/x/,/y/]\n{{b.md}}[3,]\n", "{{a.md}}[/x/,/y/]\n{{b.md}}[3,]\n"},
+		{"<{{b.go}}[prefix=\"S: \"]\n", "<{{b.go}}[prefix=\"S: \"]\n"},
+	}
+	for i, tc := range tests {
+		got := string(format([]byte(tc.input)))
+		if got != tc.want {
+			t.Errorf("test %d: expected\n%q, got\n%q", i, tc.want, got)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	// "#" can't start a line.
+	got := wrap("aaa bbb # ccc", 8)
+	want := []string{"aaa bbb #", "ccc"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+// TestRoundTrip formats each document in testdata and rfc and checks the formatted document renders to the
+// same XML as the original and that formatting it again doesn't change it. The documents in the docs map are
+// checked too, these hold constructs the files don't have.
+func TestRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../../testdata/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfcs, err := filepath.Glob("../../rfc/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := map[string][]byte{
+		"one column table": []byte("| a |\n|---|\n| b |\n"),
+	}
+	for _, f := range append(files, rfcs...) {
+		input, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs[f] = input
+	}
+	for f, input := range docs {
+		formatted := format(input)
+		if want, got := toXML(input), toXML(formatted); got != want {
+			t.Errorf("%s: formatted document renders differently, expected\n%s\ngot\n%s\nformatted:\n%s", f, want, got, formatted)
+			continue
+		}
+		if again := format(formatted); string(again) != string(formatted) {
+			t.Errorf("%s: formatting isn't stable, expected\n%s\ngot\n%s", f, formatted, again)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package markdown
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// row is a rendered table row.
+type row struct {
+	cells []string
+	spans []int
+}
+
+// table outputs the table with its columns aligned. The rows of the header, body and footer are
+// separated by a line of dashes (header) or equal signs (footer).
+func (r *Renderer) table(w io.Writer, tab *ast.Table) {
+	r.blockStart(w, tab)
+
+	var header, body, footer []row
+	var aligns []ast.CellAlignFlags
+	r.inTable = true
+	for _, section := range tab.GetChildren() {
+		for _, tr := range section.GetChildren() {
+			rw := row{}
+			for _, c := range tr.GetChildren() {
+				cell, ok := c.(*ast.TableCell)
+				if !ok {
+					continue
+				}
+				span := cell.ColSpan
+				if span < 1 {
+					span = 1
+				}
+				rw.cells = append(rw.cells, r.inlineString(cell))
+				rw.spans = append(rw.spans, span)
+				if len(rw.cells) > len(aligns) {
+					aligns = append(aligns, cell.Align)
+				}
+			}
+			switch section.(type) {
+			case *ast.TableHeader:
+				header = append(header, rw)
+			case *ast.TableFooter:
+				footer = append(footer, rw)
+			default:
+				body = append(body, rw)
+			}
+		}
+	}
+	r.inTable = false
+
+	// Column widths, cells spanning multiple columns are not taken into account.
+	widths := make([]int, len(aligns))
+	for _, rows := range [][]row{header, body, footer} {
+		for _, rw := range rows {
+			col := 0
+			for i, cell := range rw.cells {
+				if col < len(widths) && rw.spans[i] == 1 {
+					if n := utf8.RuneCountInString(cell); n > widths[col] {
+						widths[col] = n
+					}
+				}
+				col += rw.spans[i]
+			}
+		}
+	}
+	for i := range widths {
+		if widths[i] < 3 {
+			widths[i] = 3
+		}
+	}
+
+	for _, rw := range header {
+		r.line(w, tableRow(rw, widths))
+	}
+	r.line(w, tableSeparator("-", aligns, widths))
+	for _, rw := range body {
+		r.line(w, tableRow(rw, widths))
+	}
+	if len(footer) > 0 {
+		r.line(w, tableSeparator("=", nil, widths))
+		for _, rw := range footer {
+			r.line(w, tableRow(rw, widths))
+		}
+	}
+}
+
+// tableRow returns the row with each cell padded to the width of its column. A cell spanning multiple
+// columns is followed by as many pipe symbols. The row of a table with a single column starts and ends with a
+// pipe symbol, without these the table would be parsed as a setext heading.
+func tableRow(rw row, widths []int) string {
+	if len(widths) == 1 {
+		cell := strings.Join(rw.cells, "")
+		return "| " + cell + strings.Repeat(" ", max(0, widths[0]-utf8.RuneCountInString(cell))) + " |"
+	}
+	s := ""
+	col := 0
+	for i, cell := range rw.cells {
+		span := rw.spans[i]
+		last := i == len(rw.cells)-1
+		if !last || span > 1 {
+			width := 0
+			if col < len(widths) {
+				width = widths[col]
+			}
+			cell += strings.Repeat(" ", max(0, width-utf8.RuneCountInString(cell)))
+		}
+		s += cell
+		switch {
+		case span > 1:
+			s += " " + strings.Repeat("|", span)
+			if !last {
+				s += " "
+			}
+		case !last:
+			s += " | "
+		}
+		col += span
+	}
+	return strings.TrimRight(s, " ")
+}
+
+// tableSeparator returns the line of c's below the header or above the footer. Each column's part of
+// the line is as wide as the column including the spaces around the pipe symbols, so the alignment
+// colons touch the pipes. The line of a table with a single column starts and ends with a pipe symbol.
+func tableSeparator(c string, aligns []ast.CellAlignFlags, widths []int) string {
+	cols := make([]string, len(widths))
+	for i, width := range widths {
+		if i > 0 || len(widths) == 1 {
+			width++
+		}
+		if i < len(widths)-1 || len(widths) == 1 {
+			width++
+		}
+		sep := strings.Repeat(c, width)
+		if i < len(aligns) {
+			switch aligns[i] {
+			case ast.TableAlignmentLeft:
+				sep = ":" + sep[1:]
+			case ast.TableAlignmentRight:
+				sep = sep[1:] + ":"
+			case ast.TableAlignmentCenter:
+				sep = ":" + sep[2:] + ":"
+			}
+		}
+		cols[i] = sep
+	}
+	if len(widths) == 1 {
+		return "|" + cols[0] + "|"
+	}
+	return strings.Join(cols, "|")
+}
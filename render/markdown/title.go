@@ -0,0 +1,93 @@
+package markdown
+
+import (
+	"bytes"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// encodeTitle returns the TOML encoding of title. This is only used when the title block's source isn't
+// available. Empty values are left out and keys are written as they are in the mmark documentation, i.e.
+// "seriesInfo" instead of "SeriesInfo".
+func encodeTitle(title *mast.TitleData) []byte {
+	if title == nil {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	if err := toml.NewEncoder(buf).Encode(title); err != nil {
+		return nil
+	}
+	m := map[string]interface{}{}
+	if _, err := toml.Decode(buf.String(), &m); err != nil {
+		return nil
+	}
+	buf.Reset()
+	enc := toml.NewEncoder(buf)
+	enc.Indent = ""
+	if err := enc.Encode(prune(m)); err != nil {
+		return nil
+	}
+	return bytes.TrimSpace(buf.Bytes())
+}
+
+// prune removes the zero values from v and lowercases the first letter of each key. Booleans are kept,
+// because not all of them default to false.
+func prune(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, val := range v {
+			if val = prune(val); val != nil {
+				m[lowerFirst(k)] = val
+			}
+		}
+		if len(m) == 0 {
+			return nil
+		}
+		return m
+	case []map[string]interface{}:
+		s := []map[string]interface{}{}
+		for _, val := range v {
+			if m, ok := prune(val).(map[string]interface{}); ok {
+				s = append(s, m)
+			}
+		}
+		if len(s) == 0 {
+			return nil
+		}
+		return s
+	case []interface{}:
+		s := []interface{}{}
+		for _, val := range v {
+			if val = prune(val); val != nil {
+				s = append(s, val)
+			}
+		}
+		if len(s) == 0 {
+			return nil
+		}
+		return s
+	case string:
+		if v == "" {
+			return nil
+		}
+	case int64:
+		if v == 0 {
+			return nil
+		}
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+	}
+	return v
+}
+
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
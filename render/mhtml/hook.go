@@ -2,21 +2,38 @@ package mhtml
 
 import (
 	"fmt"
+	"html"
 	"io"
+	"strings"
 
 	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
 	"github.com/mmarkdown/mmark/v2/lang"
 	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+	"github.com/mmarkdown/mmark/v2/render/cite"
+	"github.com/mmarkdown/mmark/v2/render/number"
 )
 
-var (
-	// IndexReturnLinkContents is the string to use for index item return links.
-	IndexReturnLinkContents = "<sup>[go]</sup>"
-)
+// IndexReturnLinkContents is the string to use for index item return links.
+//
+// Deprecated: set RendererOptions.IndexReturnLinkContents, changing this variable isn't safe when documents are
+// rendered concurrently.
+var IndexReturnLinkContents = "<sup>[go]</sup>"
 
 // RenderOptions are options for RenderHook.
 type RendererOptions struct {
 	Language lang.Lang
+
+	// Style is the citation style used to format the bibliography, defaults to cite.Default.
+	Style cite.Style
+
+	// IndexReturnLinkContents is the HTML used for index item return links, defaults to IndexReturnLinkContents.
+	IndexReturnLinkContents string
+
+	// Numbers holds the numbers of the sections, figures and tables. If set, headings and captions are
+	// numbered and cross references get their text, i.e. "Section 1.2".
+	Numbers *number.Numbers
 }
 
 // RenderHook is used to render mmark specific AST nodes.
@@ -31,7 +48,7 @@ func (r RendererOptions) RenderHook(w io.Writer, node ast.Node, entering bool) (
 		io.WriteString(w, r.Language.Footnotes())
 	case *mast.Bibliography, *mast.BibliographyWrapper:
 		if !entering {
-			io.WriteString(w, "</dl>\n")
+			io.WriteString(w, "</dl>\n</div>\n")
 			return ast.GoToNext, true
 		}
 		io.WriteString(w, "<h1 id=\"bibliography-section\">"+r.Language.Bibliography()+"</h1>\n<div class=\"bibliography\">\n")
@@ -41,10 +58,51 @@ func (r RendererOptions) RenderHook(w io.Writer, node ast.Node, entering bool) (
 		if !entering {
 			return ast.GoToNext, true
 		}
-		bibliographyItem(w, node, entering)
+		r.bibliographyItem(w, node)
+		return ast.GoToNext, true
+	case *ast.Heading:
+		if entering && r.Numbers != nil && r.Numbers.Heading[node] != "" {
+			r.heading(w, node)
+			return ast.GoToNext, true
+		}
+	case *ast.Caption:
+		if figure, ok := node.Parent.(*ast.CaptionFigure); ok && entering && r.Numbers != nil && r.Numbers.Figure[figure] != "" {
+			io.WriteString(w, `<figcaption><span class="figure-number">`+escapeText.Replace(r.Numbers.Figure[figure])+":</span> ")
+			return ast.GoToNext, true
+		}
+	case *ast.CrossReference:
+		if r.Numbers == nil {
+			break
+		}
+		text, ok := r.Numbers.CrossReference(node)
+		if !ok {
+			break
+		}
+		if entering {
+			io.WriteString(w, `<a class="xref" href="#`+html.EscapeString(string(node.Destination))+`">`+escapeText.Replace(text))
+			return ast.GoToNext, true
+		}
+		io.WriteString(w, "</a>")
 		return ast.GoToNext, true
 	case *mast.Title:
-		// we out if in mmark.go with a hack to capture it.
+		if entering {
+			r.title(w, node)
+		}
+		return ast.GoToNext, true
+	case *mast.Authors:
+		if entering {
+			r.authors(w, documentTitle(node))
+		}
+		return ast.GoToNext, true
+	case *mast.TableOfContents:
+		if !entering {
+			io.WriteString(w, "</ul>\n</nav>\n")
+			return ast.GoToNext, true
+		}
+		io.WriteString(w, "<nav class=\"toc\">\n<h1 id=\"toc-section\">"+r.Language.Contents()+"</h1>\n<ul>\n")
+		return ast.GoToNext, true
+	case *mast.TableOfContentsEntry:
+		r.tableOfContentsEntry(w, node, entering)
 		return ast.GoToNext, true
 	case *mast.DocumentIndex:
 		if !entering {
@@ -96,7 +154,11 @@ func (r RendererOptions) RenderHook(w io.Writer, node ast.Node, entering bool) (
 			return ast.GoToNext, true
 		}
 		io.WriteString(w, ` <a class="index-return" href="#`+string(node.Destination)+`">`)
-		io.WriteString(w, IndexReturnLinkContents)
+		if r.IndexReturnLinkContents == "" {
+			io.WriteString(w, IndexReturnLinkContents)
+		} else {
+			io.WriteString(w, r.IndexReturnLinkContents)
+		}
 		return ast.GoToNext, true
 	case *mast.ReferenceBlock:
 		// ignore these for HTML output as this is XML and not used at all.
@@ -105,25 +167,104 @@ func (r RendererOptions) RenderHook(w io.Writer, node ast.Node, entering bool) (
 	return ast.GoToNext, false
 }
 
-func bibliographyItem(w io.Writer, bib *mast.BibliographyItem, _ bool) {
+// heading writes the start of a numbered heading, the number is put before the heading's text.
+func (r RendererOptions) heading(w io.Writer, node *ast.Heading) {
+	attrs := mdhtml.BlockAttrs(node)
+	if id := mast.Attribute(node, "id"); len(id) == 0 && node.HeadingID != "" {
+		attrs = append([]string{`id="` + node.HeadingID + `"`}, attrs...)
+	}
+	io.WriteString(w, mdhtml.HeadingOpenTagFromLevel(node.Level))
+	for _, a := range attrs {
+		io.WriteString(w, " "+a)
+	}
+	io.WriteString(w, `><span class="section-number">`+escapeText.Replace(r.Numbers.Heading[node])+"</span> ")
+}
+
+// tableOfContentsEntry writes a link to the section of entry, the entries of its subsections are put in a
+// nested list.
+func (r RendererOptions) tableOfContentsEntry(w io.Writer, entry *mast.TableOfContentsEntry, entering bool) {
+	children := len(entry.GetChildren()) > 0
+	if !entering {
+		if children {
+			io.WriteString(w, "</ul>\n")
+		}
+		io.WriteString(w, "</li>\n")
+		return
+	}
+	io.WriteString(w, "<li")
+	if entry.Unnumbered {
+		io.WriteString(w, ` class="unnumbered"`)
+	}
+	io.WriteString(w, `><a href="#`+html.EscapeString(string(entry.Destination))+`">`)
+	if r.Numbers != nil {
+		if n := r.Numbers.Section(string(entry.Destination)); n != "" {
+			io.WriteString(w, `<span class="section-number">`+escapeText.Replace(n)+"</span> ")
+		}
+	}
+	io.WriteString(w, escapeText.Replace(string(entry.Literal))+"</a>")
+	if children {
+		io.WriteString(w, "\n<ul>\n")
+		return
+	}
+	io.WriteString(w, "\n")
+}
+
+func (r RendererOptions) bibliographyItem(w io.Writer, bib *mast.BibliographyItem) {
 	io.WriteString(w, `<dt class="bibliography-cite" id="`+string(bib.Anchor)+`">`+fmt.Sprintf("[%s]", bib.Anchor)+"</dt>\n")
 	io.WriteString(w, `<dd>`)
 	defer io.WriteString(w, "</dd>\n")
-	if bib.Reference == nil {
+	if bib.Reference != nil {
+		r.reference(w, bib.Reference)
 		return
 	}
-	for _, author := range bib.Reference.Front.Authors {
-		io.WriteString(w, `<span class="bibliography-author">`+author.Fullname+"</span>\n")
+	if group := bib.ReferenceGroup; group != nil {
+		io.WriteString(w, "\n<ul class=\"bibliography-group\">\n")
+		for i := range group.References {
+			io.WriteString(w, "<li>")
+			r.reference(w, &group.References[i])
+			io.WriteString(w, "</li>\n")
+		}
+		io.WriteString(w, "</ul>\n")
+		if group.Target != "" {
+			io.WriteString(w, `<a class="bibliography-target" href="`+html.EscapeString(group.Target)+`">`+escapeText.Replace(group.Target)+"</a>\n")
+		}
 	}
-	io.WriteString(w, `<span class="bibliography-title">`+bib.Reference.Front.Title+"</span>\n")
-	if bib.Reference.Target != "" {
-		io.WriteString(w, `<a class="bliography-target" href="`+bib.Reference.Target+"\">"+bib.Reference.Target+"</a>\n")
+}
+
+// reference writes ref formatted according to r.Style.
+func (r RendererOptions) reference(w io.Writer, ref *reference.Reference) {
+	style := r.Style
+	if style == nil {
+		style = cite.Default
 	}
-	if bib.Reference.Front.Date != nil && bib.Reference.Front.Date.Year != "" {
-		io.WriteString(w, `<date class="bibliography-date">`+bib.Reference.Front.Date.Year+"</date>\n")
+	for _, f := range style.Format(ref, r.Language) {
+		text := escapeText.Replace(f.Text)
+		switch f.Kind {
+		case cite.Text:
+			io.WriteString(w, text)
+		case cite.Target:
+			io.WriteString(w, `<a class="bibliography-target" href="`+html.EscapeString(f.Text)+`">`+text+"</a>")
+		case cite.Title:
+			io.WriteString(w, `<cite class="bibliography-title">`+text+"</cite>")
+		case cite.Date:
+			io.WriteString(w, `<time class="bibliography-date">`+text+"</time>")
+		default:
+			io.WriteString(w, `<span class="bibliography-`+bibliographyClass[f.Kind]+`">`+text+"</span>")
+		}
 	}
 }
 
+var escapeText = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// bibliographyClass holds the class name suffixes for the fields of a formatted reference.
+var bibliographyClass = map[cite.Kind]string{
+	cite.Author:     "author",
+	cite.EtAl:       "etal",
+	cite.Series:     "series",
+	cite.Content:    "content",
+	cite.Annotation: "annotation",
+}
+
 func firstSubItem(node ast.Node) bool {
 	prev := ast.GetPrevNode(node)
 	if prev == nil {
@@ -0,0 +1,158 @@
+package mhtml
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/render/number"
+)
+
+// Page is a page of a document that is split in pages.
+type Page struct {
+	Name  string     // file name of the page, i.e. "index.html" or "introduction.html"
+	Title string     // title of the page, i.e. "1. Introduction"
+	Nodes []ast.Node // the nodes on the page
+	Up    int        // index of the page this page is part of, -1 for the first page
+
+	level int // level of the section the page starts with
+}
+
+// Split splits doc in pages. The first page, "index.html", has everything before the first section: the title
+// block, the abstract, notes and the table of contents. The sections of level and lower each start a page,
+// as do the references, the index, the footnotes and the authors' addresses. The pages of a section are named
+// after the ID of its heading. Sections in the front matter aren't split.
+func (r RendererOptions) Split(doc ast.Node, level int) []*Page {
+	if level <= 0 {
+		level = 1
+	}
+	title := r.Language.Contents()
+	if t := documentTitle(doc); t != nil && t.TitleData != nil && t.Title != "" {
+		title = t.Title
+	}
+	pages := []*Page{{Name: "index.html", Title: title, Up: -1}}
+	names := map[string]bool{"index.html": true}
+	page := pages[0]
+
+	start := func(id, title string, level int) {
+		name := pageName(id)
+		base := strings.TrimSuffix(name, ".html")
+		for n := 1; names[name]; n++ {
+			name = base + "-" + strconv.Itoa(n) + ".html"
+		}
+		names[name] = true
+
+		up := len(pages) - 1
+		for up > 0 && pages[up].level >= level {
+			up = pages[up].Up
+		}
+		page = &Page{Name: name, Title: title, Up: up, level: level}
+		pages = append(pages, page)
+	}
+
+	matter := ast.DocumentMatterNone
+	var add func(node ast.Node)
+	add = func(node ast.Node) {
+		switch node := node.(type) {
+		case *ast.DocumentMatter:
+			matter = node.Matter
+			// the bibliography is added to the back matter.
+			for _, c := range node.GetChildren() {
+				add(c)
+			}
+			return
+		case *ast.Heading:
+			if node.IsSpecial || node.IsTitleblock || matter == ast.DocumentMatterFront || node.Level > level {
+				break
+			}
+			id, title := number.ID(node), mast.PlainText(node)
+			if r.Numbers != nil {
+				if n := r.Numbers.Section(id); n != "" {
+					title = n + " " + title
+				}
+			}
+			start(id, title, node.Level)
+		case *mast.Bibliography, *mast.BibliographyWrapper:
+			start("bibliography-section", r.Language.Bibliography(), 1)
+		case *mast.DocumentIndex:
+			start("index-section", r.Language.Index(), 1)
+		case *ast.Footnotes:
+			start("footnote-section", r.Language.Footnotes(), 1)
+		case *mast.Authors:
+			if t := documentTitle(node); t == nil || t.TitleData == nil || len(t.Author) == 0 {
+				return
+			}
+			start("authors-addresses", r.Language.Addresses(), 1)
+		}
+		page.Nodes = append(page.Nodes, node)
+	}
+	for _, c := range doc.GetChildren() {
+		add(c)
+	}
+	return pages
+}
+
+// pageName returns the file name for a page that starts with the element with ID id.
+func pageName(id string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, id)
+	if strings.Trim(name, "-") == "" {
+		name = "section"
+	}
+	return name + ".html"
+}
+
+// Navigation writes the links to the previous, next and up pages of page i.
+func Navigation(w io.Writer, pages []*Page, i int) {
+	link := func(rel string, p *Page) {
+		io.WriteString(w, `<a rel="`+rel+`" href="`+p.Name+`">`+escapeText.Replace(p.Title)+"</a>\n")
+	}
+	io.WriteString(w, "<nav class=\"page-navigation\">\n")
+	if i > 0 {
+		link("prev", pages[i-1])
+	}
+	if up := pages[i].Up; up >= 0 {
+		link("up", pages[up])
+	}
+	if i < len(pages)-1 {
+		link("next", pages[i+1])
+	}
+	io.WriteString(w, "</nav>\n")
+}
+
+var (
+	reID   = regexp.MustCompile(`\sid="([^"]+)"`)
+	reHref = regexp.MustCompile(`\shref="#([^"]+)"`)
+)
+
+// Relink rewrites the links in the rendered pages, a link to an element on another page gets that page's
+// name. The pages' HTML is in html, in the same order as pages.
+func Relink(pages []*Page, html [][]byte) {
+	where := map[string]string{}
+	for i, h := range html {
+		for _, m := range reID.FindAllSubmatch(h, -1) {
+			if _, ok := where[string(m[1])]; !ok {
+				where[string(m[1])] = pages[i].Name
+			}
+		}
+	}
+	for i, h := range html {
+		html[i] = reHref.ReplaceAllFunc(h, func(href []byte) []byte {
+			id := reHref.FindSubmatch(href)[1]
+			name, ok := where[string(id)]
+			if !ok || name == pages[i].Name {
+				return href
+			}
+			return bytes.Replace(href, []byte(`"#`), []byte(`"`+name+"#"), 1)
+		})
+	}
+}
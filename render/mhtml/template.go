@@ -0,0 +1,98 @@
+package mhtml
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"io"
+
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// TemplateData is the data a page template is executed with. The HTML fragments are rendered separately, so a
+// template can put them where it wants.
+type TemplateData struct {
+	Title    string          // title of the page
+	Language string          // language of the document
+	Meta     *mast.TitleData // the title block, nil if there isn't one
+	CSS      string          // link to a CSS stylesheet
+	Head     template.HTML   // <meta> tags and the HTML to be included in head
+
+	Header       template.HTML // the document header: the title block's information and the title
+	Contents     template.HTML // the table of contents
+	Body         template.HTML // everything before the back matter that isn't in one of the other fragments
+	Bibliography template.HTML // the references
+	BackMatter   template.HTML // everything from the back matter on that isn't in one of the other fragments
+	Index        template.HTML // the index
+	Authors      template.HTML // the authors' addresses
+	Navigation   template.HTML // links to the previous, next and up pages, only set when split in pages
+}
+
+//go:embed default.html
+var defaultTemplate string
+
+// DefaultTemplate is the built-in page template, it resembles the HTML format of RFCs.
+var DefaultTemplate = template.Must(template.New("default").Parse(defaultTemplate))
+
+// Fragments renders nodes, which belong to doc, with renderer and sets the HTML fragments in data. The title
+// block, the table of contents, the references, the index and the authors' addresses are rendered in their own
+// fragment, everything else is rendered in the body, or in the back matter once the {backmatter} is seen. This
+// way a template can put the references before the appendices. The renderer must not create complete pages.
+func Fragments(renderer *mdhtml.Renderer, doc ast.Node, nodes []ast.Node, data *TemplateData) {
+	var header, contents, body, backMatter, bibliography, index, authors bytes.Buffer
+	rest := &body // where the nodes that aren't in a fragment go.
+	fragment := func(node ast.Node) io.Writer {
+		switch node.(type) {
+		case *mast.Title:
+			return &header
+		case *mast.TableOfContents:
+			return &contents
+		case *mast.Bibliography, *mast.BibliographyWrapper:
+			return &bibliography
+		case *mast.DocumentIndex:
+			return &index
+		case *mast.Authors:
+			return &authors
+		}
+		return nil
+	}
+
+	renderer.RenderHeader(&body, doc)
+	for _, n := range nodes {
+		ast.WalkFunc(n, func(node ast.Node, entering bool) ast.WalkStatus {
+			if m, ok := node.(*ast.DocumentMatter); ok && m.Matter == ast.DocumentMatterBack && entering {
+				// this closes the main matter's section in the body and opens the back matter's section.
+				buf := &bytes.Buffer{}
+				renderer.RenderNode(buf, node, entering)
+				end := []byte("</section>\n")
+				if bytes.HasPrefix(buf.Bytes(), end) {
+					body.Write(buf.Next(len(end)))
+				}
+				rest = &backMatter
+				rest.Write(buf.Bytes())
+				return ast.GoToNext
+			}
+			w := fragment(node)
+			if w == nil {
+				return renderer.RenderNode(rest, node, entering)
+			}
+			if entering {
+				ast.WalkFunc(node, func(node ast.Node, entering bool) ast.WalkStatus {
+					return renderer.RenderNode(w, node, entering)
+				})
+			}
+			return ast.SkipChildren
+		})
+	}
+	renderer.RenderFooter(rest, doc)
+
+	data.Header = template.HTML(header.String())
+	data.Contents = template.HTML(contents.String())
+	data.Body = template.HTML(body.String())
+	data.Bibliography = template.HTML(bibliography.String())
+	data.BackMatter = template.HTML(backMatter.String())
+	data.Index = template.HTML(index.String())
+	data.Authors = template.HTML(authors.String())
+}
@@ -0,0 +1,205 @@
+package mhtml
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/render/xml"
+)
+
+// title writes the document header: a list with the workgroup, series info, status, dates, authors and
+// keywords, followed by the title.
+func (r RendererOptions) title(w io.Writer, t *mast.Title) {
+	if t.TitleData == nil {
+		return
+	}
+	io.WriteString(w, "<header class=\"document-header\">\n<dl class=\"document-info\">\n")
+	info := func(class, label, value string) {
+		if value == "" {
+			return
+		}
+		io.WriteString(w, `<dt class="label-`+class+`">`+escapeText.Replace(label)+":</dt>\n")
+		io.WriteString(w, `<dd class="`+class+`">`+value+"</dd>\n")
+	}
+
+	info("workgroup", "Workgroup", escapeText.Replace(t.Workgroup))
+	if t.Draft() {
+		info("internet-draft", "Internet-Draft", escapeText.Replace(t.SeriesInfo.Value))
+	} else {
+		info("rfc", "Request for Comments", escapeText.Replace(t.SeriesInfo.Value))
+	}
+	info("obsoletes", "Obsoletes", rfcLinks(t.Obsoletes))
+	info("updates", "Updates", rfcLinks(t.Updates))
+	if !t.Date.IsZero() {
+		info("published", "Published", `<time datetime="`+t.Date.Format("2006-01-02")+`">`+t.DateString(true)+"</time>")
+	}
+	if t.Draft() {
+		info("status", "Intended Status", t.StatusName())
+		if !t.Date.IsZero() {
+			expires := t.Date.Add(185 * 24 * time.Hour)
+			info("expires", "Expires", `<time datetime="`+expires.Format("2006-01-02")+`">`+expires.Format("2 January 2006")+"</time>")
+		}
+	} else {
+		info("status", "Category", t.StatusName())
+	}
+
+	authors := &bytes.Buffer{}
+	for _, a := range t.Author {
+		authors.WriteString("<div class=\"author\">\n")
+		authors.WriteString(`<div class="author-name">` + escapeText.Replace(shortName(a)) + "</div>\n")
+		if org := a.Organization; org != "" {
+			authors.WriteString(`<div class="org">` + escapeText.Replace(org) + "</div>\n")
+		}
+		authors.WriteString("</div>\n")
+	}
+	info("authors", r.Language.Authors(), authors.String())
+
+	keywords := []string{}
+	for _, k := range t.Keyword {
+		if k != "" {
+			keywords = append(keywords, escapeText.Replace(k))
+		}
+	}
+	info("keywords", "Keywords", strings.Join(keywords, ", "))
+	io.WriteString(w, "</dl>\n")
+
+	io.WriteString(w, `<h1 id="title">`+escapeText.Replace(t.Title)+"</h1>\n")
+	io.WriteString(w, "</header>\n")
+}
+
+// shortName returns the name of the author as shown in the header: "J. Doe".
+func shortName(a mast.Author) string {
+	if a.Surname == "" {
+		return a.Fullname
+	}
+	if a.Initials == "" {
+		return a.Surname
+	}
+	return a.Initials + " " + a.Surname
+}
+
+// rfcLinks returns links to the RFCs in numbers.
+func rfcLinks(numbers []int) string {
+	links := make([]string, len(numbers))
+	for i, n := range numbers {
+		links[i] = `<a href="https://www.rfc-editor.org/info/rfc` + strconv.Itoa(n) + `">` + strconv.Itoa(n) + "</a>"
+	}
+	return strings.Join(links, ", ")
+}
+
+// authors writes the authors' addresses section.
+func (r RendererOptions) authors(w io.Writer, t *mast.Title) {
+	if t == nil || t.TitleData == nil || len(t.Author) == 0 {
+		return
+	}
+	io.WriteString(w, "<section class=\"authors\">\n")
+	io.WriteString(w, `<h1 id="authors-addresses">`+escapeText.Replace(r.Language.Addresses())+"</h1>\n")
+	for _, a := range t.Author {
+		io.WriteString(w, "<address class=\"vcard\">\n")
+		line := func(class, text string) {
+			if text != "" {
+				io.WriteString(w, `<div class="`+class+`">`+escapeText.Replace(text)+"</div>\n")
+			}
+		}
+		line("fn", a.Fullname)
+		line("org", a.Organization)
+		p := a.Address.Postal
+		for _, s := range append([]string{p.Street}, p.Streets...) {
+			line("street-address", s)
+		}
+		for _, s := range p.PostalLine {
+			line("postal-line", s)
+		}
+		line("locality", strings.Join(nonEmpty(p.City, p.Region, p.Code), " "))
+		for _, c := range append([]string{p.Country}, p.Countries...) {
+			line("country-name", c)
+		}
+		if a.Address.Phone != "" {
+			io.WriteString(w, `<div class="tel">Phone: <a href="tel:`+html.EscapeString(a.Address.Phone)+`">`+escapeText.Replace(a.Address.Phone)+"</a></div>\n")
+		}
+		for _, e := range append([]string{a.Address.Email}, a.Address.Emails...) {
+			if e != "" {
+				io.WriteString(w, `<div class="email">Email: <a href="mailto:`+html.EscapeString(e)+`">`+escapeText.Replace(e)+"</a></div>\n")
+			}
+		}
+		if a.Address.URI != "" {
+			io.WriteString(w, `<div class="url">URI: <a href="`+html.EscapeString(a.Address.URI)+`">`+escapeText.Replace(a.Address.URI)+"</a></div>\n")
+		}
+		io.WriteString(w, "</address>\n")
+	}
+	io.WriteString(w, "</section>\n")
+}
+
+// nonEmpty returns the strings in s that aren't empty.
+func nonEmpty(s ...string) []string {
+	ne := []string{}
+	for _, x := range s {
+		if x != "" {
+			ne = append(ne, x)
+		}
+	}
+	return ne
+}
+
+// documentTitle returns the title block of the document node is in, or nil if there isn't one.
+func documentTitle(node ast.Node) *mast.Title {
+	for node.GetParent() != nil {
+		node = node.GetParent()
+	}
+	for _, c := range node.GetChildren() {
+		if t, ok := c.(*mast.Title); ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// Meta returns the <meta> tags for the document's title block: the authors, keywords, date and the
+// abstract as the description. It returns nil if there is no title block.
+func Meta(doc ast.Node) []byte {
+	t := documentTitle(doc)
+	if t == nil || t.TitleData == nil {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	meta := func(name, content string) {
+		if content != "" {
+			buf.WriteString(`  <meta name="` + name + `" content="` + html.EscapeString(content) + "\">\n")
+		}
+	}
+	for _, a := range t.Author {
+		meta("author", a.Fullname)
+	}
+	meta("keywords", strings.Join(nonEmpty(t.Keyword...), ", "))
+	if !t.Date.IsZero() {
+		meta("date", t.Date.Format("2006-01-02"))
+	}
+	meta("description", abstract(doc))
+	return buf.Bytes()
+}
+
+// abstract returns the text of the abstract's first paragraph.
+func abstract(doc ast.Node) string {
+	children := doc.GetChildren()
+	for i, c := range children {
+		h, ok := c.(*ast.Heading)
+		if !ok || !h.IsSpecial || !xml.IsAbstract(h.Literal) {
+			continue
+		}
+		for _, p := range children[i+1:] {
+			if _, ok := p.(*ast.Heading); ok {
+				return ""
+			}
+			if para, ok := p.(*ast.Paragraph); ok {
+				return mast.PlainText(para)
+			}
+		}
+	}
+	return ""
+}
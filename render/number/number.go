@@ -0,0 +1,225 @@
+// Package number numbers the sections, figures and tables of a document like xml2rfc does. The numbers are
+// used in headings and captions, and for the text of cross references.
+package number
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// Target is something that can be cross referenced.
+type Target struct {
+	Kind   string // i.e. "Section", "Appendix" or "Figure"
+	Number string // "1.2", "A" or "3"
+	Title  string
+}
+
+func (t Target) String() string {
+	if t.Number == "" {
+		return `"` + t.Title + `"`
+	}
+	return t.Kind + " " + t.Number
+}
+
+// Numbers holds the numbers of the sections, figures and tables in a document.
+type Numbers struct {
+	Heading      map[*ast.Heading]string       // "1.2.", "Appendix A." or "" for an unnumbered section
+	Figure       map[*ast.CaptionFigure]string // "Figure 3" or "Table 1"
+	Bibliography map[ast.Node]string           // the number of the references sections
+	Targets      map[string]Target             // keyed by ID
+
+	sections map[string]string // the heading numbers keyed by ID
+
+	language lang.Lang
+}
+
+// New numbers the sections, figures and tables in doc like xml2rfc does: the main matter's sections are
+// numbered 1, 1.1, etc., followed by the references, and the back matter's sections are the appendices A, A.1,
+// etc. The abstract, notes, the front matter's sections and sections with the attribute numbered="false" or
+// the class "unnumbered" (and their subsections) aren't numbered. The names of the targets are taken from l.
+func New(doc ast.Node, l lang.Lang) *Numbers {
+	n := &Numbers{
+		Heading:      map[*ast.Heading]string{},
+		Figure:       map[*ast.CaptionFigure]string{},
+		Bibliography: map[ast.Node]string{},
+		Targets:      map[string]Target{},
+		sections:     map[string]string{},
+		language:     l,
+	}
+	var (
+		section  = upperFirst(l.Section())
+		appendix = upperFirst(l.Appendix())
+		figure   = upperFirst(l.Figure())
+		table    = upperFirst(l.Table())
+	)
+
+	var (
+		main       []int
+		appendices []int
+		matter     = ast.DocumentMatterNone
+		unnumbered = 0 // level of the unnumbered section we are in
+		figures    = 0
+		tables     = 0
+	)
+	// count increments the counter for level and returns the section number.
+	count := func(counters *[]int, level int) []int {
+		for len(*counters) < level {
+			*counters = append(*counters, 0)
+		}
+		*counters = (*counters)[:level]
+		(*counters)[level-1]++
+		return *counters
+	}
+
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch node := node.(type) {
+		case *ast.DocumentMatter:
+			matter = node.Matter
+		case *mast.BibliographyWrapper:
+			n.Bibliography[node] = join(count(&main, 1)) + "."
+		case *mast.Bibliography:
+			if wrapper, ok := node.Parent.(*mast.BibliographyWrapper); ok {
+				n.Bibliography[node] = n.Bibliography[wrapper] + strconv.Itoa(mast.ChildIndex(node)+1) + "."
+				break
+			}
+			n.Bibliography[node] = join(count(&main, 1)) + "."
+		case *ast.Heading:
+			id, title := ID(node), mast.PlainText(node)
+			if unnumbered > 0 && node.Level > unnumbered {
+				n.target(id, Target{Kind: section, Title: title})
+				break
+			}
+			unnumbered = 0
+			if node.IsSpecial || matter == ast.DocumentMatterFront || Unnumbered(node) {
+				unnumbered = node.Level
+				n.target(id, Target{Kind: section, Title: title})
+				break
+			}
+			if matter == ast.DocumentMatterBack {
+				counters := count(&appendices, node.Level)
+				number := letters(counters[0])
+				if len(counters) > 1 {
+					number += "." + join(counters[1:])
+				}
+				n.target(id, Target{Kind: appendix, Number: number, Title: title})
+				if node.Level == 1 {
+					n.heading(node, id, appendix+" "+number+".")
+					break
+				}
+				n.heading(node, id, number+".")
+				break
+			}
+			number := join(count(&main, node.Level))
+			n.target(id, Target{Kind: section, Number: number, Title: title})
+			n.heading(node, id, number+".")
+		case *ast.CaptionFigure:
+			t := Target{Kind: figure}
+			switch child := ast.GetFirstChild(node).(type) {
+			case *ast.BlockQuote:
+				return ast.GoToNext
+			case *ast.CaptionFigure:
+				// a "!---" wrapper without a caption around a single figure, only that figure is numbered.
+				if child == ast.GetLastChild(node) {
+					return ast.GoToNext
+				}
+				figures++
+				t.Number = strconv.Itoa(figures)
+			case *ast.Table:
+				tables++
+				t.Kind, t.Number = table, strconv.Itoa(tables)
+			default:
+				figures++
+				t.Number = strconv.Itoa(figures)
+			}
+			if caption, ok := ast.GetLastChild(node).(*ast.Caption); ok {
+				t.Title = mast.PlainText(caption)
+			}
+			n.Figure[node] = t.Kind + " " + t.Number
+			n.target(mast.FigureID(node), t)
+		}
+		return ast.GoToNext
+	})
+	return n
+}
+
+// target adds t under id, the first target with an ID wins.
+func (n *Numbers) target(id string, t Target) {
+	if _, ok := n.Targets[id]; !ok && id != "" {
+		n.Targets[id] = t
+	}
+}
+
+func (n *Numbers) heading(node *ast.Heading, id, number string) {
+	n.Heading[node] = number
+	if _, ok := n.sections[id]; !ok && id != "" {
+		n.sections[id] = number
+	}
+}
+
+// Section returns the number of the section with ID id as shown in its heading, i.e. "1.2." or "Appendix A.".
+// It returns the empty string if the section isn't numbered.
+func (n *Numbers) Section(id string) string { return n.sections[id] }
+
+// CrossReference returns the text for a cross reference, i.e. "Section 1.2" or "Figure 3". The suffixes "use
+// title" and "use counter" return the target's title or its number. It returns false if the target isn't
+// known.
+func (n *Numbers) CrossReference(cr *ast.CrossReference) (string, bool) {
+	t, ok := n.Targets[string(cr.Destination)]
+	if !ok {
+		return "", false
+	}
+	switch string(cr.Suffix) {
+	case n.language.UseTitle():
+		return `"` + t.Title + `"`, true
+	case n.language.UseCounter():
+		if t.Number != "" {
+			return t.Number, true
+		}
+	}
+	return t.String(), true
+}
+
+// Unnumbered returns true if the heading has the attribute numbered="false" or the class "unnumbered".
+func Unnumbered(node *ast.Heading) bool {
+	return string(mast.Attribute(node, "numbered")) == "false" || mast.AttributeClass(node, "unnumbered")
+}
+
+// ID returns the ID of the heading, an ID set with an attribute takes precedence.
+func ID(node *ast.Heading) string {
+	if id := mast.Attribute(node, "id"); len(id) > 0 {
+		return string(id)
+	}
+	return node.HeadingID
+}
+
+// join returns the numbers joined with dots.
+func join(numbers []int) string {
+	s := make([]string, len(numbers))
+	for i := range numbers {
+		s[i] = strconv.Itoa(numbers[i])
+	}
+	return strings.Join(s, ".")
+}
+
+// letters returns the letters for appendix i: A, B, ..., Z, AA, AB, etc.
+func letters(i int) string {
+	s := ""
+	for ; i > 0; i = (i - 1) / 26 {
+		s = string(rune('A'+(i-1)%26)) + s
+	}
+	return s
+}
+
+func upperFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
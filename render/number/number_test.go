@@ -0,0 +1,129 @@
+package number
+
+import (
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mparser"
+)
+
+var doc = []byte(`.# Abstract
+
+Abstract.
+
+{mainmatter}
+
+# Introduction {#intro}
+
+## Terminology {#terms}
+
+{numbered="false"}
+# Acknowledgements {#ack}
+
+## Others {#others}
+
+{#fig}
+~~~
+code
+~~~
+Figure: A figure.
+
+| a | b |
+|---|---|
+| 1 | 2 |
+Table: A table. {#tab}
+
+{backmatter}
+
+# Extra {#extra}
+
+## More {#more}
+`)
+
+func TestNumbers(t *testing.T) {
+	p := parser.NewWithExtensions(mparser.Extensions)
+	n := New(markdown.Parse(doc, p), lang.New("en"))
+
+	tests := []struct {
+		id     string
+		expect string
+	}{
+		{"intro", "Section 1"},
+		{"terms", "Section 1.1"},
+		{"ack", `"Acknowledgements"`},
+		{"others", `"Others"`},
+		{"fig", "Figure 1"},
+		{"tab", "Table 1"},
+		{"extra", "Appendix A"},
+		{"more", "Appendix A.1"},
+	}
+	for _, tc := range tests {
+		text, ok := n.CrossReference(&ast.CrossReference{Destination: []byte(tc.id)})
+		if !ok {
+			t.Errorf("expected target %q to be found", tc.id)
+			continue
+		}
+		if text != tc.expect {
+			t.Errorf("expected %q for %q, got %q", tc.expect, tc.id, text)
+		}
+	}
+	if s := n.Section("extra"); s != "Appendix A." {
+		t.Errorf("expected %q, got %q", "Appendix A.", s)
+	}
+	if s := n.Section("ack"); s != "" {
+		t.Errorf("expected no number, got %q", s)
+	}
+	if _, ok := n.CrossReference(&ast.CrossReference{Destination: []byte("unknown")}); ok {
+		t.Errorf("expected unknown target not to be found")
+	}
+}
+
+func TestCrossReferenceSuffix(t *testing.T) {
+	p := parser.NewWithExtensions(mparser.Extensions)
+	n := New(markdown.Parse(doc, p), lang.New("nl"))
+
+	tests := []struct {
+		suffix string
+		expect string
+	}{
+		{"", "Sectie 1.1"},
+		{"gebruik nummer", "1.1"},
+		{"gebruik titel", `"Terminology"`},
+	}
+	for _, tc := range tests {
+		text, _ := n.CrossReference(&ast.CrossReference{Destination: []byte("terms"), Suffix: []byte(tc.suffix)})
+		if text != tc.expect {
+			t.Errorf("expected %q for suffix %q, got %q", tc.expect, tc.suffix, text)
+		}
+	}
+}
+
+func TestNumbersFigureWrapper(t *testing.T) {
+	doc := []byte(`# Introduction
+
+!---
+{#inner}
+~~~
+code
+~~~
+Figure: Inner.
+!---
+
+{#second}
+~~~
+more
+~~~
+Figure: Second.
+`)
+	p := parser.NewWithExtensions(mparser.Extensions)
+	n := New(markdown.Parse(doc, p), lang.New("en"))
+
+	for id, expect := range map[string]string{"inner": "Figure 1", "second": "Figure 2"} {
+		if text, _ := n.CrossReference(&ast.CrossReference{Destination: []byte(id)}); text != expect {
+			t.Errorf("expected %q for %q, got %q", expect, id, text)
+		}
+	}
+}
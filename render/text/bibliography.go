@@ -0,0 +1,113 @@
+package text
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
+	"github.com/mmarkdown/mmark/v2/render/cite"
+)
+
+// bibliography adds the heading for the references, when there are normative and informative references
+// these are subsections of a "References" section.
+func (r *Renderer) bibliography(w io.Writer, node *mast.Bibliography, entering bool) {
+	if !entering {
+		return
+	}
+	name := r.opts.Language.Bibliography()
+	switch node.Type {
+	case ast.CitationTypeInformative:
+		name = "Informative References"
+	case ast.CitationTypeNormative:
+		name = "Normative References"
+	}
+	level := 1
+	if _, ok := node.Parent.(*mast.BibliographyWrapper); ok {
+		level = 2
+	}
+	r.section(level, r.numbers.Bibliography[node], name)
+}
+
+// maxHang is the largest hanging indent of the references, as used by xml2rfc.
+const maxHang = 11
+
+// bibliographyItem adds a reference, the label is followed by the reference with a hanging indent that is
+// the same for all references in the section. A label that doesn't fit in the indent is on a line of its own.
+func (r *Renderer) bibliographyItem(w io.Writer, bib *mast.BibliographyItem) {
+	hang := 0
+	for _, c := range bib.Parent.GetChildren() {
+		if item, ok := c.(*mast.BibliographyItem); ok {
+			hang = max(hang, len(item.Anchor)+2+2)
+		}
+	}
+	hang = min(hang, maxHang)
+
+	var texts []string
+	switch {
+	case bib.Reference != nil:
+		texts = append(texts, r.reference(bib.Reference))
+	case bib.ReferenceGroup != nil:
+		group := bib.ReferenceGroup
+		for i := range group.References {
+			texts = append(texts, r.reference(&group.References[i]))
+		}
+		if group.Target != "" {
+			texts = append(texts, "<"+group.Target+">")
+		}
+	default:
+		// Not resolved, i.e. an RFC that is included by xml2rfc.
+		texts = append(texts, unresolved(string(bib.Anchor)))
+	}
+
+	label := "[" + string(bib.Anchor) + "]"
+	lines := []string{}
+	if len(label)+2 > hang {
+		lines = append(lines, label)
+		label = strings.Repeat(" ", hang)
+	} else {
+		label += strings.Repeat(" ", hang-len(label))
+	}
+	for i, text := range texts {
+		first := strings.Repeat(" ", hang)
+		if i == 0 {
+			first = label
+		}
+		for j, l := range wrapWidth(text, r.width()-hang, r.width()-hang) {
+			if j == 0 {
+				lines = append(lines, first+l)
+				continue
+			}
+			lines = append(lines, strings.Repeat(" ", hang)+l)
+		}
+	}
+	r.add(bib, lines)
+}
+
+// reference returns ref formatted according to the citation style, the title and URL can't be broken over
+// lines.
+func (r *Renderer) reference(ref *reference.Reference) string {
+	style := r.opts.Style
+	if style == nil {
+		style = cite.Default
+	}
+	s := ""
+	for _, f := range style.Format(ref, r.opts.Language) {
+		switch f.Kind {
+		case cite.Target, cite.Series, cite.Date:
+			s += protect(f.Text)
+		default:
+			s += strings.Replace(f.Text, "\n", " ", -1)
+		}
+	}
+	return s
+}
+
+// unresolved returns the text for a reference that isn't resolved, for an RFC this links to the RFC Editor.
+func unresolved(anchor string) string {
+	if n := strings.TrimLeft(strings.TrimPrefix(anchor, "RFC"), "0"); n != anchor && n != "" && strings.Trim(n, "0123456789") == "" {
+		return protect("RFC "+n) + ", " + protect("<https://www.rfc-editor.org/info/rfc"+n+">") + "."
+	}
+	return anchor + "."
+}
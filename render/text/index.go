@@ -0,0 +1,70 @@
+package text
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// indexed records the section the index is found in.
+func (r *Renderer) indexed(node *ast.Index) {
+	if r.current != "" {
+		r.indices[node.ID] = r.current
+	}
+}
+
+// index adds the index, each item is followed by the sections it is found in. Primary items are marked with
+// an asterisk.
+func (r *Renderer) index(w io.Writer, node *mast.DocumentIndex) {
+	r.section(1, "", r.opts.Language.Index())
+	for _, l := range node.GetChildren() {
+		letter, ok := l.(*mast.IndexLetter)
+		if !ok {
+			continue
+		}
+		r.blocks = append(r.blocks, &block{space: true, next: true, toc: -1, lines: []string{r.indent() + strings.ToUpper(string(letter.Literal))}})
+		for _, i := range letter.GetChildren() {
+			item, ok := i.(*mast.IndexItem)
+			if !ok {
+				continue
+			}
+			r.indexItem(string(item.Item), item, "   ")
+			for _, s := range item.GetChildren() {
+				if sub, ok := s.(*mast.IndexSubItem); ok {
+					r.indexItem(string(sub.Subitem), sub, "      ")
+				}
+			}
+		}
+	}
+}
+
+// indexItem adds a line for an index item with the sections linked from node.
+func (r *Renderer) indexItem(text string, node ast.Node, indent string) {
+	seen := map[string]bool{}
+	refs := []string{}
+	for _, c := range node.GetChildren() {
+		link, ok := c.(*mast.IndexLink)
+		if !ok {
+			continue
+		}
+		ref := r.indices[string(link.Destination)]
+		if ref == "" || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		if link.Primary {
+			ref += "*"
+		}
+		refs = append(refs, protect(ref))
+	}
+	if len(refs) > 0 {
+		text += protect(" ") + " " + strings.Join(refs, ", ")
+	}
+	b := &block{toc: -1}
+	for _, l := range wrapHanging(text, r.width()-len(indent), 3) {
+		b.lines = append(b.lines, r.indent()+indent+l)
+	}
+	r.blocks = append(r.blocks, b)
+}
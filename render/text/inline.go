@@ -0,0 +1,226 @@
+package text
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/render/xml"
+)
+
+const (
+	space     = "\x00" // a space that can't be used to wrap a line
+	hardbreak = "\x01" // a forced line break
+)
+
+// protect makes s unbreakable when wrapping.
+func protect(s string) string { return strings.NewReplacer(" ", space, "\n", space).Replace(s) }
+
+// unprotect reverts protect.
+func unprotect(s string) string { return strings.Replace(s, space, " ", -1) }
+
+func (r *Renderer) outs(w io.Writer, s string) { io.WriteString(w, s) }
+
+// inlineWriter returns the writer inline elements are written to.
+func (r *Renderer) inlineWriter(w io.Writer) io.Writer {
+	if r.inline != nil {
+		return r.inline
+	}
+	return w
+}
+
+// inlineString renders the children of node as a single line.
+func (r *Renderer) inlineString(node ast.Node) string {
+	saved := r.inline
+	r.inline = &bytes.Buffer{}
+	for _, child := range node.GetChildren() {
+		ast.WalkFunc(child, func(node ast.Node, entering bool) ast.WalkStatus {
+			return r.renderInline(r.inline, node, entering)
+		})
+	}
+	s := r.inline.String()
+	r.inline = saved
+	return unprotect(strings.Join(strings.Fields(strings.Replace(s, hardbreak, " ", -1)), " "))
+}
+
+func (r *Renderer) renderInline(w io.Writer, node ast.Node, entering bool) ast.WalkStatus {
+	switch node := node.(type) {
+	case *ast.Text:
+		if _, ok := node.Parent.(*ast.Strong); ok && xml.Is2119(node.Literal) {
+			// BCP 14 keywords are written as is and never wrapped.
+			r.outs(w, protect(string(node.Literal)))
+			break
+		}
+		r.outs(w, string(node.Literal))
+	case *ast.Softbreak:
+		r.outs(w, " ")
+	case *ast.Hardbreak:
+		r.outs(w, hardbreak)
+	case *ast.NonBlockingSpace:
+		r.outs(w, space)
+	case *ast.Emph:
+		r.outs(w, "_")
+	case *ast.Strong:
+		if bcp14(node) {
+			break
+		}
+		r.outs(w, "*")
+	case *ast.Del:
+		// there is no markup for deleted text.
+	case *ast.Code:
+		r.outs(w, string(node.Literal))
+	case *ast.HTMLSpan:
+		// HTML can't be shown in text.
+	case *ast.Math:
+		r.outs(w, string(node.Literal))
+	case *ast.Subscript:
+		r.outs(w, protect("_"+script(node.Literal)))
+	case *ast.Superscript:
+		r.outs(w, protect("^"+script(node.Literal)))
+	case *ast.Callout:
+		r.outs(w, "("+string(node.ID)+")")
+	case *ast.Index:
+		r.indexed(node)
+	case *ast.CrossReference:
+		if entering {
+			r.outs(w, protect(r.crossReference(node)))
+		}
+		return ast.SkipChildren
+	case *ast.Citation:
+		r.outs(w, r.citation(node))
+	case *ast.Link:
+		if entering {
+			r.outs(w, r.link(node))
+		}
+		return ast.SkipChildren
+	case *ast.Image:
+		if entering {
+			r.outs(w, r.inlineString(node))
+		}
+		return ast.SkipChildren
+	default:
+		panic(fmt.Sprintf("Unknown node %T", node))
+	}
+	return ast.GoToNext
+}
+
+// bcp14 returns true if strong only holds a BCP 14 keyword, these are written without markup.
+func bcp14(strong *ast.Strong) bool {
+	t, ok := ast.GetFirstChild(strong).(*ast.Text)
+	return ok && len(strong.Children) == 1 && xml.Is2119(t.Literal)
+}
+
+// script returns the text of a sub or superscript, in parenthesis if longer than a single character.
+func script(literal []byte) string {
+	s := string(bytes.Replace(literal, []byte(`\ `), []byte(" "), -1))
+	if utf8.RuneCountInString(s) > 1 {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+// crossReference returns the text for a cross reference, i.e. "Section 1.2" or "Figure 3".
+func (r *Renderer) crossReference(node *ast.CrossReference) string {
+	if text, ok := r.numbers.CrossReference(node); ok {
+		return text
+	}
+	return "[" + string(node.Destination) + "]"
+}
+
+// citation returns the text for a citation, i.e. "[RFC2119], Section 3". The citation of an author or contact
+// is their name.
+func (r *Renderer) citation(node *ast.Citation) string {
+	cites := []string{}
+	for i, c := range node.Destination {
+		if node.Type[i] == ast.CitationTypeSuppressed {
+			continue
+		}
+		if author := xml.AuthorFromTitle(c, r.Title); author != nil {
+			cites = append(cites, protect(author.Fullname))
+			continue
+		}
+		if contact := xml.ContactFromTitle(c, r.Title); contact != nil {
+			cites = append(cites, protect(contact.Fullname))
+			continue
+		}
+		// RFC2119@BCP14 cites the first document.
+		if n := bytes.Index(c, []byte("@")); n > 0 {
+			c = c[:n]
+		}
+		cite := "[" + string(c) + "]"
+		if len(node.Suffix) > i {
+			if suf := bytes.TrimSpace(node.Suffix[i]); len(suf) > 0 {
+				cite += ", " + protect(string(suf))
+			}
+		}
+		cites = append(cites, cite)
+	}
+	return strings.Join(cites, ", ")
+}
+
+// link returns the text for a link, external links are followed by their URL.
+func (r *Renderer) link(link *ast.Link) string {
+	if link.Footnote != nil {
+		return fmt.Sprintf("[%d]", link.NoteID)
+	}
+	text := r.inlineString(link)
+	dest := string(link.Destination)
+	switch {
+	case strings.HasPrefix(dest, "#"):
+		return text
+	case text == "" || text == dest || "mailto:"+text == dest:
+		return "<" + dest + ">"
+	}
+	return text + " <" + dest + ">"
+}
+
+// wrap wraps text at width, it is split into lines at hard breaks.
+func wrap(text string, width int) []string { return wrapWidth(text, width, width) }
+
+// wrapHanging wraps text at width, all lines but the first are indented with hang spaces.
+func wrapHanging(text string, width, hang int) []string {
+	lines := wrapWidth(text, width, width-hang)
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.Repeat(" ", hang) + lines[i]
+	}
+	return lines
+}
+
+// wrapWidth wraps text, the first line at first and the other lines at width.
+func wrapWidth(text string, first, width int) []string {
+	lines := []string{}
+	max := func() int {
+		if len(lines) == 0 {
+			return first
+		}
+		return width
+	}
+	for _, s := range strings.Split(text, hardbreak) {
+		line := ""
+		for _, word := range strings.Fields(s) {
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > max():
+				lines = append(lines, unprotect(line))
+				line = word
+			default:
+				line += " " + word
+			}
+		}
+		lines = append(lines, unprotect(line))
+	}
+	return lines
+}
+
+// center centers s in width.
+func center(s string, width int) string {
+	n := (width - utf8.RuneCountInString(s)) / 2
+	if n <= 0 {
+		return s
+	}
+	return strings.Repeat(" ", n) + s
+}
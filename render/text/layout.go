@@ -0,0 +1,254 @@
+package text
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// block is a number of lines that are laid out together.
+type block struct {
+	lines []string
+	space bool // separated from the previous block by an empty line
+	keep  bool // don't break the block over two pages, if it fits on a page
+	next  bool // keep the block on the same page as the start of the next block, i.e. for headings
+	toc   int  // index of the table of contents entry for this block, -1 if none
+}
+
+// entry is an entry in the table of contents.
+type entry struct {
+	level  int
+	number string
+	title  string
+	page   int
+}
+
+// Page dimensions, see RFC 7994, Section 4.
+const (
+	pageLength = 58                 // lines on a page, including the header and footer
+	pageBody   = pageLength - 3 - 3 // the header and footer are separated from the text by two empty lines
+)
+
+func (r *Renderer) addEntry(level int, number, title string) int {
+	r.entries = append(r.entries, entry{level: level, number: number, title: title})
+	return len(r.entries) - 1
+}
+
+// layout lays out the document and returns its pages, when not paginating there is a single page. The table
+// of contents has a line for each entry (unless one needs to be wrapped) so the pages can be numbered before
+// the table of contents is filled in.
+func (r *Renderer) layout() [][]string {
+	blocks := r.blocks
+	if r.opts.Flags&TextFragment == 0 {
+		toc := r.toc
+		if toc < 0 {
+			toc = len(blocks)
+		}
+		front := r.frontPage()
+		contents := r.contents()
+		blocks = append(append(append(front, blocks[:toc]...), contents...), blocks[toc:]...)
+		if r.opts.Flags&Paginate != 0 {
+			r.paginate(blocks)
+			// the page numbers are known now, redo the table of contents with them.
+			copy(blocks[len(front)+toc:], r.contents())
+		}
+	}
+	if r.opts.Flags&Paginate == 0 {
+		page := []string{}
+		for i, b := range blocks {
+			if b.space && i > 0 {
+				page = append(page, "")
+			}
+			page = append(page, b.lines...)
+		}
+		return [][]string{page}
+	}
+
+	bodies := r.paginate(blocks)
+	pages := make([][]string, len(bodies))
+	for i, body := range bodies {
+		page := []string{r.header(), "", ""}
+		if i == 0 {
+			page = []string{}
+		}
+		page = append(page, body...)
+		for len(page) < pageLength-1 {
+			page = append(page, "")
+		}
+		pages[i] = append(page, r.footer(i+1))
+	}
+	return pages
+}
+
+// paginate breaks the blocks into pages and sets the page numbers of the table of contents entries. The
+// lines of each page are returned, without the header and footer.
+func (r *Renderer) paginate(blocks []*block) [][]string {
+	pages := [][]string{}
+	page := []string{}
+	size := func() int { return pageBody + 3*boolInt(len(pages) == 0) } // the first page has no header
+	newPage := func() {
+		pages = append(pages, page)
+		page = []string{}
+	}
+
+	for i, b := range blocks {
+		space := boolInt(b.space && len(page) > 0)
+		// need is the number of lines needed on this page: all lines of a block that is kept together,
+		// otherwise two, the same for the next block(s) that this one is kept with.
+		need := space + min(len(b.lines), 2)
+		if b.keep && len(b.lines) <= size() {
+			need = space + len(b.lines)
+		}
+		for j := i; j < len(blocks)-1 && blocks[j].next; j++ {
+			need += len(blocks[j].lines)*boolInt(j > i) + boolInt(blocks[j+1].space)
+			if blocks[j+1].keep && !blocks[j+1].next {
+				need += len(blocks[j+1].lines)
+				continue
+			}
+			need += min(len(blocks[j+1].lines), 2)
+		}
+		if need > size()-len(page) && len(page) > 0 && need <= size() {
+			newPage()
+			space = 0
+		}
+		if space > 0 {
+			page = append(page, "")
+		}
+		if b.toc >= 0 {
+			r.entries[b.toc].page = len(pages) + 1
+		}
+		lines := b.lines
+		for len(lines) > 0 {
+			room := size() - len(page)
+			if room <= 0 {
+				newPage()
+				continue
+			}
+			if len(lines) <= room {
+				page = append(page, lines...)
+				break
+			}
+			// don't leave a single line behind on the next page.
+			n := room
+			if len(lines)-n == 1 && n > 2 {
+				n--
+			}
+			page = append(page, lines[:n]...)
+			lines = lines[n:]
+			newPage()
+		}
+	}
+	if len(page) > 0 || len(pages) == 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// contents returns the table of contents.
+func (r *Renderer) contents() []*block {
+	if len(r.entries) == 0 {
+		return nil
+	}
+	blocks := []*block{{space: true, next: true, toc: -1, lines: []string{"Table of Contents"}}}
+	b := &block{space: true, toc: -1}
+	for _, e := range r.entries {
+		indent := 3 + 2*(e.level-1)
+		text := e.title
+		if e.number != "" {
+			text = protect(e.number+" ") + " " + text
+		}
+		hang := len(e.number) + 2
+		if e.number == "" {
+			hang = 3
+		}
+		if r.opts.Flags&Paginate == 0 {
+			for _, l := range wrapHanging(text, Width-indent, hang) {
+				b.lines = append(b.lines, strings.Repeat(" ", indent)+l)
+			}
+			continue
+		}
+		// The page number is right aligned, the dots in between line up on even columns.
+		page := fmt.Sprintf("%d", e.page)
+		lines := wrapHanging(text, Width-indent-len(page)-6, hang)
+		for i := range lines {
+			lines[i] = strings.Repeat(" ", indent) + lines[i]
+		}
+		last := lines[len(lines)-1] + " "
+		if utf8.RuneCountInString(last)%2 == 1 {
+			last += " "
+		}
+		for utf8.RuneCountInString(last) < Width-len(page)-2 {
+			last += ". "
+		}
+		lines[len(lines)-1] = last + strings.Repeat(" ", Width-len(page)-utf8.RuneCountInString(last)) + page
+		b.lines = append(b.lines, lines...)
+	}
+	return append(blocks, b)
+}
+
+// header returns the page header: the document's name, its (abbreviated) title and its date.
+func (r *Renderer) header() string {
+	if r.Title == nil {
+		return ""
+	}
+	title := r.Title.Abbrev
+	if title == "" {
+		title = r.Title.Title
+	}
+	return spread(r.seriesName(), title, r.date(false))
+}
+
+// footer returns the footer of page n: the authors, the status or expiry date and the page number.
+func (r *Renderer) footer(n int) string {
+	page := fmt.Sprintf("[Page %d]", n)
+	if r.Title == nil {
+		return spread("", "", page)
+	}
+	center := r.status()
+	if r.Title.Draft() && !r.Title.Date.IsZero() {
+		center = "Expires " + r.expires()
+	}
+	return spread(r.surnames(), center, page)
+}
+
+// spread returns a line with left, center and right aligned text. When that doesn't fit the center text is
+// shortened.
+func spread(left, center, right string) string {
+	l, c, rr := utf8.RuneCountInString(left), utf8.RuneCountInString(center), utf8.RuneCountInString(right)
+	if room := Width - l - rr - 4; c > room {
+		if room < 4 {
+			center, c = "", 0
+		} else {
+			center = string([]rune(center)[:room-3]) + "..."
+			c = room
+		}
+	}
+	at := max((Width-c)/2, l+2)
+	s := left + strings.Repeat(" ", at-l) + center
+	if right == "" {
+		return strings.TrimRight(s, " ")
+	}
+	return s + strings.Repeat(" ", max(1, Width-utf8.RuneCountInString(s)-rr)) + right
+}
+
+// date returns the date of the document, "October 2026", with the day if long is true and the document is a
+// draft.
+func (r *Renderer) date(long bool) string {
+	if r.Title == nil {
+		return ""
+	}
+	return r.Title.DateString(long)
+}
+
+// expires returns the expiry date of a draft, 185 days after its date.
+func (r *Renderer) expires() string {
+	return r.Title.Date.Add(185 * 24 * time.Hour).Format("2 January 2006")
+}
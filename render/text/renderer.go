@@ -0,0 +1,473 @@
+// The package text outputs plain text in the style of RFC 7994 (72 columns, numbered sections, a table of
+// contents and optionally pages with running headers and footers) from mmark markdown. The output is meant for
+// reviewing and diffing documents without xml2rfc.
+package text
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mparser"
+	"github.com/mmarkdown/mmark/v2/render/cite"
+	"github.com/mmarkdown/mmark/v2/render/number"
+)
+
+// Flags control optional behavior of text renderer.
+type Flags int
+
+// Text renderer configuration options.
+const (
+	FlagsNone    Flags = 0
+	TextFragment Flags = 1 << iota // Don't generate the first page header, table of contents and authors' addresses
+	Paginate                       // Break the output in pages with a header and footer
+
+	CommonFlags Flags = Paginate
+)
+
+// Width is the width of the output, see RFC 7994, Section 4.
+const Width = 72
+
+// RendererOptions is a collection of supplementary parameters tweaking
+// the behavior of various parts of text renderer.
+type RendererOptions struct {
+	Flags Flags // Flags allow customizing this renderer's behavior
+
+	Language lang.Lang // Output language for the document.
+
+	// if set, called at the start of RenderNode(). Allows replacing rendering of some nodes
+	RenderNodeHook html.RenderNodeFunc
+
+	// Style is the citation style used to format the references, defaults to cite.Default (RFC 7322).
+	Style cite.Style
+
+	// Diagnostics collects all problems found while rendering, if nil they are logged.
+	Diagnostics *diag.Diagnostics
+
+	// File is the name of the document, used in diagnostics for nodes without a known source span.
+	File string
+
+	// Sources holds the source spans of the nodes, it's used to locate diagnostics. May be nil.
+	Sources *mast.Sources
+
+	// ReadFile reads the .ascii-art images, which are shown as artwork instead of the note that the figure is
+	// only available as an image. It defaults to mparser.ReadFileFunc(nil).
+	ReadFile func(name string) ([]byte, error)
+}
+
+// Renderer implements Renderer interface for text output. Nothing is written until RenderFooter, as the page
+// numbers in the table of contents are only known when the entire document has been laid out.
+type Renderer struct {
+	opts RendererOptions
+
+	Title   *mast.Title
+	numbers *number.Numbers
+
+	blocks  []*block
+	entries []entry // the table of contents
+	toc     int     // index of the block before which the table of contents is put, -1 if not known yet
+
+	current string            // the section we are in, i.e. "Section 1.2", for the index
+	indices map[string]string // the section each index is found in, keyed by the index' ID
+
+	// prefix holds the indentation of each nested block. If the marker of a level is set it is used instead
+	// on the next line, i.e. "*  " for a list item.
+	prefix []string
+	marker []string
+
+	inline  *bytes.Buffer // when not nil, inline elements are written here
+	figures []int         // index of the first block of each open figure
+}
+
+// NewRenderer creates and configures an Renderer object, which satisfies the Renderer interface.
+func NewRenderer(opts RendererOptions) *Renderer {
+	return &Renderer{opts: opts, toc: -1, indices: map[string]string{}}
+}
+
+func (r *Renderer) push(prefix, marker string) {
+	r.prefix = append(r.prefix, prefix)
+	r.marker = append(r.marker, marker)
+}
+
+func (r *Renderer) pop() {
+	r.prefix = r.prefix[:len(r.prefix)-1]
+	r.marker = r.marker[:len(r.marker)-1]
+}
+
+// indent returns the indentation for the next line and clears the markers used.
+func (r *Renderer) indent() string {
+	s := ""
+	for i := range r.prefix {
+		if r.marker[i] != "" {
+			s += r.marker[i]
+			r.marker[i] = ""
+			continue
+		}
+		s += r.prefix[i]
+	}
+	return s
+}
+
+// width returns the width available for text, after the indentation.
+func (r *Renderer) width() int {
+	width := Width
+	for _, p := range r.prefix {
+		width -= len(p)
+	}
+	if width < 20 {
+		width = 20
+	}
+	return width
+}
+
+// add adds a block with lines, each line is indented. The block is separated from the previous one by an
+// empty line, unless node is in a list item that doesn't contain blocks.
+func (r *Renderer) add(node ast.Node, lines []string) *block {
+	b := &block{space: separate(node), toc: -1}
+	for _, l := range lines {
+		b.lines = append(b.lines, strings.TrimRight(r.indent()+l, " "))
+	}
+	r.blocks = append(r.blocks, b)
+	return b
+}
+
+// separate returns true if node is separated from the previous block by an empty line.
+func separate(node ast.Node) bool {
+	item, ok := node.GetParent().(*ast.ListItem)
+	if !ok || item.ListFlags&ast.ListItemContainsBlock != 0 {
+		return true
+	}
+	if ast.GetPrevNode(node) != nil {
+		return false
+	}
+	// The first block of the first item starts the list, the list itself may be separated.
+	if ast.GetPrevNode(item) == nil && item.Parent != nil {
+		return separate(item.Parent)
+	}
+	return false
+}
+
+// heading adds a section heading, headings aren't indented. The table of contents follows the abstract and
+// notes, the special sections at the start of the document.
+func (r *Renderer) heading(w io.Writer, node *ast.Heading) {
+	if r.toc < 0 && !node.IsSpecial {
+		r.toc = len(r.blocks)
+	}
+	if t, ok := r.numbers.Targets[number.ID(node)]; ok {
+		r.current = t.String()
+	}
+	r.section(node.Level, r.numbers.Heading[node], r.inlineString(node))
+}
+
+// tocDepth returns the depth of the table of contents, xml2rfc defaults to 3.
+func (r *Renderer) tocDepth() int {
+	if r.Title != nil && r.Title.TocDepth > 0 {
+		return r.Title.TocDepth
+	}
+	return 3
+}
+
+// section adds a heading with number and text, it's also used for the sections that aren't in the document,
+// i.e. the references. Headings before the table of contents aren't in it.
+func (r *Renderer) section(level int, number, text string) {
+	title := text
+	if number != "" {
+		title = protect(number+" ") + " " + text
+	}
+	b := &block{space: true, next: true, toc: -1, lines: wrapHanging(title, Width, len(number)+2)}
+	if r.toc >= 0 && level <= r.tocDepth() {
+		b.toc = r.addEntry(level, number, text)
+	}
+	r.blocks = append(r.blocks, b)
+}
+
+func (r *Renderer) paragraph(w io.Writer, para *ast.Paragraph, entering bool) {
+	if entering {
+		r.inline = &bytes.Buffer{}
+		return
+	}
+	text := r.inline.String()
+	r.inline = nil
+	if item, ok := para.Parent.(*ast.ListItem); ok && item.ListFlags&ast.ListTypeTerm != 0 {
+		// a term is on a line of its own, the definition follows indented.
+		r.add(para, wrap(text, r.width()))
+		return
+	}
+	if strings.TrimSpace(unprotect(text)) == "" {
+		// i.e. a paragraph with only an index or suppressed citations.
+		return
+	}
+	r.add(para, wrap(text, r.width()))
+}
+
+func (r *Renderer) list(w io.Writer, list *ast.List, entering bool) {
+	if entering && list.IsFootnotesList {
+		r.section(1, "", r.opts.Language.Footnotes())
+	}
+}
+
+// bullets are the bullets used for each level of nested unordered lists.
+var bullets = []string{"*", "-", "o"}
+
+func (r *Renderer) listItem(w io.Writer, item *ast.ListItem, entering bool) {
+	if !entering {
+		if r.inline != nil {
+			text := r.inline.String()
+			r.inline = nil
+			r.add(item, wrap(text, r.width()))
+		}
+		r.pop()
+		return
+	}
+	list, _ := item.Parent.(*ast.List)
+	x := item.ListFlags
+	switch {
+	case item.RefLink != nil:
+		marker := fmt.Sprintf("[%d]", mast.ChildIndex(item)+1)
+		r.push(strings.Repeat(" ", len(marker)+2), marker+"  ")
+	case x&ast.ListTypeTerm != 0:
+		r.push("", "")
+	case x&ast.ListTypeDefinition != 0:
+		r.push("   ", "")
+	case x&ast.ListTypeOrdered != 0:
+		start := 1
+		if list != nil && list.Start > 1 {
+			start = list.Start
+		}
+		delim := item.Delimiter
+		if delim == 0 {
+			delim = '.'
+		}
+		marker := fmt.Sprintf("%d%c", start+mast.ChildIndex(item), delim)
+		width := len(fmt.Sprintf("%d%c", start+len(list.Children)-1, delim))
+		marker += strings.Repeat(" ", width-len(marker))
+		r.push(strings.Repeat(" ", len(marker)+2), marker+"  ")
+	default:
+		r.push("   ", bullets[r.listDepth(item)%len(bullets)]+"  ")
+	}
+	// A footnote with a single paragraph holds the inline elements directly.
+	if first := ast.GetFirstChild(item); first != nil && !isBlock(first) {
+		r.inline = &bytes.Buffer{}
+	}
+}
+
+// listDepth returns the number of unordered lists node is in, minus one.
+func (r *Renderer) listDepth(node ast.Node) int {
+	depth := -1
+	for p := node.GetParent(); p != nil; p = p.GetParent() {
+		if l, ok := p.(*ast.List); ok && l.ListFlags&(ast.ListTypeOrdered|ast.ListTypeDefinition) == 0 {
+			depth++
+		}
+	}
+	return depth
+}
+
+// isBlock returns true if node is a block level element.
+func isBlock(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.Paragraph, *ast.List, *ast.ListItem, *ast.Heading, *ast.CodeBlock, *ast.MathBlock, *ast.BlockQuote,
+		*ast.Aside, *ast.Table, *ast.CaptionFigure, *ast.HTMLBlock, *ast.HorizontalRule, *mast.ReferenceBlock:
+		return true
+	}
+	return false
+}
+
+// artwork adds the lines in literal as artwork, these are indented and never wrapped.
+func (r *Renderer) artwork(node ast.Node, literal []byte) {
+	r.push("   ", "")
+	defer r.pop()
+	lines := strings.Split(strings.TrimRight(string(literal), "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.Replace(lines[i], "\t", "        ", -1)
+		if len(r.indent())+len([]rune(lines[i])) > Width {
+			r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(node, diag.Warning, "text-artwork", r.opts.File, "Artwork line is longer than %d characters: %q", Width, lines[i]))
+			break
+		}
+	}
+	r.add(node, lines).keep = true
+}
+
+func (r *Renderer) image(w io.Writer, node *ast.Image) {
+	dest := string(node.Destination)
+	if strings.HasSuffix(dest, ".ascii-art") {
+		img, err := mparser.ReadFileFunc(r.opts.ReadFile)(dest)
+		if err == nil {
+			r.artwork(node.Parent, img)
+			return
+		}
+		r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(node, diag.Warning, "text-image", r.opts.File, "Failure to read image: %s", err))
+	}
+	alt := r.inlineString(node)
+	if alt == "" {
+		alt = path.Base(dest)
+	}
+	r.add(node.Parent, wrap(protect("(Artwork only available as an image:")+" "+alt+", "+protect("see "+dest+")"), r.width()))
+}
+
+// captionFigure keeps the blocks of a figure together with its caption.
+func (r *Renderer) captionFigure(w io.Writer, figure *ast.CaptionFigure, entering bool) {
+	if entering {
+		r.figures = append(r.figures, len(r.blocks))
+		return
+	}
+	start := r.figures[len(r.figures)-1]
+	r.figures = r.figures[:len(r.figures)-1]
+	for i := start; i < len(r.blocks)-1; i++ {
+		r.blocks[i].next = true
+	}
+}
+
+func (r *Renderer) caption(w io.Writer, caption *ast.Caption) {
+	figure, ok := caption.Parent.(*ast.CaptionFigure)
+	if !ok {
+		return
+	}
+	text := r.inlineString(caption)
+	if _, ok := ast.GetFirstChild(figure).(*ast.BlockQuote); ok {
+		r.push("   ", "")
+		r.add(caption, wrap("-- "+text, r.width()))
+		r.pop()
+		return
+	}
+	if n := r.numbers.Figure[figure]; n != "" {
+		text = n + ": " + text
+	}
+	lines := wrap(text, r.width())
+	for i := range lines {
+		lines[i] = center(lines[i], r.width())
+	}
+	r.add(caption, lines)
+}
+
+func (r *Renderer) blockQuote(w io.Writer, node ast.Node, entering bool) {
+	if !entering {
+		r.pop()
+		return
+	}
+	r.push("   ", "")
+}
+
+// RenderNode renders a markdown node to text.
+func (r *Renderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast.WalkStatus {
+	if r.opts.RenderNodeHook != nil {
+		status, didHandle := r.opts.RenderNodeHook(w, node, entering)
+		if didHandle {
+			return status
+		}
+	}
+
+	switch node := node.(type) {
+	case *ast.Document:
+		// do nothing
+	case *mast.Title:
+		r.Title = node // used in RenderFooter.
+	case *mast.Authors:
+		// the authors' addresses are added at the end.
+	case *mast.TableOfContents:
+		// the table of contents is made when laying out the pages.
+		return ast.SkipChildren
+	case *mast.BibliographyWrapper:
+		if entering {
+			r.section(1, r.numbers.Bibliography[node], "References")
+		}
+	case *mast.Bibliography:
+		r.bibliography(w, node, entering)
+	case *mast.BibliographyItem:
+		if entering {
+			r.bibliographyItem(w, node)
+		}
+		return ast.SkipChildren
+	case *mast.DocumentIndex:
+		if entering {
+			r.index(w, node)
+		}
+		return ast.SkipChildren
+	case *mast.ReferenceBlock:
+		// ignore
+	case *ast.Footnotes:
+		// the footnotes list follows.
+	case *ast.DocumentMatter:
+		// sections are numbered in RenderHeader.
+	case *ast.Heading:
+		if entering {
+			r.heading(w, node)
+		}
+		return ast.SkipChildren
+	case *ast.HorizontalRule:
+		if entering {
+			r.add(node, []string{center("*  *  *", r.width())})
+		}
+	case *ast.Paragraph:
+		r.paragraph(w, node, entering)
+	case *ast.HTMLBlock:
+		// HTML can't be shown in text.
+	case *ast.List:
+		r.list(w, node, entering)
+	case *ast.ListItem:
+		r.listItem(w, node, entering)
+	case *ast.CodeBlock:
+		if entering {
+			r.artwork(node, node.Literal)
+		}
+	case *ast.MathBlock:
+		if entering {
+			r.artwork(node, bytes.Trim(node.Literal, "\n"))
+		}
+		return ast.SkipChildren
+	case *ast.Caption:
+		if entering {
+			r.caption(w, node)
+		}
+		return ast.SkipChildren
+	case *ast.CaptionFigure:
+		r.captionFigure(w, node, entering)
+	case *ast.Table:
+		if entering {
+			r.table(w, node)
+		}
+		return ast.SkipChildren
+	case *ast.BlockQuote:
+		r.blockQuote(w, node, entering)
+	case *ast.Aside:
+		r.blockQuote(w, node, entering)
+	case *ast.Image:
+		// an image is a block in text, a paragraph with only images doesn't add any text.
+		if entering && r.inline != nil && strings.TrimSpace(r.inline.String()) == "" {
+			r.image(w, node)
+			return ast.SkipChildren
+		}
+		return r.renderInline(r.inlineWriter(w), node, entering)
+	default:
+		// all other nodes are inline elements.
+		return r.renderInline(r.inlineWriter(w), node, entering)
+	}
+	return ast.GoToNext
+}
+
+// RenderHeader numbers the sections, figures and tables in doc, these are needed for cross references. All
+// text, except headings, is indented by three spaces.
+func (r *Renderer) RenderHeader(w io.Writer, doc ast.Node) {
+	r.numbers = number.New(doc, r.opts.Language)
+	r.push("   ", "")
+}
+
+// RenderFooter lays out the document and writes it.
+func (r *Renderer) RenderFooter(w io.Writer, _ ast.Node) {
+	if r.opts.Flags&TextFragment == 0 {
+		r.authors()
+	}
+	pages := r.layout()
+	for i, p := range pages {
+		io.WriteString(w, strings.Join(p, "\n")+"\n")
+		if r.opts.Flags&Paginate != 0 && i < len(pages)-1 {
+			io.WriteString(w, "\f\n")
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package text
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mparser"
+)
+
+func render(t *testing.T, input string, flags Flags) string {
+	t.Helper()
+	init := mparser.NewInitial("")
+	p := parser.NewWithExtensions(mparser.Extensions)
+	p.Opts = parser.Options{ParserHook: init.Hook}
+	doc := markdown.Parse([]byte(input), p)
+	mparser.AddIndex(doc)
+	r := NewRenderer(RendererOptions{Flags: flags, Language: lang.New("en")})
+	return string(markdown.Render(doc, r))
+}
+
+func TestRenderer(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"# Intro {#intro}\n\nSee (#intro) and (#fig).\n\n{backmatter}\n\n# Extra\n\n## More {#more}\n\nSee (#more).\n", []string{
+			"1.  Intro\n\n   See Section 1 and [fig].",
+			"Appendix A.  Extra\n\nA.1.  More\n\n   See Appendix A.1.",
+		}},
+		{"# Intro\n\n~~~\nx = 1\n~~~\nFigure: Code. {#fig}\n\nSee (#fig).\n", []string{
+			"      x = 1\n\n                              Figure 1: Code.",
+			"See Figure 1.",
+		}},
+		{"* one\n* two\n  1. a\n  2. b\n", []string{"   *  one\n   *  two\n      1.  a\n      2.  b"}},
+		{"Term\n: Definition\n", []string{"   Term\n      Definition"}},
+		{"Name | Age\n-----|----\nBob  | 27\nTable: People\n", []string{
+			"   +------+-----+\n   | Name | Age |\n   +======+=====+\n   | Bob  | 27  |\n   +------+-----+",
+			"Table 1: People",
+		}},
+		{"Note[^1].\n\n[^1]: The note.\n", []string{"Note[1].", "Footnotes\n\n   [1]  The note."}},
+		{"A **MUST** and *b* and a [link](https://example.org).\n", []string{"A MUST and _b_ and a link <https://example.org>."}},
+		{"# Water\n\nWell (!well, water).\n", []string{"Index\n\n   W\n      well\n         water  Section 1"}},
+	}
+	for i, tc := range tests {
+		got := render(t, tc.input, TextFragment)
+		for _, want := range tc.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("test %d: expected %q in output, got\n%s", i, want, got)
+			}
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	input := "%%%\ntitle = \"Test\"\n[seriesInfo]\nname = \"RFC\"\nvalue = \"9999\"\nstatus = \"informational\"\n\n[[author]]\nsurname = \"Doe\"\n%%%\n\n"
+	for i := 0; i < 30; i++ {
+		input += "# Section\n\nSome text.\n\n"
+	}
+	got := render(t, input, Paginate)
+	pages := strings.Split(got, "\f\n")
+	if len(pages) < 2 {
+		t.Fatalf("expected more than one page, got %d", len(pages))
+	}
+	for i, p := range pages {
+		lines := strings.Split(strings.TrimSuffix(p, "\n"), "\n")
+		if len(lines) != pageLength {
+			t.Errorf("page %d: expected %d lines, got %d", i+1, pageLength, len(lines))
+		}
+		if last := lines[len(lines)-1]; !strings.HasPrefix(last, "Doe") || !strings.HasSuffix(last, "]") {
+			t.Errorf("page %d: unexpected footer %q", i+1, last)
+		}
+		for _, l := range lines {
+			if len(l) > Width {
+				t.Errorf("page %d: line longer than %d characters: %q", i+1, Width, l)
+			}
+		}
+	}
+	if !strings.HasPrefix(pages[1], "RFC 9999 ") {
+		t.Errorf("expected header on the second page, got %q", pages[1][:Width])
+	}
+	if !strings.Contains(pages[0], "Request for Comments: 9999") || !strings.Contains(pages[0], "Table of Contents") {
+		t.Errorf("expected first page, got\n%s", pages[0])
+	}
+}
+
+func TestBibliographyWidth(t *testing.T) {
+	input, err := os.ReadFile("../../rfc/draft-citation-test.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	init := mparser.NewInitial("")
+	p := parser.NewWithExtensions(mparser.Extensions)
+	p.Opts = parser.Options{ParserHook: init.Hook}
+	doc := markdown.Parse(input, p)
+	init.AddBibliography(doc)
+	got := string(markdown.Render(doc, NewRenderer(RendererOptions{Language: lang.New("en")})))
+
+	if want := "   [I-D.brzozowski-dhc-dhcvp6-leasequery]\n              I-D.brzozowski-dhc-dhcvp6-leasequery."; !strings.Contains(got, want) {
+		t.Errorf("expected %q in output, got\n%s", want, got)
+	}
+	for _, l := range strings.Split(got, "\n") {
+		if len(l) > Width {
+			t.Errorf("line longer than %d characters: %q", Width, l)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	got := wrapHanging("1.  "+protect("a b")+" c d", 8, 4)
+	want := []string{"1. a b c", "    d"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,182 @@
+package text
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/diag"
+)
+
+// row is a table row, cells are not wrapped yet.
+type row struct {
+	cells  []string
+	spans  []int
+	header bool
+}
+
+// table adds the table as a grid of cells like xml2rfc does, the header is separated from the body with equal
+// signs. When the table is too wide, the cells in the widest column are wrapped until it fits.
+func (r *Renderer) table(w io.Writer, tab *ast.Table) {
+	var (
+		rows   []row
+		aligns []ast.CellAlignFlags
+	)
+	for _, section := range tab.GetChildren() {
+		_, header := section.(*ast.TableHeader)
+		for _, tr := range section.GetChildren() {
+			rw := row{header: header}
+			for _, c := range tr.GetChildren() {
+				cell, ok := c.(*ast.TableCell)
+				if !ok {
+					continue
+				}
+				rw.cells = append(rw.cells, r.cellString(cell))
+				rw.spans = append(rw.spans, max(1, cell.ColSpan))
+				for len(aligns) < len(rw.cells) {
+					aligns = append(aligns, cell.Align)
+				}
+			}
+			rows = append(rows, rw)
+		}
+	}
+	if len(aligns) == 0 {
+		return
+	}
+
+	// Column widths, and the longest word in each column: the minimum width.
+	widths := make([]int, len(aligns))
+	minimum := make([]int, len(aligns))
+	for _, rw := range rows {
+		col := 0
+		for i, cell := range rw.cells {
+			if col < len(widths) && rw.spans[i] == 1 {
+				widths[col] = max(widths[col], utf8.RuneCountInString(unprotect(cell)))
+				for _, word := range strings.Fields(cell) {
+					minimum[col] = max(minimum[col], utf8.RuneCountInString(word))
+				}
+			}
+			col += rw.spans[i]
+		}
+	}
+	total := func() int {
+		t := 1
+		for _, w := range widths {
+			t += w + 3
+		}
+		return t
+	}
+	for total() > r.width() {
+		widest := 0
+		for i := range widths {
+			if widths[i]-minimum[i] > widths[widest]-minimum[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minimum[widest] {
+			r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(tab, diag.Warning, "text-table", r.opts.File, "Table is wider than %d characters", Width))
+			break
+		}
+		widths[widest]--
+	}
+
+	lines := []string{separator("-", widths)}
+	for i, rw := range rows {
+		lines = append(lines, r.tableRow(rw, widths, aligns)...)
+		switch {
+		case rw.header && (i == len(rows)-1 || !rows[i+1].header):
+			lines = append(lines, separator("=", widths))
+		default:
+			lines = append(lines, separator("-", widths))
+		}
+	}
+	r.add(tab, lines).keep = true
+}
+
+// cellString returns the text of a cell, hard breaks are kept.
+func (r *Renderer) cellString(cell *ast.TableCell) string {
+	saved := r.inline
+	r.inline = &bytes.Buffer{}
+	for _, child := range cell.GetChildren() {
+		ast.WalkFunc(child, func(node ast.Node, entering bool) ast.WalkStatus {
+			return r.renderInline(r.inline, node, entering)
+		})
+	}
+	s := r.inline.String()
+	r.inline = saved
+	return s
+}
+
+// separator returns a line separating rows, it uses c as the horizontal line.
+func separator(c string, widths []int) string {
+	s := "+"
+	for _, w := range widths {
+		s += strings.Repeat(c, w+2) + "+"
+	}
+	return s
+}
+
+// tableRow returns the lines of a row, cells are wrapped to the width of their column(s).
+func (r *Renderer) tableRow(rw row, widths []int, aligns []ast.CellAlignFlags) []string {
+	var (
+		cells  [][]string
+		colw   []int
+		height int
+	)
+	col := 0
+	for i, cell := range rw.cells {
+		width := -3
+		for j := col; j < col+rw.spans[i] && j < len(widths); j++ {
+			width += widths[j] + 3
+		}
+		width = max(width, 1)
+		lines := wrap(cell, width)
+		for j := range lines {
+			align := ast.TableAlignmentLeft
+			if col < len(aligns) {
+				align = aligns[col]
+			}
+			lines[j] = pad(lines[j], width, align)
+		}
+		cells = append(cells, lines)
+		colw = append(colw, width)
+		height = max(height, len(lines))
+		col += rw.spans[i]
+	}
+	// Rows with less cells than columns are filled up with empty cells.
+	for ; col < len(widths); col++ {
+		cells = append(cells, nil)
+		colw = append(colw, widths[col])
+	}
+
+	lines := make([]string, height)
+	for l := range lines {
+		s := "|"
+		for i := range cells {
+			text := strings.Repeat(" ", colw[i])
+			if l < len(cells[i]) {
+				text = cells[i][l]
+			}
+			s += " " + text + " |"
+		}
+		lines[l] = s
+	}
+	return lines
+}
+
+// pad pads s to width according to align.
+func pad(s string, width int, align ast.CellAlignFlags) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s
+	}
+	switch align {
+	case ast.TableAlignmentRight:
+		return strings.Repeat(" ", n) + s
+	case ast.TableAlignmentCenter:
+		return strings.Repeat(" ", n/2) + s + strings.Repeat(" ", n-n/2)
+	}
+	return s + strings.Repeat(" ", n)
+}
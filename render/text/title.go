@@ -0,0 +1,180 @@
+package text
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// seriesName returns the name of the document used in the page header, "RFC 9999" or "Internet-Draft".
+func (r *Renderer) seriesName() string {
+	if r.Title.Draft() {
+		return "Internet-Draft"
+	}
+	return "RFC " + r.Title.SeriesInfo.Value
+}
+
+// status returns the status of the document, i.e. "Experimental".
+func (r *Renderer) status() string {
+	return r.Title.StatusName()
+}
+
+// surnames returns the surnames of the authors for the page footer: "Gieben", "Gieben & Ietf" or "Gieben, et al.".
+func (r *Renderer) surnames() string {
+	a := r.Title.Author
+	switch len(a) {
+	case 0:
+		return ""
+	case 1:
+		return a[0].Surname
+	case 2:
+		return a[0].Surname + " & " + a[1].Surname
+	}
+	return a[0].Surname + ", " + r.opts.Language.EtAl()
+}
+
+// frontPage returns the blocks of the first page: a left column with the workgroup and the document's status,
+// a right column with the authors and the date, followed by the title.
+func (r *Renderer) frontPage() []*block {
+	if r.Title == nil {
+		return nil
+	}
+	t := r.Title
+
+	left := []string{}
+	if t.Workgroup != "" {
+		left = append(left, t.Workgroup)
+	}
+	if r.Title.Draft() {
+		left = append(left, "Internet-Draft")
+	} else {
+		left = append(left, "Request for Comments: "+t.SeriesInfo.Value)
+	}
+	if len(t.Obsoletes) > 0 {
+		left = append(left, "Obsoletes: "+rfcNumbers(t.Obsoletes))
+	}
+	if len(t.Updates) > 0 {
+		left = append(left, "Updates: "+rfcNumbers(t.Updates))
+	}
+	if status := r.status(); status != "" {
+		if r.Title.Draft() {
+			left = append(left, "Intended status: "+status)
+		} else {
+			left = append(left, "Category: "+status)
+		}
+	}
+	if r.Title.Draft() && !t.Date.IsZero() {
+		left = append(left, "Expires: "+r.expires())
+	}
+
+	right := []string{}
+	for i, a := range t.Author {
+		name := a.Surname
+		if a.Initials != "" {
+			name = a.Initials + " " + name
+		}
+		if name == "" {
+			name = a.Fullname
+		}
+		right = append(right, name)
+		org := a.OrganizationAbbrev
+		if org == "" {
+			org = a.Organization
+		}
+		// Consecutive authors of the same organization share a line.
+		if org != "" && (i == len(t.Author)-1 || !sameOrganization(a, t.Author[i+1])) {
+			right = append(right, org)
+		}
+	}
+	right = append(right, r.date(true))
+
+	b := &block{toc: -1}
+	for i := 0; i < len(left) || i < len(right); i++ {
+		l, rr := "", ""
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			rr = right[i]
+		}
+		pad := Width - utf8.RuneCountInString(l) - utf8.RuneCountInString(rr)
+		b.lines = append(b.lines, strings.TrimRight(l+strings.Repeat(" ", max(1, pad))+rr, " "))
+	}
+
+	title := &block{space: true, toc: -1}
+	title.lines = append(title.lines, "", "")
+	for _, l := range wrap(t.Title, Width-10) {
+		title.lines = append(title.lines, center(l, Width))
+	}
+	if r.Title.Draft() && t.SeriesInfo.Value != "" {
+		title.lines = append(title.lines, center(t.SeriesInfo.Value, Width))
+	}
+	return []*block{b, title}
+}
+
+func sameOrganization(a, b mast.Author) bool {
+	return a.Organization == b.Organization && a.OrganizationAbbrev == b.OrganizationAbbrev
+}
+
+// rfcNumbers returns the RFC numbers in is as a comma separated list.
+func rfcNumbers(is []int) string {
+	s := make([]string, len(is))
+	for i := range is {
+		s[i] = strconv.Itoa(is[i])
+	}
+	return strings.Join(s, ", ")
+}
+
+// authors adds the Authors' Addresses section.
+func (r *Renderer) authors() {
+	if r.Title == nil || len(r.Title.Author) == 0 {
+		return
+	}
+	heading := "Author's Address"
+	if len(r.Title.Author) > 1 {
+		heading = "Authors' Addresses"
+	}
+	r.section(1, "", heading)
+	for _, a := range r.Title.Author {
+		lines := []string{a.Fullname}
+		if a.Organization != "" {
+			lines = append(lines, a.Organization)
+		}
+		p := a.Address.Postal
+		lines = append(lines, nonEmpty(p.Street)...)
+		lines = append(lines, p.Streets...)
+		lines = append(lines, p.PostalLine...)
+		if city := strings.TrimSpace(strings.Join(nonEmpty(p.City, p.Region, p.Code), " ")); city != "" {
+			lines = append(lines, city)
+		}
+		lines = append(lines, nonEmpty(p.Country)...)
+		lines = append(lines, p.Countries...)
+		if a.Address.Phone != "" {
+			lines = append(lines, "Phone: "+a.Address.Phone)
+		}
+		for _, e := range append(nonEmpty(a.Address.Email), a.Address.Emails...) {
+			lines = append(lines, "Email: "+e)
+		}
+		if a.Address.URI != "" {
+			lines = append(lines, "URI:   "+a.Address.URI)
+		}
+		b := &block{space: true, keep: true, toc: -1}
+		for _, l := range lines {
+			b.lines = append(b.lines, r.indent()+l)
+		}
+		r.blocks = append(r.blocks, b)
+	}
+}
+
+// nonEmpty returns the strings in s that aren't empty.
+func nonEmpty(s ...string) []string {
+	ne := []string{}
+	for _, x := range s {
+		if x != "" {
+			ne = append(ne, x)
+		}
+	}
+	return ne
+}
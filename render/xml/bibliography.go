@@ -8,6 +8,7 @@ import (
 
 	"github.com/gomarkdown/markdown/ast"
 	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mast/reference"
 )
 
 func (r *Renderer) bibliographyWrapper(w io.Writer, node *mast.BibliographyWrapper, entering bool) {
@@ -54,44 +55,36 @@ func (r *Renderer) bibliographyItem(w io.Writer, node *mast.BibliographyItem) {
 	}
 
 	if node.ReferenceGroup != nil {
+		data, _ := xml.MarshalIndent(node.ReferenceGroup, "", "  ")
+		r.out(w, data)
+		r.cr(w)
+		return
+	}
+
+	if node.Raw != nil {
 		// output this raw
-		r.out(w, node.ReferenceGroup)
+		r.out(w, node.Raw)
 		r.cr(w)
 		return
 	}
 
 	tag := ""
+	_, file := reference.BibXML(string(node.Anchor))
 	switch {
 	case bytes.HasPrefix(node.Anchor, []byte("RFC")):
-		tag = makeXiInclude(BibRFC, fmt.Sprintf("reference.RFC.%s.xml", node.Anchor[3:]))
+		tag = makeXiInclude(BibRFC, file)
 
 	case bytes.HasPrefix(node.Anchor, []byte("W3C.")):
-		tag = makeXiInclude(BibW3C, fmt.Sprintf("reference.W3C.%s.xml", node.Anchor[4:]))
+		tag = makeXiInclude(BibW3C, file)
 
 	case bytes.HasPrefix(node.Anchor, []byte("BCP")):
-		tag = makeXiInclude(BibBCP, fmt.Sprintf("reference.BCP.%s.xml", node.Anchor[3:]))
+		tag = makeXiInclude(BibBCP, file)
 
 	case bytes.HasPrefix(node.Anchor, []byte("STD")):
-		tag = makeXiInclude(BibSTD, fmt.Sprintf("reference.STD.%s.xml", node.Anchor[3:]))
+		tag = makeXiInclude(BibSTD, file)
 
 	case bytes.HasPrefix(node.Anchor, []byte("I-D.")):
-		hash := bytes.Index(node.Anchor, []byte("#"))
-		draft := ""
-		if hash > 0 {
-			// no version: https://bib.ietf.org/public/rfc/bibxml3/reference.I-D.brzozowski-dhc-dhcvp6-leasequery.xml
-			//
-			// with version: https://bib.ietf.org/public/rfc/bibxml3/reference.I-D.draft-brzozowski-dhc-dhcvp6-leasequery-00.xml
-			//
-			// rewrite # to - and we have our link, and also include "draft-" before for it xi:include
-			// the anchor text from the reference is: anchor="I-D.brzozowski-dhc-dhcvp6-leasequery"
-			// problem here is that the original xref includes #00, which isn't the case in the reference
-			// any more.
-
-			draft = "draft-"
-			node.Anchor[hash] = '-'
-			defer func() { node.Anchor[hash] = '#' }() // never know if this will be used again
-		}
-		tag = makeXiInclude(BibID, fmt.Sprintf("reference.I-D.%s%s.xml", draft, node.Anchor[4:]))
+		tag = makeXiInclude(BibID, file)
 	}
 	r.outs(w, tag)
 	r.cr(w)
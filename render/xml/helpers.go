@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/gomarkdown/markdown/ast"
@@ -152,6 +153,45 @@ func Attributes(keys, values []string) (s []string) {
 	return s
 }
 
+// blockAttrs returns the block level attributes of node, each type set as key="value". It is
+// html.BlockAttrs, but the ID is output as an anchor.
+func blockAttrs(node ast.Node) []string {
+	var attr *ast.Attribute
+	if c := node.AsContainer(); c != nil && c.Attribute != nil {
+		attr = c.Attribute
+	}
+	if l := node.AsLeaf(); l != nil && l.Attribute != nil {
+		attr = l.Attribute
+	}
+	if attr == nil {
+		return nil
+	}
+
+	var s []string
+	if attr.ID != nil {
+		s = append(s, fmt.Sprintf(`anchor="%s"`, attr.ID))
+	}
+
+	classes := ""
+	for _, c := range attr.Classes {
+		classes += " " + string(c)
+	}
+	if classes != "" {
+		s = append(s, fmt.Sprintf(`class="%s"`, classes[1:]))
+	}
+
+	// sort the attributes so they remain stable between runs
+	keys := []string{}
+	for k := range attr.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s = append(s, fmt.Sprintf(`%s="%s"`, k, attr.Attrs[k]))
+	}
+	return s
+}
+
 // AttributesContains checks if the attribute list contains key.
 func AttributesContains(key string, attr []string) bool {
 	check := key + `="`
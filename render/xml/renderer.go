@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"log"
 	"path"
 	"path/filepath"
 	"strconv"
@@ -13,6 +12,7 @@ import (
 
 	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/html"
+	"github.com/mmarkdown/mmark/v2/diag"
 	"github.com/mmarkdown/mmark/v2/lang"
 	"github.com/mmarkdown/mmark/v2/mast"
 )
@@ -51,6 +51,15 @@ type RendererOptions struct {
 	Generator string
 
 	Language lang.Lang // Input/Output language for the document.
+
+	// Diagnostics collects all problems found while rendering, if nil they are logged.
+	Diagnostics *diag.Diagnostics
+
+	// File is the name of the document, used in diagnostics for nodes without a known source span.
+	File string
+
+	// Sources holds the source spans of the nodes, it's used to locate diagnostics. May be nil.
+	Sources *mast.Sources
 }
 
 // Renderer implements Renderer interface for IETF XMLv3 output. See RFC 7991.
@@ -88,7 +97,6 @@ var filterFunc mast.FilterFunc = func(s string) bool {
 
 // NewRenderer creates and configures an Renderer object, which satisfies the Renderer interface.
 func NewRenderer(opts RendererOptions) *Renderer {
-	html.IDTag = "anchor"
 	if opts.Generator == "" {
 		opts.Generator = Generator
 	}
@@ -206,7 +214,7 @@ func (r *Renderer) headingEnter(w io.Writer, heading *ast.Heading) {
 	}
 
 	r.cr(w)
-	r.outTag(w, tag, html.BlockAttrs(heading))
+	r.outTag(w, tag, blockAttrs(heading))
 
 	if heading.IsSpecial && IsAbstract(heading.Literal) {
 		return
@@ -357,7 +365,7 @@ func (r *Renderer) paragraphEnter(w io.Writer, para *ast.Paragraph) {
 		return
 	}
 
-	tag := tagWithAttributes("<t", html.BlockAttrs(para))
+	tag := tagWithAttributes("<t", blockAttrs(para))
 	r.outs(w, tag)
 }
 
@@ -434,7 +442,7 @@ func (r *Renderer) listEnter(w io.Writer, nodeData *ast.List) {
 			mast.SetAttribute(nodeData, "spacing", []byte("compact"))
 		}
 	}
-	r.outTag(w, openTag, html.BlockAttrs(nodeData))
+	r.outTag(w, openTag, blockAttrs(nodeData))
 	r.cr(w)
 }
 
@@ -523,7 +531,7 @@ func (r *Renderer) codeBlock(w io.Writer, codeBlock *ast.CodeBlock) {
 	}
 
 	r.cr(w)
-	r.outTag(w, "<"+name, html.BlockAttrs(codeBlock))
+	r.outTag(w, "<"+name, blockAttrs(codeBlock))
 	callout := false
 	if r.opts.Comments != nil {
 		callout = callouts(codeBlock.Literal, r.opts.Comments)
@@ -563,7 +571,7 @@ func (r *Renderer) tableCell(w io.Writer, tableCell *ast.TableCell, entering boo
 	if ast.GetPrevNode(tableCell) == nil {
 		r.cr(w)
 	}
-	r.outTag(w, openTag, html.BlockAttrs(tableCell))
+	r.outTag(w, openTag, blockAttrs(tableCell))
 }
 
 func (r *Renderer) tableBody(w io.Writer, node *ast.TableBody, entering bool) {
@@ -666,7 +674,7 @@ func (r *Renderer) imageEnter(w io.Writer, image *ast.Image) {
 		switch ext {
 		case ".svg", ".ascii-art":
 		default:
-			log.Printf("Image extension of %q will likely create errors in XML2RFC", ext)
+			r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(image, diag.Warning, "image-extension", r.opts.File, "Image extension of %q will likely create errors in XML2RFC", ext))
 		}
 		r.outs(w, ` type="`)
 		r.outs(w, ext[1:])
@@ -750,7 +758,7 @@ func (r *Renderer) captionFigure(w io.Writer, captionFigure *ast.CaptionFigure,
 	}
 
 	r.outs(w, "<figure")
-	r.outAttr(w, html.BlockAttrs(captionFigure))
+	r.outAttr(w, blockAttrs(captionFigure))
 	r.outs(w, ">")
 
 	// Now render the caption and then *remove* it from the tree.
@@ -788,7 +796,7 @@ func (r *Renderer) table(w io.Writer, tab *ast.Table, entering bool) {
 		tab.Attribute.ID = []byte(captionFigure.HeadingID)
 	}
 
-	tag := tagWithAttributes("<table", html.BlockAttrs(tab))
+	tag := tagWithAttributes("<table", blockAttrs(tab))
 	r.outs(w, tag)
 
 	// Now render the caption if our parent is a ast.CaptionFigure
@@ -826,7 +834,7 @@ func (r *Renderer) blockQuote(w io.Writer, block *ast.BlockQuote, entering bool)
 	}
 
 	r.outs(w, "<blockquote")
-	r.outAttr(w, html.BlockAttrs(block))
+	r.outAttr(w, blockAttrs(block))
 	defer r.outs(w, ">")
 
 	// Now render the caption if our parent is a ast.CaptionFigure
@@ -876,6 +884,9 @@ func (r *Renderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast.Wal
 		r.title = node
 	case *mast.Authors:
 		// ignore
+	case *mast.TableOfContents:
+		// xml2rfc generates the table of contents.
+		return ast.SkipChildren
 	case *mast.BibliographyWrapper:
 		r.bibliographyWrapper(w, node, entering)
 	case *mast.Bibliography:
@@ -950,7 +961,7 @@ func (r *Renderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast.Wal
 	case *ast.BlockQuote:
 		r.blockQuote(w, node, entering)
 	case *ast.Aside:
-		tag := tagWithAttributes("<aside", html.BlockAttrs(node))
+		tag := tagWithAttributes("<aside", blockAttrs(node))
 		r.outOneOfCr(w, entering, tag, "</aside>")
 	case *ast.CrossReference:
 		r.crossReference(w, node, entering)
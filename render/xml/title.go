@@ -3,13 +3,13 @@ package xml
 import (
 	"fmt"
 	"io"
-	"log"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/html"
+	"github.com/mmarkdown/mmark/v2/diag"
 	"github.com/mmarkdown/mmark/v2/mast"
 	"github.com/mmarkdown/mmark/v2/mast/reference"
 )
@@ -83,7 +83,7 @@ func (r *Renderer) titleBlock(w io.Writer, t *mast.Title) {
 	r.outs(w, d.Title)
 	r.outs(w, "</title>")
 
-	r.titleSeriesInfo(w, d.SeriesInfo)
+	r.titleSeriesInfo(w, t, d.SeriesInfo)
 
 	for _, author := range d.Author {
 		r.TitleAuthor(w, author, "author")
@@ -212,19 +212,19 @@ func (r *Renderer) TitleKeyword(w io.Writer, keyword []string) {
 	}
 }
 
-// titleSeriesInfo outputs the seriesInfo from the TOML title block.
-func (r *Renderer) titleSeriesInfo(w io.Writer, s reference.SeriesInfo) {
+// titleSeriesInfo outputs the seriesInfo s from the TOML title block t.
+func (r *Renderer) titleSeriesInfo(w io.Writer, t *mast.Title, s reference.SeriesInfo) {
 	if s.Value == "" {
-		log.Printf("Empty 'value' in [seriesInfo], resulting XML may fail to parse.")
+		r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(t, diag.Warning, "seriesinfo-empty", r.opts.File, "Empty 'value' in [seriesInfo], resulting XML may fail to parse."))
 	}
 	if s.Stream == "" {
-		log.Printf("Empty 'stream' in [seriesInfo], resulting XML may fail to parse.")
+		r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(t, diag.Warning, "seriesinfo-empty", r.opts.File, "Empty 'stream' in [seriesInfo], resulting XML may fail to parse."))
 	}
 	if s.Status == "" {
-		log.Printf("Empty 'status' in [seriesInfo], resulting XML may fail to parse.")
+		r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(t, diag.Warning, "seriesinfo-empty", r.opts.File, "Empty 'status' in [seriesInfo], resulting XML may fail to parse."))
 	}
 	if s.Name == "" {
-		log.Printf("Empty 'name' in [seriesInfo], resulting XML may fail to parse.")
+		r.opts.Diagnostics.Add(r.opts.Sources.Diagnostic(t, diag.Warning, "seriesinfo-empty", r.opts.File, "Empty 'name' in [seriesInfo], resulting XML may fail to parse."))
 	}
 	attr := Attributes(
 		[]string{"value", "stream", "status", "name"},
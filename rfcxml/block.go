@@ -0,0 +1,465 @@
+package rfcxml
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// special adds a special section (abstract or note) with name and the content of el.
+func (i *importer) special(parent ast.Node, name string, el *element) {
+	heading := &ast.Heading{Level: 1, IsSpecial: true}
+	heading.Literal = []byte(name)
+	ast.AppendChild(heading, &ast.Text{Leaf: ast.Leaf{Literal: []byte(name)}})
+	ast.AppendChild(parent, heading)
+	i.blocks(parent, el, 1)
+}
+
+// section adds the heading for section and its content, level is the section's depth.
+func (i *importer) section(parent ast.Node, section *element, level int) {
+	heading := &ast.Heading{Level: level, HeadingID: section.attr("anchor")}
+	if name := section.child("name"); name != nil {
+		i.inlines(heading, name)
+	} else if title := section.attr("title"); title != "" {
+		ast.AppendChild(heading, text(title))
+	}
+	trimSpace(heading)
+	setAttributes(heading, section, "anchor", "title")
+	ast.AppendChild(parent, heading)
+	i.blocks(parent, section, level+1)
+}
+
+// blocks adds the block level elements in el to parent, level is the depth of sections found in el.
+// Character data and inline elements outside of a block are put in paragraphs.
+func (i *importer) blocks(parent ast.Node, el *element, level int) {
+	para := &ast.Paragraph{}
+	var contacts *ast.Citation // consecutive contacts are one citation, as the XML renderer made them from one
+	flush := func() {
+		trimSpace(para)
+		if len(para.Children) > 0 {
+			ast.AppendChild(parent, para)
+		}
+		para = &ast.Paragraph{}
+	}
+	for _, c := range el.children {
+		switch c := c.(type) {
+		case string:
+			if strings.TrimSpace(c) != "" {
+				contacts = nil
+			}
+			ast.AppendChild(para, text(c))
+		case *element:
+			if c.name == "contact" {
+				flush()
+				contacts = i.contact(parent, contacts, c)
+				continue
+			}
+			contacts = nil
+			if !isBlock(c) {
+				i.inline(para, c)
+				continue
+			}
+			flush()
+			i.block(parent, c, level)
+		}
+	}
+	flush()
+}
+
+// isBlock returns true if el is a block level element.
+func isBlock(el *element) bool {
+	switch el.name {
+	case "name", "references", "seriesInfo", "boilerplate", "displayreference", "section", "appendix", "t", "ul", "ol",
+		"dl", "artwork", "sourcecode", "artset", "figure", "table", "texttable", "blockquote", "aside", "contact":
+		return true
+	}
+	return false
+}
+
+// block converts the block level element el.
+func (i *importer) block(parent ast.Node, el *element, level int) {
+	switch el.name {
+	case "section", "appendix":
+		i.section(parent, el, level)
+	case "t":
+		para := &ast.Paragraph{}
+		i.inlines(para, el)
+		trimSpace(para)
+		setAttributes(para, el, "anchor")
+		if len(para.Children) > 0 {
+			ast.AppendChild(parent, para)
+		}
+	case "ul", "ol", "dl":
+		i.list(parent, el)
+	case "artwork", "sourcecode":
+		i.artwork(parent, el)
+	case "artset":
+		para := &ast.Paragraph{}
+		for _, a := range el.elements("artwork") {
+			if image := i.image(a); image != nil {
+				ast.AppendChild(para, image)
+			}
+		}
+		ast.AppendChild(parent, para)
+	case "figure":
+		i.figure(parent, el)
+	case "table", "texttable":
+		i.table(parent, el)
+	case "blockquote":
+		i.blockQuote(parent, el)
+	case "aside":
+		aside := &ast.Aside{}
+		i.blocks(aside, el, level)
+		ast.AppendChild(parent, aside)
+	case "contact":
+		// a paragraph with only contacts is rendered as such by the XML renderer.
+		para := &ast.Paragraph{}
+		i.inline(para, el)
+		ast.AppendChild(parent, para)
+	}
+	// name, references, etc. are handled elsewhere or not needed.
+}
+
+// contact adds the contact in el to citation, or to a new paragraph in parent if citation is nil. The
+// citation is returned.
+func (i *importer) contact(parent ast.Node, citation *ast.Citation, el *element) *ast.Citation {
+	para := &ast.Paragraph{}
+	i.inline(para, el)
+	c, ok := ast.GetFirstChild(para).(*ast.Citation)
+	if !ok {
+		return citation
+	}
+	if citation == nil {
+		ast.AppendChild(parent, para)
+		return c
+	}
+	citation.Destination = append(citation.Destination, c.Destination...)
+	citation.Type = append(citation.Type, c.Type...)
+	citation.Suffix = append(citation.Suffix, c.Suffix...)
+	return citation
+}
+
+func (i *importer) list(parent ast.Node, el *element) {
+	list := &ast.List{Tight: el.attr("spacing") == "compact"}
+	skip := []string{"spacing"}
+	switch el.name {
+	case "ol":
+		list.ListFlags = ast.ListTypeOrdered
+		list.Delimiter = '.'
+		list.Start, _ = strconv.Atoi(el.attr("start"))
+		if list.Start == 1 {
+			list.Start = 0
+		}
+		skip = append(skip, "start")
+		switch el.attr("type") {
+		case "%d)":
+			list.Delimiter = ')'
+			skip = append(skip, "type")
+		case "1", "%d.", "%d":
+			skip = append(skip, "type")
+		}
+	case "dl":
+		list.ListFlags = ast.ListTypeDefinition
+	}
+	setAttributes(list, el, skip...)
+
+	for _, c := range el.children {
+		child, ok := c.(*element)
+		if !ok {
+			continue
+		}
+		item := &ast.ListItem{ListFlags: list.ListFlags, Delimiter: list.Delimiter}
+		switch child.name {
+		case "li":
+		case "dt":
+			item.ListFlags |= ast.ListTypeTerm
+		case "dd":
+		default:
+			continue
+		}
+		if len(list.Children) == 0 {
+			item.ListFlags |= ast.ListItemBeginningOfList
+		}
+		i.blocks(item, child, 1)
+		if item.ListFlags&ast.ListTypeTerm != 0 {
+			// a term can only hold text.
+			para := &ast.Paragraph{}
+			i.inlines(para, child)
+			trimSpace(para)
+			item.Children = nil
+			ast.AppendChild(item, para)
+		}
+		ast.AppendChild(list, item)
+	}
+	i.spacing(list, el)
+	ast.AppendChild(parent, list)
+}
+
+// spacing sets ListItemContainsBlock on the items of list so that they render as the items in el, and the
+// list has the spacing of el when it is parsed again. The XML renderer only uses <t> in an item that holds
+// blocks, or a list. The parser sets ListItemContainsBlock on all items after the first empty line in a list. An
+// item is preceded by an empty line when the previous item holds blocks, this, or an empty line between the
+// blocks of an item, makes the list loose; the empty line before a term doesn't. If the spacing can't be made
+// to match, it is kept as an attribute or all items get blocks.
+func (i *importer) spacing(list *ast.List, el *element) {
+	var items []*element
+	for _, c := range el.children {
+		if c, ok := c.(*element); ok && (c.name == "li" || c.name == "dt" || c.name == "dd") {
+			items = append(items, c)
+		}
+	}
+	first := len(items)
+	for j, item := range items {
+		paras, lists, blocks := 0, 0, 0
+		for _, c := range item.children {
+			c, ok := c.(*element)
+			if !ok || !isBlock(c) {
+				continue
+			}
+			blocks++
+			switch c.name {
+			case "t":
+				paras++
+			case "ul", "ol", "dl":
+				lists++
+			}
+		}
+		must := blocks > paras+lists || paras > 1 || (paras == 1 && lists == 0)
+		if must || (!list.Tight && blocks > 0) {
+			first = j
+			break
+		}
+	}
+	// A definition and its term are separated by an empty line.
+	if first > 0 && first < len(items) && items[first].name == "dd" && items[first-1].name == "dt" {
+		first--
+	}
+	for _, item := range list.Children[first:] {
+		item.(*ast.ListItem).ListFlags |= ast.ListItemContainsBlock
+	}
+
+	loose := false
+	for j, item := range list.Children {
+		if item.(*ast.ListItem).ListFlags&ast.ListItemContainsBlock == 0 {
+			continue
+		}
+		if len(item.GetChildren()) > 1 {
+			loose = true
+		}
+		if j+1 < len(list.Children) && list.Children[j+1].(*ast.ListItem).ListFlags&ast.ListTypeTerm == 0 {
+			loose = true
+		}
+	}
+	switch {
+	case list.Tight && loose:
+		list.Tight = false
+		mast.AttributeInit(list)
+		mast.SetAttribute(list, "spacing", []byte(el.attr("spacing")))
+	case !list.Tight && !loose:
+		for _, item := range list.Children {
+			item.(*ast.ListItem).ListFlags |= ast.ListItemContainsBlock
+		}
+	}
+}
+
+// artwork converts <artwork> and <sourcecode> to a code block. An artwork with a src attribute is an image.
+func (i *importer) artwork(parent ast.Node, el *element) {
+	if el.attr("src") != "" {
+		para := &ast.Paragraph{}
+		if image := i.image(el); image != nil {
+			ast.AppendChild(para, image)
+		}
+		ast.AppendChild(parent, para)
+		return
+	}
+	literal := strings.TrimLeft(el.rawText(), "\n")
+	if !strings.HasSuffix(literal, "\n") {
+		literal += "\n"
+	}
+	if el.attr("type") == "math" {
+		math := &ast.MathBlock{}
+		math.Literal = []byte(literal)
+		ast.AppendChild(parent, math)
+		return
+	}
+	code := &ast.CodeBlock{IsFenced: true}
+	code.Literal = []byte(literal)
+	skip := []string{"anchor"}
+	if el.name == "sourcecode" && el.attr("type") != "" {
+		code.Info = []byte(el.attr("type"))
+		skip = append(skip, "type")
+	}
+	setAttributes(code, el, skip...)
+	if id := el.attr("anchor"); id != "" {
+		mast.AttributeInit(code)
+		code.Attribute.ID = []byte(id)
+	}
+	ast.AppendChild(parent, code)
+}
+
+func (i *importer) image(el *element) *ast.Image {
+	if el.attr("src") == "" {
+		return nil
+	}
+	image := &ast.Image{Destination: []byte(el.attr("src")), Title: []byte(el.attr("name"))}
+	if alt := el.attr("alt"); alt != "" {
+		ast.AppendChild(image, text(alt))
+	}
+	return image
+}
+
+// figure converts a figure, a figure with a name becomes a caption figure.
+func (i *importer) figure(parent ast.Node, el *element) {
+	name := el.child("name")
+	if name == nil && el.attr("title") == "" {
+		i.blocks(parent, el, 1)
+		return
+	}
+	figure := &ast.CaptionFigure{HeadingID: el.attr("anchor")}
+	i.blocks(figure, el, 1)
+	ast.AppendChild(figure, i.caption(el))
+	ast.AppendChild(parent, figure)
+}
+
+// caption returns the <name> (or title attribute) of el as a caption.
+func (i *importer) caption(el *element) *ast.Caption {
+	caption := &ast.Caption{}
+	if name := el.child("name"); name != nil {
+		i.inlines(caption, name)
+	} else {
+		ast.AppendChild(caption, text(el.attr("title")))
+	}
+	trimSpace(caption)
+	return caption
+}
+
+func (i *importer) table(parent ast.Node, el *element) {
+	table := &ast.Table{}
+	var header, body, footer ast.Node
+	row := func(section ast.Node, tr *element) {
+		r := &ast.TableRow{}
+		for _, c := range tr.children {
+			cell, ok := c.(*element)
+			if !ok || (cell.name != "td" && cell.name != "th") {
+				continue
+			}
+			tc := &ast.TableCell{IsHeader: cell.name == "th"}
+			switch cell.attr("align") {
+			case "left":
+				tc.Align = ast.TableAlignmentLeft
+			case "right":
+				tc.Align = ast.TableAlignmentRight
+			case "center":
+				tc.Align = ast.TableAlignmentCenter
+			}
+			tc.ColSpan, _ = strconv.Atoi(cell.attr("colspan"))
+			i.inlines(tc, cell)
+			trimSpace(tc)
+			ast.AppendChild(r, tc)
+		}
+		ast.AppendChild(section, r)
+	}
+	for _, c := range el.children {
+		child, ok := c.(*element)
+		if !ok {
+			continue
+		}
+		switch child.name {
+		case "thead":
+			if header == nil {
+				header = &ast.TableHeader{}
+			}
+			for _, tr := range child.elements("tr") {
+				row(header, tr)
+			}
+		case "tbody":
+			if body == nil {
+				body = &ast.TableBody{}
+			}
+			for _, tr := range child.elements("tr") {
+				row(body, tr)
+			}
+		case "tfoot":
+			if footer == nil {
+				footer = &ast.TableFooter{}
+			}
+			for _, tr := range child.elements("tr") {
+				row(footer, tr)
+			}
+		case "tr":
+			if body == nil {
+				body = &ast.TableBody{}
+			}
+			row(body, child)
+		}
+	}
+	for _, section := range []ast.Node{header, body, footer} {
+		if section != nil {
+			ast.AppendChild(table, section)
+		}
+	}
+
+	if el.child("name") == nil {
+		setAttributes(table, el)
+		ast.AppendChild(parent, table)
+		return
+	}
+	setAttributes(table, el, "anchor")
+	figure := &ast.CaptionFigure{HeadingID: el.attr("anchor")}
+	ast.AppendChild(figure, table)
+	ast.AppendChild(figure, i.caption(el))
+	ast.AppendChild(parent, figure)
+}
+
+// blockQuote converts a block quote, the quotedFrom attribute becomes its caption.
+func (i *importer) blockQuote(parent ast.Node, el *element) {
+	quote := &ast.BlockQuote{}
+	i.blocks(quote, el, 1)
+	setAttributes(quote, el, "quotedFrom", "anchor")
+	from := el.attr("quotedFrom")
+	if from == "" {
+		ast.AppendChild(parent, quote)
+		return
+	}
+	figure := &ast.CaptionFigure{HeadingID: el.attr("anchor")}
+	ast.AppendChild(figure, quote)
+	caption := &ast.Caption{}
+	ast.AppendChild(caption, text(from))
+	ast.AppendChild(figure, caption)
+	ast.AppendChild(parent, figure)
+}
+
+// setAttributes sets the attributes of el, except the ones in skip, as the block level attributes of
+// node. The anchor becomes the ID.
+func setAttributes(node ast.Node, el *element, skip ...string) {
+	for k, v := range el.attrs {
+		if contains(skip, k) {
+			continue
+		}
+		mast.AttributeInit(node)
+		if k == "anchor" {
+			mast.AttributeFromNode(node).ID = []byte(v)
+			continue
+		}
+		mast.SetAttribute(node, k, []byte(v))
+	}
+}
+
+func contains(s []string, x string) bool {
+	for _, y := range s {
+		if x == y {
+			return true
+		}
+	}
+	return false
+}
+
+// addBlock adds the reference in el as a reference block.
+func (i *importer) addBlock(el *element) {
+	block := &mast.ReferenceBlock{}
+	block.Literal = el.raw
+	block.Content = el.raw
+	i.refBlocks = append(i.refBlocks, block)
+}
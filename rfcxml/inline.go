@@ -0,0 +1,173 @@
+package rfcxml
+
+import (
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+func text(s string) *ast.Text {
+	return &ast.Text{Leaf: ast.Leaf{Literal: []byte(s)}}
+}
+
+// inlines adds the content of el, as inline elements, to parent.
+func (i *importer) inlines(parent ast.Node, el *element) {
+	for _, c := range el.children {
+		switch c := c.(type) {
+		case string:
+			ast.AppendChild(parent, text(c))
+		case *element:
+			i.inline(parent, c)
+		}
+	}
+}
+
+// inline adds the inline element el to parent.
+func (i *importer) inline(parent ast.Node, el *element) {
+	switch el.name {
+	case "em", "i":
+		emph := &ast.Emph{}
+		i.inlines(emph, el)
+		ast.AppendChild(parent, emph)
+	case "strong", "b", "bcp14":
+		strong := &ast.Strong{}
+		if el.name == "bcp14" {
+			ast.AppendChild(strong, text(el.text()))
+		} else {
+			i.inlines(strong, el)
+		}
+		ast.AppendChild(parent, strong)
+	case "tt":
+		// Code can't start or end with white space, it's moved outside.
+		raw := el.rawText()
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" {
+			ast.AppendChild(parent, text(raw))
+			return
+		}
+		if start := strings.Index(raw, trimmed); start > 0 {
+			ast.AppendChild(parent, text(raw[:start]))
+		}
+		code := &ast.Code{}
+		code.Literal = []byte(trimmed)
+		ast.AppendChild(parent, code)
+		if end := strings.Index(raw, trimmed) + len(trimmed); end < len(raw) {
+			ast.AppendChild(parent, text(raw[end:]))
+		}
+	case "sub":
+		sub := &ast.Subscript{}
+		sub.Literal = []byte(strings.Replace(el.text(), " ", `\ `, -1))
+		ast.AppendChild(parent, sub)
+	case "sup":
+		sup := &ast.Superscript{}
+		sup.Literal = []byte(strings.Replace(el.text(), " ", `\ `, -1))
+		ast.AppendChild(parent, sup)
+	case "br":
+		ast.AppendChild(parent, &ast.Hardbreak{})
+	case "eref":
+		link := &ast.Link{Destination: []byte(el.attr("target"))}
+		i.inlines(link, el)
+		if len(strings.TrimSpace(el.rawText())) == 0 {
+			link.Children = nil
+			ast.AppendChild(link, text(el.attr("target")))
+		}
+		ast.AppendChild(parent, link)
+	case "xref", "relref":
+		i.xref(parent, el)
+	case "iref":
+		index := &ast.Index{Primary: isTrue(el.attr("primary")), Item: []byte(el.attr("item")), Subitem: []byte(el.attr("subitem"))}
+		ast.AppendChild(parent, index)
+	case "cref":
+		// comments are kept, as HTML comments.
+		comment := &ast.HTMLSpan{}
+		comment.Literal = []byte("<!-- " + el.text() + " -->")
+		ast.AppendChild(parent, comment)
+	case "contact", "author":
+		contact := mast.Contact(author(el))
+		if contact.Fullname == "" {
+			return
+		}
+		if !i.contacts[contact.Fullname] {
+			i.contacts[contact.Fullname] = true
+			i.title.Contact = append(i.title.Contact, contact)
+		}
+		citation := &ast.Citation{Destination: [][]byte{[]byte(contact.Fullname)}, Type: []ast.CitationTypes{ast.CitationTypeInformative}, Suffix: [][]byte{nil}}
+		ast.AppendChild(parent, citation)
+	case "u", "span":
+		ast.AppendChild(parent, text(el.rawText()))
+	default:
+		i.unsupported(el)
+		i.inlines(parent, el)
+	}
+}
+
+// xref converts a reference to a citation, when it refers to a reference, or a cross reference.
+func (i *importer) xref(parent ast.Node, el *element) {
+	target := el.attr("target")
+	normative, isReference := i.references[strings.ToLower(target)]
+	if anchor, ok := i.anchors[strings.ToLower(target)]; ok {
+		target = anchor
+	}
+	if !isReference {
+		ref := &ast.CrossReference{Destination: []byte(target)}
+		switch el.attr("format") {
+		case "counter":
+			ref.Suffix = []byte(i.language.UseCounter())
+		case "title":
+			ref.Suffix = []byte(i.language.UseTitle())
+		}
+		ast.AppendChild(parent, ref)
+		return
+	}
+
+	i.cited[strings.ToLower(el.attr("target"))] = true
+	typ := ast.CitationTypeInformative
+	if normative {
+		typ = ast.CitationTypeNormative
+	}
+	suffix := ""
+	if section := el.attr("section"); section != "" {
+		// This is the reverse of what the XML renderer does with the suffix.
+		switch el.attr("sectionFormat") {
+		case "comma":
+			suffix = i.language.See() + ", " + i.language.Section() + " " + section
+		case "parens":
+			suffix = "(" + i.language.See() + ") " + i.language.Section() + " " + section
+		case "bare":
+			suffix = section
+		default:
+			suffix = i.language.Section() + " " + section
+		}
+	}
+	citation := &ast.Citation{Destination: [][]byte{[]byte(target)}, Type: []ast.CitationTypes{typ}, Suffix: [][]byte{[]byte(suffix)}}
+	ast.AppendChild(parent, citation)
+}
+
+// trimSpace removes the white space at the start and end of the text in node.
+func trimSpace(node ast.Node) {
+	children := node.GetChildren()
+	for len(children) > 0 {
+		t, ok := children[0].(*ast.Text)
+		if !ok {
+			break
+		}
+		t.Literal = []byte(strings.TrimLeft(string(t.Literal), " \t\n"))
+		if len(t.Literal) > 0 {
+			break
+		}
+		children = children[1:]
+	}
+	for len(children) > 0 {
+		t, ok := children[len(children)-1].(*ast.Text)
+		if !ok {
+			break
+		}
+		t.Literal = []byte(strings.TrimRight(string(t.Literal), " \t\n"))
+		if len(t.Literal) > 0 {
+			break
+		}
+		children = children[:len(children)-1]
+	}
+	node.SetChildren(children)
+}
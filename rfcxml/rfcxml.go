@@ -0,0 +1,338 @@
+// Package rfcxml imports RFC 7991 (xml2rfc version 3) documents. The XML is converted to an mmark AST
+// that can be rendered as mmark markdown with the markdown renderer: the <front> becomes the title
+// block, sections become headings, <xref>s to references become citations and <reference>s are kept as
+// reference blocks.
+package rfcxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mast"
+)
+
+// Options control how a document is imported.
+type Options struct {
+	// FileName is the name of the file being imported, it is only used in diagnostics.
+	FileName string
+
+	// Diagnostics collects the problems found while importing, i.e. elements that aren't supported, if
+	// nil they are logged.
+	Diagnostics *diag.Diagnostics
+}
+
+// element is an XML element, its children are either *element or string (character data).
+type element struct {
+	name     string
+	attrs    map[string]string
+	children []interface{}
+	raw      []byte // the element as it was found in the input, only set for references
+}
+
+func (e *element) attr(name string) string { return e.attrs[name] }
+
+// child returns the first child element with name, or nil if there isn't one.
+func (e *element) child(name string) *element {
+	for _, c := range e.children {
+		if el, ok := c.(*element); ok && el.name == name {
+			return el
+		}
+	}
+	return nil
+}
+
+// elements returns the child elements with name.
+func (e *element) elements(name string) []*element {
+	var els []*element
+	for _, c := range e.children {
+		if el, ok := c.(*element); ok && el.name == name {
+			els = append(els, el)
+		}
+	}
+	return els
+}
+
+// text returns all character data in e, with white space collapsed.
+func (e *element) text() string {
+	return strings.Join(strings.Fields(e.rawText()), " ")
+}
+
+// rawText returns all character data in e.
+func (e *element) rawText() string {
+	s := ""
+	for _, c := range e.children {
+		switch c := c.(type) {
+		case string:
+			s += c
+		case *element:
+			s += c.rawText()
+		}
+	}
+	return s
+}
+
+// parse parses data into a tree of elements and returns the root element.
+func parse(data []byte) (*element, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	var (
+		root  *element
+		stack []*element
+		start []int64
+	)
+	for {
+		offset := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			el := &element{name: tok.Name.Local, attrs: map[string]string{}}
+			for _, a := range tok.Attr {
+				name := a.Name.Local
+				if a.Name.Space == "xml" || a.Name.Space == "http://www.w3.org/XML/1998/namespace" {
+					name = "xml:" + name
+				}
+				el.attrs[name] = a.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			} else if root == nil {
+				root = el
+			}
+			stack = append(stack, el)
+			start = append(start, offset)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("unexpected </%s>", tok.Name.Local)
+			}
+			el := stack[len(stack)-1]
+			if el.name == "reference" || el.name == "referencegroup" {
+				el.raw = data[start[len(start)-1]:d.InputOffset()]
+			}
+			stack = stack[:len(stack)-1]
+			start = start[:len(start)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, string(tok))
+			}
+		}
+	}
+	if root == nil || root.name != "rfc" {
+		return nil, fmt.Errorf("no <rfc> element found")
+	}
+	return root, nil
+}
+
+// importer holds the state while converting a document.
+type importer struct {
+	opts     Options
+	language lang.Lang
+
+	references map[string]bool   // lowercased anchors of the references, true for normative references
+	order      []string          // anchors of the references, in document order
+	cited      map[string]bool   // lowercased anchors of the references that are cited
+	anchors    map[string]string // versioned anchors of I-Ds, keyed by the lowercased anchor without the version
+	refBlocks  []ast.Node        // reference blocks, added at the end of the document
+	title      *mast.Title
+	contacts   map[string]bool // full names of the authors and contacts in the title block
+	warned     map[string]bool // elements we've warned about
+}
+
+// Parse parses the RFC 7991 XML document in data and returns it as an mmark AST.
+func Parse(data []byte, opts Options) (ast.Node, error) {
+	root, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	language := root.attr("xml:lang")
+	if language == "" {
+		language = "en"
+	}
+	i := &importer{
+		opts:       opts,
+		language:   lang.New(language),
+		references: map[string]bool{},
+		cited:      map[string]bool{},
+		anchors:    map[string]string{},
+		contacts:   map[string]bool{},
+		warned:     map[string]bool{},
+	}
+	return i.document(root), nil
+}
+
+// unsupported warns once for each element that can't be imported.
+func (i *importer) unsupported(el *element) {
+	if i.warned[el.name] {
+		return
+	}
+	i.warned[el.name] = true
+	i.opts.Diagnostics.Warningf("import-element", i.opts.FileName, "Element <%s> is not supported, only its text is imported", el.name)
+}
+
+func (i *importer) document(root *element) ast.Node {
+	doc := &ast.Document{}
+
+	// The references must be known before the text is converted, to tell citations and cross references apart.
+	if back := root.child("back"); back != nil {
+		for _, refs := range back.elements("references") {
+			i.referencesSection(refs, false)
+		}
+	}
+
+	i.title = i.titleBlock(root)
+	ast.AppendChild(doc, i.title)
+	for _, a := range i.title.Author {
+		i.contacts[a.Fullname] = true
+	}
+
+	if front := root.child("front"); front != nil {
+		for _, c := range front.children {
+			el, ok := c.(*element)
+			if !ok {
+				continue
+			}
+			switch el.name {
+			case "abstract":
+				i.special(doc, "Abstract", el)
+			case "note":
+				name := "Note"
+				if n := el.child("name"); n != nil {
+					name = n.text()
+				} else if t := el.attr("title"); t != "" {
+					name = t
+				}
+				i.special(doc, name, el)
+			}
+		}
+	}
+
+	if middle := root.child("middle"); middle != nil {
+		ast.AppendChild(doc, &ast.DocumentMatter{Matter: ast.DocumentMatterMain})
+		i.blocks(doc, middle, 1)
+	}
+	uncited := &ast.Paragraph{}
+	ast.AppendChild(doc, uncited)
+	if back := root.child("back"); back != nil {
+		ast.AppendChild(doc, &ast.DocumentMatter{Matter: ast.DocumentMatterBack})
+		i.blocks(doc, back, 1)
+	}
+	i.uncited(uncited)
+	if len(uncited.Children) == 0 {
+		ast.RemoveFromTree(uncited)
+	}
+	for _, b := range i.refBlocks {
+		ast.AppendChild(doc, b)
+	}
+	return doc
+}
+
+// referencesSection records the anchors of the references in refs, the references are normative when the
+// section's name says so.
+func (i *importer) referencesSection(refs *element, normative bool) {
+	name := ""
+	if n := refs.child("name"); n != nil {
+		name = n.text()
+	} else {
+		name = refs.attr("title")
+	}
+	if strings.Contains(strings.ToLower(name), "normative") && !strings.Contains(strings.ToLower(name), "informative") {
+		normative = true
+	}
+	for _, c := range refs.children {
+		el, ok := c.(*element)
+		if !ok {
+			continue
+		}
+		switch el.name {
+		case "references":
+			i.referencesSection(el, normative)
+		case "reference", "referencegroup":
+			anchor := el.attr("anchor")
+			if anchor == "" {
+				continue
+			}
+			i.references[strings.ToLower(anchor)] = normative
+			i.order = append(i.order, anchor)
+			i.addBlock(el)
+		case "include":
+			anchor := includeAnchor(el.attr("href"))
+			if anchor == "" {
+				continue
+			}
+			// The XML renderer removes the version of a draft from the target of an xref.
+			if hash := strings.Index(anchor, "#"); hash > 0 {
+				i.anchors[strings.ToLower(anchor[:hash])] = anchor
+				anchor = anchor[:hash]
+			}
+			i.references[strings.ToLower(anchor)] = normative
+			i.order = append(i.order, anchor)
+		}
+	}
+}
+
+// uncited adds a citation that is not shown for each reference that isn't cited to para, otherwise they are
+// left out of the references. These can only be informative.
+func (i *importer) uncited(para *ast.Paragraph) {
+	citation := &ast.Citation{}
+	for _, anchor := range i.order {
+		if i.cited[strings.ToLower(anchor)] {
+			continue
+		}
+		if i.references[strings.ToLower(anchor)] {
+			i.opts.Diagnostics.Warningf("import-reference", i.opts.FileName, "Normative reference %q is not cited and is left out", anchor)
+			continue
+		}
+		citation.Destination = append(citation.Destination, []byte(anchor))
+		citation.Type = append(citation.Type, ast.CitationTypeSuppressed)
+		citation.Suffix = append(citation.Suffix, nil)
+	}
+	if len(citation.Destination) > 0 {
+		ast.AppendChild(para, citation)
+	}
+}
+
+// includeAnchor returns the anchor of the reference included from href, i.e. "RFC2119" for
+// "https://bib.ietf.org/public/rfc/bibxml/reference.RFC.2119.xml". This is the reverse of reference.BibXML.
+func includeAnchor(href string) string {
+	file := href[strings.LastIndex(href, "/")+1:]
+	if !strings.HasPrefix(file, "reference.") || !strings.HasSuffix(file, ".xml") {
+		return ""
+	}
+	file = strings.TrimSuffix(strings.TrimPrefix(file, "reference."), ".xml")
+	dot := strings.Index(file, ".")
+	if dot < 0 {
+		return ""
+	}
+	series, name := file[:dot], file[dot+1:]
+	switch series {
+	case "RFC", "BCP", "STD":
+		return series + name
+	case "W3C":
+		return series + "." + name
+	case "I-D":
+		// A versioned draft, "draft-ietf-foo-02", is cited as "I-D.ietf-foo#02".
+		if strings.HasPrefix(name, "draft-") {
+			name = name[len("draft-"):]
+			if dash := strings.LastIndex(name, "-"); dash > 0 {
+				name = name[:dash] + "#" + name[dash+1:]
+			}
+		}
+		return series + "." + name
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package rfcxml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/mmarkdown/mmark/v2/diag"
+	"github.com/mmarkdown/mmark/v2/lang"
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/mparser"
+	mmarkdown "github.com/mmarkdown/mmark/v2/render/markdown"
+	"github.com/mmarkdown/mmark/v2/render/xml"
+)
+
+func TestIncludeAnchor(t *testing.T) {
+	tests := []struct {
+		href string
+		want string
+	}{
+		{"https://bib.ietf.org/public/rfc/bibxml/reference.RFC.2119.xml", "RFC2119"},
+		{"https://bib.ietf.org/public/rfc/bibxml/reference.RFC.0791.xml", "RFC0791"},
+		{"https://bib.ietf.org/public/rfc/bibxml3/reference.I-D.draft-ietf-foo-bar-02.xml", "I-D.ietf-foo-bar#02"},
+		{"https://bib.ietf.org/public/rfc/bibxml3/reference.I-D.ietf-foo-bar.xml", "I-D.ietf-foo-bar"},
+		{"https://example.org/other.xml", ""},
+	}
+	for _, tc := range tests {
+		if got := includeAnchor(tc.href); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.href, tc.want, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	const doc = `<rfc category="info" docName="draft-foo-00" submissionType="IETF">
+<front><title abbrev="Foo">The Foo</title><date year="2020" month="March"/></front>
+<middle>
+<section anchor="intro"><name>Intro</name>
+<t>See <xref target="RFC2119"/> and <xref target="intro"/>, <blink>now</blink>.</t>
+</section>
+</middle>
+<back>
+<references><name>Normative References</name>
+<reference anchor="RFC2119"><front><title>Key words</title></front></reference>
+</references>
+</back>
+</rfc>`
+	d := diag.New()
+	doc1, err := Parse([]byte(doc), Options{Diagnostics: d})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	title := ast.GetFirstChild(doc1).(*mast.Title)
+	if title.Title != "The Foo" || title.SeriesInfo.Value != "draft-foo-00" || title.SeriesInfo.Status != "informational" {
+		t.Errorf("unexpected title block %+v", title.TitleData)
+	}
+	if title.Date.Month() != 3 {
+		t.Errorf("expected March, got %s", title.Date.Month())
+	}
+
+	var citation *ast.Citation
+	var ref *ast.CrossReference
+	var blocks int
+	ast.WalkFunc(doc1, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch n := node.(type) {
+		case *ast.Citation:
+			citation = n
+		case *ast.CrossReference:
+			ref = n
+		case *mast.ReferenceBlock:
+			blocks++
+		}
+		return ast.GoToNext
+	})
+	if citation == nil || citation.Type[0] != ast.CitationTypeNormative {
+		t.Errorf("expected a normative citation, got %+v", citation)
+	}
+	if ref == nil || string(ref.Destination) != "intro" {
+		t.Errorf("expected a cross reference to intro, got %+v", ref)
+	}
+	if blocks != 1 {
+		t.Errorf("expected 1 reference block, got %d", blocks)
+	}
+	if len(d.List()) != 1 || d.List()[0].Code != "import-element" {
+		t.Errorf("expected a warning for <blink>, got %v", d.List())
+	}
+}
+
+func TestParseNoRFC(t *testing.T) {
+	if _, err := Parse([]byte("<html></html>"), Options{}); err == nil {
+		t.Error("expected an error for a document without <rfc>")
+	}
+}
+
+// TestParseRoundTrip checks that the markdown of an imported document renders to the same XML elements.
+func TestParseRoundTrip(t *testing.T) {
+	const doc = `<rfc category="info" docName="draft-foo-00" submissionType="IETF">
+<front><title>The Foo</title></front>
+<middle>
+<section anchor="start"><name>Introduction</name>
+<table><thead><tr><th>a</th></tr></thead><tbody><tr><td>b</td></tr></tbody></table>
+<figure anchor="fig-code"><name>The code</name>
+<sourcecode type="go">x := 1</sourcecode>
+</figure>
+</section>
+</middle>
+</rfc>`
+	imported, err := Parse([]byte(doc), Options{Diagnostics: diag.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := markdown.Render(imported, mmarkdown.NewRenderer(mmarkdown.RendererOptions{Flags: mmarkdown.CommonFlags}))
+
+	p := parser.NewWithExtensions(mparser.Extensions)
+	p.Opts = parser.Options{ParserHook: mparser.Hook}
+	r := xml.NewRenderer(xml.RendererOptions{Flags: xml.CommonFlags | xml.XMLFragment, Language: lang.New("en")})
+	got := string(markdown.Render(markdown.Parse(md, p), r))
+
+	for _, want := range []string{
+		`<section anchor="start"><name>Introduction</name>`,
+		"<table>\n<thead>\n<tr>\n<th>a</th>",
+		`<figure anchor="fig-code"><name>The code`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in\n%s\nimported:\n%s", want, got, md)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package rfcxml
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmarkdown/mmark/v2/mast"
+	"github.com/mmarkdown/mmark/v2/render/xml"
+)
+
+// titleBlock returns the title block made from the <rfc> attributes and the <front>.
+func (i *importer) titleBlock(root *element) *mast.Title {
+	t := mast.NewTitle()
+	d := t.TitleData
+
+	if ipr := root.attr("ipr"); ipr != "" {
+		d.Ipr = ipr
+	}
+	d.SubmissionType = root.attr("submissionType")
+	d.Consensus = isTrue(root.attr("consensus"))
+	d.SortRefs = isTrue(root.attr("sortRefs"))
+	if x := root.attr("indexInclude"); x != "" {
+		d.IndexInclude = isTrue(x)
+	}
+	d.TocDepth, _ = strconv.Atoi(root.attr("tocDepth"))
+	d.Updates = ints(root.attr("updates"))
+	d.Obsoletes = ints(root.attr("obsoletes"))
+	if l := root.attr("xml:lang"); l != "" && l != "en" {
+		d.Language = l
+	}
+
+	front := root.child("front")
+	if front == nil {
+		front = &element{}
+	}
+	if title := front.child("title"); title != nil {
+		d.Title = title.text()
+		d.Abbrev = title.attr("abbrev")
+	}
+
+	// The first seriesInfo is used, if there isn't one it's made from the <rfc> attributes.
+	for _, si := range append(front.elements("seriesInfo"), root.elements("seriesInfo")...) {
+		d.SeriesInfo.Name = si.attr("name")
+		d.SeriesInfo.Value = si.attr("value")
+		d.SeriesInfo.Stream = si.attr("stream")
+		d.SeriesInfo.Status = si.attr("status")
+		break
+	}
+	if d.SeriesInfo.Name == "" {
+		switch {
+		case root.attr("number") != "":
+			d.SeriesInfo.Name = "RFC"
+			d.SeriesInfo.Value = root.attr("number")
+		case root.attr("docName") != "":
+			d.SeriesInfo.Name = "Internet-Draft"
+			d.SeriesInfo.Value = root.attr("docName")
+		}
+	}
+	if d.SeriesInfo.Stream == "" {
+		d.SeriesInfo.Stream = d.SubmissionType
+	}
+	if d.SeriesInfo.Status == "" {
+		for status, category := range xml.StatusToCategory {
+			// "full-standard" and "standard" map to the same category, prefer the latter.
+			if category == root.attr("category") && (d.SeriesInfo.Status == "" || status == "standard") {
+				d.SeriesInfo.Status = status
+			}
+		}
+	}
+
+	for _, a := range front.elements("author") {
+		d.Author = append(d.Author, author(a))
+	}
+	if date := front.child("date"); date != nil {
+		d.Date = parseDate(date)
+	}
+	if area := front.child("area"); area != nil {
+		d.Area = area.text()
+	}
+	if wg := front.child("workgroup"); wg != nil {
+		d.Workgroup = wg.text()
+	}
+	for _, k := range front.elements("keyword") {
+		d.Keyword = append(d.Keyword, k.text())
+	}
+	return t
+}
+
+// author returns the author or contact in a.
+func author(a *element) mast.Author {
+	au := mast.Author{
+		Initials: a.attr("initials"),
+		Surname:  a.attr("surname"),
+		Fullname: a.attr("fullname"),
+		Role:     a.attr("role"),
+	}
+	if org := a.child("organization"); org != nil {
+		au.Organization = org.text()
+		au.OrganizationAbbrev = org.attr("abbrev")
+	}
+	addr := a.child("address")
+	if addr == nil {
+		return au
+	}
+	if x := addr.child("phone"); x != nil {
+		au.Address.Phone = x.text()
+	}
+	if x := addr.child("uri"); x != nil {
+		au.Address.URI = x.text()
+	}
+	for _, x := range addr.elements("email") {
+		au.Address.Email, au.Address.Emails = plural(au.Address.Email, au.Address.Emails, x.text())
+	}
+	postal := addr.child("postal")
+	if postal == nil {
+		return au
+	}
+	p := &au.Address.Postal
+	for _, c := range postal.children {
+		el, ok := c.(*element)
+		if !ok {
+			continue
+		}
+		text := el.text()
+		switch el.name {
+		case "street":
+			p.Street, p.Streets = plural(p.Street, p.Streets, text)
+		case "city":
+			p.City, p.Cities = plural(p.City, p.Cities, text)
+		case "cityarea":
+			p.CityArea, p.CityAreas = plural(p.CityArea, p.CityAreas, text)
+		case "code":
+			p.Code, p.Codes = plural(p.Code, p.Codes, text)
+		case "country":
+			p.Country, p.Countries = plural(p.Country, p.Countries, text)
+		case "region":
+			p.Region, p.Regions = plural(p.Region, p.Regions, text)
+		case "pobox":
+			p.PoBox, p.PoBoxes = plural(p.PoBox, p.PoBoxes, text)
+		case "extaddr":
+			p.ExtAddr, p.ExtAddrs = plural(p.ExtAddr, p.ExtAddrs, text)
+		case "postalLine":
+			if text != "" {
+				p.PostalLine = append(p.PostalLine, text)
+			}
+		}
+	}
+	return au
+}
+
+// plural sets single to value if it is empty, otherwise value is added to the plural values.
+func plural(single string, plurals []string, value string) (string, []string) {
+	switch {
+	case value == "":
+	case single == "":
+		single = value
+	default:
+		plurals = append(plurals, value)
+	}
+	return single, plurals
+}
+
+// parseDate returns the date in <date>, a missing month or day defaults to the first.
+func parseDate(date *element) time.Time {
+	year, err := strconv.Atoi(date.attr("year"))
+	if err != nil {
+		return time.Time{}
+	}
+	month := time.January
+	if m := date.attr("month"); m != "" {
+		if n, err := strconv.Atoi(m); err == nil && n >= 1 && n <= 12 {
+			month = time.Month(n)
+		} else if t, err := time.Parse("January", m); err == nil {
+			month = t.Month()
+		} else if t, err := time.Parse("Jan", m); err == nil {
+			month = t.Month()
+		}
+	}
+	day, err := strconv.Atoi(date.attr("day"))
+	if err != nil || day < 1 {
+		day = 1
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func isTrue(s string) bool { return s == "true" || s == "yes" }
+
+// ints returns the numbers in the comma separated list s.
+func ints(s string) []int {
+	var is []int
+	for _, f := range strings.Split(s, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(f)); err == nil {
+			is = append(is, n)
+		}
+	}
+	return is
+}